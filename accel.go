@@ -0,0 +1,37 @@
+package main
+
+import "time"
+
+// Held pan and zoom keys speed up: each repeat of the same action within accelWindow of
+// the last adds accelRamp to the step's multiplier, up to maxAccel (a pan step of 5 grows
+// to 25). A different key or a pause starts over at 1.
+const (
+	accelWindow = 150 * time.Millisecond
+	accelRamp   = 0.5
+	maxAccel    = 5.0
+)
+
+// Acceleration tracks repeats of the last key action
+type Acceleration struct {
+	Action  Action
+	Repeats int       // Presses in a row after the first, each within accelWindow
+	Last    time.Time // When the action was last pressed
+}
+
+// accelerate records a key action pressed at now and returns the multiplier for its step
+func (m *Model) accelerate(action Action, now time.Time) float64 {
+	if action == m.Accel.Action && now.Sub(m.Accel.Last) <= accelWindow {
+		m.Accel.Repeats++
+	} else {
+		m.Accel.Repeats = 0
+	}
+	m.Accel.Action = action
+	m.Accel.Last = now
+	return min(1+accelRamp*float64(m.Accel.Repeats), maxAccel)
+}
+
+// zoomAccel tones down a pan multiplier for zooming, which compounds: held zoom keys
+// reach at most three steps per press
+func zoomAccel(accel float64) float64 {
+	return 1 + (accel-1)/2
+}
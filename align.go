@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// alignments describes what each second key of 'A' does, for the status bar
+var alignments = map[string]string{
+	"x": "Aligned",
+	"d": "Distributed",
+	"c": "Centered",
+}
+
+// AlignChildren tidies the selected node's children: "x" lines them up on the leftmost
+// child's X, "d" spaces them evenly between the topmost and the bottommost, and "c"
+// centers them vertically on the node. Each child's subtree moves with it and sibling
+// subtrees are kept at least the vertical spacing apart. When the result would overlap
+// other nodes, the children are packed the vertical spacing apart instead and the nodes
+// below the branch move down to make room.
+func (m *Model) AlignChildren(how string) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+	children := m.GetChildrenOf(node.ID)
+	if len(children) < 2 && (how != "c" || len(children) == 0) {
+		m.setStatus(StatusWarn, "Needs at least two children")
+		return
+	}
+
+	// Children keep their top-to-bottom order on screen
+	slices.SortStableFunc(children, func(a, b *Node) int { return compareFloat(a.Y, b.Y) })
+
+	overlapping := m.hasOverlaps()
+	wasDirty := m.Dirty
+	before := m.takeSnapshot("")
+	m.pushUndo(fmt.Sprintf("align children of %s", node.ID))
+
+	switch how {
+	case "x":
+		m.alignLeft(children)
+		m.stackSubtrees(children)
+	case "d":
+		m.distributeSubtrees(children)
+	case "c":
+		m.stackSubtrees(children)
+		top, _ := m.subtreeBounds(children[0].ID)
+		_, bottom := m.subtreeBounds(children[len(children)-1].ID)
+		_, center := node.GetCenter()
+		dy := math.Round(center - (top+bottom)/2)
+		for _, child := range children {
+			m.moveSubtree(child.ID, 0, dy)
+		}
+	}
+	m.invalidateSpatialIndex()
+	status := fmt.Sprintf("%s %d children of %s", alignments[how], len(children), node.ID)
+
+	if !overlapping && m.hasOverlaps() {
+		// Fall back to the minimum spacing below the first child, and push what's below
+		// the branch down by however much further the children now reach
+		m.restoreSnapshot(before)
+		node = m.Nodes[node.ID]
+		for i, child := range children {
+			children[i] = m.Nodes[child.ID]
+		}
+		_, oldBottom := m.subtreeBounds(children[len(children)-1].ID)
+		if how == "x" {
+			m.alignLeft(children)
+		}
+		m.packSubtrees(children)
+		if _, bottom := m.subtreeBounds(children[len(children)-1].ID); bottom > oldBottom {
+			m.makeRoomBelow(node, node.Y, bottom-oldBottom)
+		}
+		m.invalidateSpatialIndex()
+		status += fmt.Sprintf(" %.0f rows apart to keep clear of other nodes", m.VSpacing)
+	}
+
+	if !overlapping && m.hasOverlaps() {
+		m.restoreSnapshot(m.UndoStack[len(m.UndoStack)-1])
+		m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+		m.Dirty = wasDirty
+		m.setStatus(StatusWarn, "Can't align: the children would overlap other nodes")
+		return
+	}
+	m.setStatus(StatusInfo, status)
+}
+
+// alignLeft moves subtrees sideways so their roots all start at the leftmost one's X
+func (m *Model) alignLeft(children []*Node) {
+	left := children[0].X
+	for _, child := range children[1:] {
+		left = min(left, child.X)
+	}
+	for _, child := range children {
+		m.moveSubtree(child.ID, left-child.X, 0)
+	}
+}
+
+// stackSubtrees moves subtrees down, in order, until each starts at least the
+// vertical spacing below the one before
+func (m *Model) stackSubtrees(children []*Node) {
+	for i := 1; i < len(children); i++ {
+		_, prevBottom := m.subtreeBounds(children[i-1].ID)
+		top, _ := m.subtreeBounds(children[i].ID)
+		if overlap := prevBottom + m.VSpacing - top; overlap > 0 {
+			m.moveSubtree(children[i].ID, 0, overlap)
+		}
+	}
+}
+
+// packSubtrees stacks subtrees, in order, exactly the vertical spacing apart below the first
+func (m *Model) packSubtrees(children []*Node) {
+	for i := 1; i < len(children); i++ {
+		_, prevBottom := m.subtreeBounds(children[i-1].ID)
+		top, _ := m.subtreeBounds(children[i].ID)
+		m.moveSubtree(children[i].ID, 0, prevBottom+m.VSpacing-top)
+	}
+}
+
+// distributeSubtrees leaves equal gaps between subtrees, keeping the first and last in
+// place. When they don't fit, the gaps are the vertical spacing and the
+// last ones move down.
+func (m *Model) distributeSubtrees(children []*Node) {
+	first, _ := m.subtreeBounds(children[0].ID)
+	_, last := m.subtreeBounds(children[len(children)-1].ID)
+	used := 0.0
+	for _, child := range children {
+		top, bottom := m.subtreeBounds(child.ID)
+		used += bottom - top
+	}
+	gap := max((last-first-used)/float64(len(children)-1), m.VSpacing)
+
+	y := first
+	for _, child := range children {
+		top, bottom := m.subtreeBounds(child.ID)
+		m.moveSubtree(child.ID, 0, math.Round(y)-top)
+		y += bottom - top + gap
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// alignTestModel returns a model whose root has two children far below it, with a
+// floating node in the way of centering them on the root
+func alignTestModel(t *testing.T) (m Model, children []string) {
+	m = newTestModel(t)
+	root := m.Nodes["0"]
+	root.X, root.Y = 0, 10
+	for _, y := range []float64{30, 40} {
+		id := addTestChild(&m, "0", "child")
+		child := m.Nodes[id]
+		child.X, child.Y, child.Width, child.Height = 40, y, 10, 3
+		children = append(children, id)
+	}
+	m.Nodes["float"] = rectNode("float", 40, 6, 10, 10)
+	m.invalidateSpatialIndex()
+	return m, children
+}
+
+func TestAlignFallsBackToMinimumSpacing(t *testing.T) {
+	m, children := alignTestModel(t)
+	m.Selected = "0"
+	m = press(m, "A", "c")
+
+	if strings.HasPrefix(m.StatusMsg, "Can't") {
+		t.Fatalf("alignment refused: %s", m.StatusMsg)
+	}
+	if m.hasOverlaps() {
+		t.Error("alignment left overlapping nodes")
+	}
+	first, second := m.Nodes[children[0]], m.Nodes[children[1]]
+	if first.Y != 30 {
+		t.Errorf("first child moved to y %v, want it to stay at 30", first.Y)
+	}
+	if gap := second.Y - (first.Y + float64(first.Height)); gap != m.VSpacing {
+		t.Errorf("children %v rows apart, want the vertical spacing %v", gap, m.VSpacing)
+	}
+	if len(m.UndoStack) == 0 {
+		t.Error("fallback alignment can't be undone")
+	}
+}
+
+func TestAlignWithRoomCenters(t *testing.T) {
+	m, children := alignTestModel(t)
+	delete(m.Nodes, "float")
+	m.invalidateSpatialIndex()
+	m.Selected = "0"
+	m = press(m, "A", "c")
+
+	top := m.Nodes[children[0]].Y
+	last := m.Nodes[children[1]]
+	_, center := m.Nodes["0"].GetCenter()
+	if mid := (top + last.Y + float64(last.Height)) / 2; mid < center-1 || mid > center+1 {
+		t.Errorf("children centered on y %v, want about %v", mid, center)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Layouts: right grows every branch to the right of the root; balanced puts new
+// top-level branches on whichever side of the root is shorter, and R splits them evenly.
+// Either way, branches to the left of the root grow leftward.
+const (
+	LayoutRight    = "right"
+	LayoutBalanced = "balanced"
+)
+
+// layouts lists the layouts :set layout takes
+var layouts = []string{LayoutRight, LayoutBalanced}
+
+// SetLayout changes which sides of the root new branches go on for this map. Nodes stay
+// where they are until the map is re-laid out.
+func (m *Model) SetLayout(layout string) {
+	if !slices.Contains(layouts, layout) {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown layout %q (use right or balanced)", layout))
+		return
+	}
+	if layout == LayoutRight {
+		layout = "" // The default isn't written to the file
+	}
+	if layout != m.Layout {
+		m.Layout = layout
+		m.Dirty = true // The layout is saved with the map
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Layout: %s (R re-lays out the map)", m.layoutName()))
+}
+
+// layoutName returns the current layout's name, with the default spelled out
+func (m *Model) layoutName() string {
+	if m.Layout == "" {
+		return LayoutRight
+	}
+	return m.Layout
+}
+
+// onLeft reports whether a node is in a branch to the left of the root. The side is the
+// top-level branch's: its center is left of the root's. The root and floating trees are
+// on the right.
+func (m *Model) onLeft(node *Node) bool {
+	root := m.Nodes["0"]
+	if root == nil {
+		return false
+	}
+	visited := make(map[string]bool)
+	for node.ParentID != root.ID && !visited[node.ID] {
+		visited[node.ID] = true // Guard against parent cycles
+		node = m.Nodes[node.ParentID]
+		if node == nil {
+			return false // Reached the root or a floating node
+		}
+	}
+	nodeX, _ := node.GetCenter()
+	rootX, _ := root.GetCenter()
+	return nodeX < rootX
+}
+
+// newBranchOnLeft reports whether a new child of the root goes on the left: in a balanced
+// map, when the branches there take up less height than those on the right
+func (m *Model) newBranchOnLeft() bool {
+	if m.Layout != LayoutBalanced {
+		return false
+	}
+	var heights [2]float64
+	for _, child := range m.GetChildrenOf("0") {
+		top, bottom := m.subtreeBounds(child.ID)
+		side := 0
+		if m.onLeft(child) {
+			side = 1
+		}
+		heights[side] += bottom - top + m.VSpacing
+	}
+	return heights[1] < heights[0]
+}
+
+// sides splits sibling nodes into those on the right of the root and those on the left,
+// keeping their order
+func (m *Model) sides(nodes []*Node) (right, left []*Node) {
+	for _, node := range nodes {
+		if m.onLeft(node) {
+			left = append(left, node)
+		} else {
+			right = append(right, node)
+		}
+	}
+	return right, left
+}
+
+// balanceSides splits the root's children for a balanced layout, handing each in turn to
+// the side whose stack is shorter so far (the right on a tie)
+func (m *Model) balanceSides(children []*Node, heights map[string]float64) (right, left []*Node) {
+	for _, child := range children {
+		if stackHeight(left, heights, m.VSpacing) < stackHeight(right, heights, m.VSpacing) {
+			left = append(left, child)
+		} else {
+			right = append(right, child)
+		}
+	}
+	return right, left
+}
+
+// stackHeight returns the height of subtree bands stacked gap apart
+func stackHeight(nodes []*Node, heights map[string]float64, gap float64) float64 {
+	total := 0.0
+	for i, node := range nodes {
+		if i > 0 {
+			total += gap
+		}
+		total += heights[node.ID]
+	}
+	return total
+}
@@ -2,6 +2,12 @@ package main
 
 import "math"
 
+// Zoom limits shared by all zoom operations
+const (
+	minZoom = 0.25
+	maxZoom = 4.0
+)
+
 // Camera represents the viewport into the world space
 type Camera struct {
 	X    float64 `json:"x"`    // Camera position in world space
@@ -59,18 +65,36 @@ func (c *Camera) Pan(dx, dy float64) {
 
 // ZoomIn increases the zoom level (sets target for smooth movement)
 func (c *Camera) ZoomIn() {
-	c.TargetZoom *= 1.2
-	if c.TargetZoom > 4.0 {
-		c.TargetZoom = 4.0
-	}
+	c.ZoomAt(1.2, c.TargetX, c.TargetY)
 }
 
 // ZoomOut decreases the zoom level (sets target for smooth movement)
 func (c *Camera) ZoomOut() {
-	c.TargetZoom *= 0.8
-	if c.TargetZoom < 0.25 {
-		c.TargetZoom = 0.25
-	}
+	c.ZoomAt(0.8, c.TargetX, c.TargetY)
+}
+
+// ZoomAt scales the target zoom by factor and moves the target so that the
+// world point (wx, wy) ends up where it was on screen
+func (c *Camera) ZoomAt(factor, wx, wy float64) {
+	oldZoom := c.TargetZoom
+	c.TargetZoom = math.Max(minZoom, math.Min(maxZoom, oldZoom*factor))
+
+	ratio := oldZoom / c.TargetZoom
+	c.TargetX = wx - (wx-c.TargetX)*ratio
+	c.TargetY = wy - (wy-c.TargetY)*ratio
+}
+
+// FitBounds sets the camera targets so the world rectangle fits the screen with a margin
+func (c *Camera) FitBounds(minX, minY, maxX, maxY float64, screenWidth, screenHeight int) {
+	const margin = 2.0 // Cells kept free on each side
+
+	c.TargetX = (minX + maxX) / 2
+	c.TargetY = (minY + maxY) / 2
+
+	availW := float64(screenWidth) - margin*2
+	availH := float64(screenHeight) - margin*2
+	zoom := math.Min(availW/(maxX-minX), availH/(maxY-minY))
+	c.TargetZoom = math.Max(minZoom, math.Min(maxZoom, zoom))
 }
 
 // GetViewportCenter returns the world coordinates of the viewport center
@@ -84,6 +108,11 @@ func (c *Camera) IsVisible(wx, wy float64, screenWidth, screenHeight int) bool {
 	return sx >= 0 && sx < screenWidth && sy >= 0 && sy < screenHeight
 }
 
+// IsMoving reports whether the camera hasn't reached its target position and zoom
+func (c *Camera) IsMoving() bool {
+	return c.X != c.TargetX || c.Y != c.TargetY || c.Zoom != c.TargetZoom
+}
+
 // Update smoothly interpolates the camera towards its target position and zoom
 // smoothness controls how smooth the movement is (0.0-1.0, where higher = smoother but slower)
 // Returns true if the camera is still moving
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Obsidian canvases measure in pixels; a character cell is roughly this many pixels
+const (
+	canvasCellWidth  = 10.0
+	canvasCellHeight = 24.0
+)
+
+// canvasAttrPrefix marks node attributes that hold canvas properties we don't use,
+// as raw JSON, so they can be written back on export
+const canvasAttrPrefix = "canvas."
+
+// canvasPresets are the colors Obsidian shows for its numbered preset colors
+var canvasPresets = map[string]string{
+	"1": "#FB464C", // Red
+	"2": "#E9973F", // Orange
+	"3": "#E0DE71", // Yellow
+	"4": "#44CF6E", // Green
+	"5": "#53DFDD", // Cyan
+	"6": "#A882FF", // Purple
+}
+
+// canvasNode holds the node properties we translate; everything else is kept as is
+type canvasNode struct {
+	ID     string  `json:"id"`
+	Type   string  `json:"type"`
+	Text   string  `json:"text,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Color  string  `json:"color,omitempty"`
+}
+
+// canvasEdge holds the edge properties we translate
+type canvasEdge struct {
+	ID       string `json:"id"`
+	FromNode string `json:"fromNode"`
+	FromSide string `json:"fromSide,omitempty"`
+	ToNode   string `json:"toNode"`
+	ToSide   string `json:"toSide,omitempty"`
+}
+
+// canvasFile is a JSON Canvas document; each entry is decoded further on its own
+type canvasFile struct {
+	Nodes []map[string]json.RawMessage `json:"nodes"`
+	Edges []map[string]json.RawMessage `json:"edges"`
+}
+
+// canvasLabelFields name the property shown as text for non-text canvas nodes
+var canvasLabelFields = map[string]string{"file": "file", "link": "url", "group": "label"}
+
+// ExportCanvas writes the map as an Obsidian JSON Canvas. Positions are scaled from
+// character cells to pixels, and properties kept from an imported canvas are written back.
+func (m *Model) ExportCanvas(filename string) error {
+	m.finishLayoutAnimation()
+
+	ids := make([]string, 0, len(m.Nodes))
+	for id := range m.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return compareIDs(ids[i], ids[j]) < 0 })
+
+	doc := struct {
+		Nodes []json.RawMessage `json:"nodes"`
+		Edges []json.RawMessage `json:"edges"`
+	}{Nodes: []json.RawMessage{}, Edges: []json.RawMessage{}}
+
+	for _, id := range ids {
+		node := m.Nodes[id]
+		entry := canvasNode{
+			ID:     node.ID,
+			Type:   "text",
+			X:      math.Round(node.X * canvasCellWidth),
+			Y:      math.Round(node.Y * canvasCellHeight),
+			Width:  float64(node.Width) * canvasCellWidth,
+			Height: float64(node.Height) * canvasCellHeight,
+			Color:  canvasColor(node.Color),
+		}
+		extras := canvasExtras(node.Attrs, "")
+		if raw, ok := extras["type"]; ok {
+			json.Unmarshal(raw, &entry.Type)
+			delete(extras, "type")
+		}
+		if entry.Type == "text" {
+			entry.Text = node.Text
+		}
+		raw, err := withExtras(entry, extras)
+		if err != nil {
+			return err
+		}
+		doc.Nodes = append(doc.Nodes, raw)
+	}
+
+	used := make(map[string]bool)
+	for _, edge := range m.Edges {
+		from, to := m.Nodes[edge.FromID], m.Nodes[edge.ToID]
+		if from == nil || to == nil {
+			continue
+		}
+		entry := canvasEdge{FromNode: from.ID, ToNode: to.ID}
+		entry.FromSide, entry.ToSide = canvasSides(from, to)
+		extras := canvasExtras(from.Attrs, "edge."+to.ID)
+		if raw, ok := extras["id"]; ok {
+			json.Unmarshal(raw, &entry.ID)
+			delete(extras, "id")
+		}
+		if entry.ID == "" || used[entry.ID] {
+			entry.ID = fmt.Sprintf("%s-%s", from.ID, to.ID)
+			for n := 2; used[entry.ID]; n++ {
+				entry.ID = fmt.Sprintf("%s-%s-%d", from.ID, to.ID, n)
+			}
+		}
+		used[entry.ID] = true
+		raw, err := withExtras(entry, extras)
+		if err != nil {
+			return err
+		}
+		doc.Edges = append(doc.Edges, raw)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// ImportCanvas replaces the mind map with the contents of an Obsidian JSON Canvas.
+// Canvas IDs become node IDs, and each node's first incoming edge that doesn't
+// form a loop makes it a child; the other edges become cross-links.
+func (m *Model) ImportCanvas(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var doc canvasFile
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("%s is not a valid canvas: %v", filepath.Base(filename), err)
+	}
+	if len(doc.Nodes) == 0 {
+		return fmt.Errorf("no nodes found in %s", filename)
+	}
+
+	nodes := make(map[string]*Node, len(doc.Nodes))
+	var order []string
+	for _, fields := range doc.Nodes {
+		var entry canvasNode
+		if err := decodeCanvasEntry(fields, &entry); err != nil {
+			return fmt.Errorf("bad canvas node: %v", err)
+		}
+		if entry.ID == "" || nodes[entry.ID] != nil {
+			continue
+		}
+
+		text := entry.Text
+		if entry.Type != "text" {
+			// Keep the type and show the file, link or group label as the text
+			fields["type"], _ = json.Marshal(entry.Type)
+			if name, ok := canvasLabelFields[entry.Type]; ok {
+				json.Unmarshal(fields[name], &text)
+			}
+			if text == "" {
+				text = entry.Type
+			}
+		}
+
+		node := NewNode(entry.ID, text, entry.X/canvasCellWidth, entry.Y/canvasCellHeight, m.WrapWidth)
+		node.Color = importCanvasColor(entry.Color)
+		for name, value := range fields {
+			if node.Attrs == nil {
+				node.Attrs = make(map[string]string)
+			}
+			node.Attrs[canvasAttrPrefix+name] = string(value)
+		}
+		nodes[entry.ID] = node
+		order = append(order, entry.ID)
+	}
+	if len(order) == 0 {
+		return fmt.Errorf("no nodes found in %s", filename)
+	}
+
+	m.Nodes = nodes
+	m.Edges = make([]Edge, 0)
+	m.ExtraFields = nil
+	m.Marks = nil
+	m.LoadWarning = ""
+	for _, fields := range doc.Edges {
+		var entry canvasEdge
+		if err := decodeCanvasEntry(fields, &entry); err != nil {
+			return fmt.Errorf("bad canvas edge: %v", err)
+		}
+		from, to := nodes[entry.FromNode], nodes[entry.ToNode]
+		if from == nil || to == nil || from == to {
+			continue
+		}
+
+		// Sides are recomputed from the layout on export; the rest is kept on the source node
+		fields["id"], _ = json.Marshal(entry.ID)
+		extras, _ := json.Marshal(fields)
+		if from.Attrs == nil {
+			from.Attrs = make(map[string]string)
+		}
+		from.Attrs[canvasAttrPrefix+"edge."+to.ID] = string(extras)
+
+		if to.ParentID == "" && !canvasHasAncestor(nodes, from, to.ID) {
+			to.ParentID = from.ID
+		}
+		m.linkNodes(from.ID, to.ID)
+	}
+
+	m.Selected = order[0]
+	if roots := m.GetRootNodes(); len(roots) > 0 {
+		m.Selected = roots[0].ID
+	}
+	m.Camera = NewCamera()
+	m.Camera.X, m.Camera.Y = m.Nodes[m.Selected].GetCenter()
+	m.Camera.TargetX, m.Camera.TargetY = m.Camera.X, m.Camera.Y
+	m.normalizeOrders() // Canvases have no sibling order, so it follows the positions
+	m.NextID = m.nextFreeID()
+	m.NextColorIndex = 0
+	m.clearHistory()
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+	m.Dirty = true // Imported content hasn't been saved as a map yet
+	return nil
+}
+
+// canvasHasAncestor reports whether ancestorID is node itself or one of its parents
+func canvasHasAncestor(nodes map[string]*Node, node *Node, ancestorID string) bool {
+	for ; node != nil; node = nodes[node.ParentID] {
+		if node.ID == ancestorID {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeCanvasEntry fills in the known properties of a canvas node or edge and
+// removes them from fields, leaving only the ones to keep as is
+func decodeCanvasEntry(fields map[string]json.RawMessage, entry any) error {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, entry); err != nil {
+		return err
+	}
+	known, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	var names map[string]json.RawMessage
+	json.Unmarshal(known, &names)
+	for name := range names {
+		delete(fields, name)
+	}
+	delete(fields, "text") // Omitted when empty, but never kept apart from the node's text
+	delete(fields, "color")
+	return nil
+}
+
+// canvasExtras collects the kept canvas properties from a node's attributes: the node's own
+// when key is empty, otherwise the ones stored under key (such as an edge's)
+func canvasExtras(attrs map[string]string, key string) map[string]json.RawMessage {
+	extras := make(map[string]json.RawMessage)
+	if key != "" {
+		json.Unmarshal([]byte(attrs[canvasAttrPrefix+key]), &extras)
+		return extras
+	}
+	for name, value := range attrs {
+		name, ok := strings.CutPrefix(name, canvasAttrPrefix)
+		if ok && !strings.HasPrefix(name, "edge.") && json.Valid([]byte(value)) {
+			extras[name] = json.RawMessage(value)
+		}
+	}
+	return extras
+}
+
+// withExtras encodes v with the extra properties added, without overriding v's own
+func withExtras(v any, extras map[string]json.RawMessage) (json.RawMessage, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil || len(extras) == 0 {
+		return encoded, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range extras {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// canvasColor converts a node color to a canvas color, using the preset number
+// for colors that came from one
+func canvasColor(color string) string {
+	for preset, hex := range canvasPresets {
+		if strings.EqualFold(color, hex) {
+			return preset
+		}
+	}
+	return color
+}
+
+// importCanvasColor converts a canvas color, a preset number or a hex color, to a node color
+func importCanvasColor(color string) string {
+	if hex, ok := canvasPresets[color]; ok {
+		return hex
+	}
+	if strings.HasPrefix(color, "#") {
+		return color
+	}
+	return ""
+}
+
+// canvasSides picks the sides an edge leaves and enters by, the way the map draws it:
+// across when the nodes are side by side, otherwise down or up
+func canvasSides(from, to *Node) (string, string) {
+	switch {
+	case to.X >= from.X+float64(from.Width):
+		return "right", "left"
+	case to.X+float64(to.Width) <= from.X:
+		return "left", "right"
+	case to.Y >= from.Y:
+		return "bottom", "top"
+	}
+	return "top", "bottom"
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// hierarchyProblem is a node whose ParentID can't be followed to a root
+type hierarchyProblem struct {
+	ID     string
+	Reason string
+}
+
+func (p hierarchyProblem) String() string {
+	return fmt.Sprintf("node %s %s", p.ID, p.Reason)
+}
+
+// hierarchyProblems finds nodes whose parent doesn't exist and parent chains that loop.
+// Each loop is reported once, at the node where walking up from the lowest ID comes back
+// around, so detaching the reported nodes leaves a proper forest.
+func (m *Model) hierarchyProblems() []hierarchyProblem {
+	ids := make([]string, 0, len(m.Nodes))
+	parents := make(map[string]string, len(m.Nodes))
+	for id, node := range m.Nodes {
+		ids = append(ids, id)
+		parents[id] = node.ParentID
+	}
+	sort.Strings(ids)
+
+	var problems []hierarchyProblem
+	for _, id := range ids {
+		if parentID := parents[id]; parentID != "" && m.Nodes[parentID] == nil {
+			problems = append(problems, hierarchyProblem{id, fmt.Sprintf("had missing parent %s", parentID)})
+			parents[id] = ""
+		}
+	}
+
+	for _, id := range ids {
+		seen := make(map[string]bool)
+		for current := id; parents[current] != ""; current = parents[current] {
+			if seen[current] {
+				problems = append(problems, hierarchyProblem{current, "was its own ancestor"})
+				parents[current] = ""
+				break
+			}
+			seen[current] = true
+		}
+	}
+	return problems
+}
+
+// repairHierarchy detaches the nodes with problems so they float free. An edge from a
+// former parent that exists is kept and shows up as a cross-link.
+func (m *Model) repairHierarchy(problems []hierarchyProblem) {
+	for _, problem := range problems {
+		if node := m.Nodes[problem.ID]; node != nil {
+			node.Order = m.nextOrder("")
+			node.ParentID = ""
+		}
+	}
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+}
+
+// describeProblems summarizes repairs for the status bar
+func describeProblems(problems []hierarchyProblem) string {
+	summary := fmt.Sprintf("detached %d nodes with broken parents (%s", len(problems), problems[0])
+	if len(problems) > 1 {
+		summary += fmt.Sprintf(", %d more", len(problems)-1)
+	}
+	return summary + ")"
+}
+
+// maxCheckDetails is how many repairs of each kind the :check report lists one by one
+const maxCheckDetails = 5
+
+// CheckMap validates the map, as after editing the JSON by hand or merging it, repairs
+// what it finds broken as one undo step and lists the repairs in an overlay. It covers
+// parent references, edges and links to missing nodes, repeated and self-linking edges,
+// and children without an edge from their parent.
+func (m *Model) CheckMap() {
+	wasDirty := m.Dirty
+	m.pushUndo("check")
+
+	report := []string{fmt.Sprintf("%d nodes, %d edges", len(m.Nodes), len(m.Edges)), ""}
+	add := func(summary string, details []string) {
+		report = append(report, summary)
+		for i, detail := range details {
+			if i == maxCheckDetails {
+				report = append(report, fmt.Sprintf("  … %d more", len(details)-i))
+				break
+			}
+			report = append(report, "  "+detail)
+		}
+	}
+
+	if problems := m.hierarchyProblems(); len(problems) > 0 {
+		m.repairHierarchy(problems)
+		details := make([]string, len(problems))
+		for i, problem := range problems {
+			details[i] = problem.String()
+		}
+		add(fmt.Sprintf("Detached %d nodes with broken parents", len(problems)), details)
+	}
+	if dangling, links := m.dropDanglingEdges(); len(dangling) > 0 || links > 0 {
+		if len(dangling) > 0 {
+			add(fmt.Sprintf("Removed %d dangling edges", len(dangling)), edgeList(dangling))
+		}
+		if links > 0 {
+			add(fmt.Sprintf("Removed %d links to missing nodes", links), nil)
+		}
+	}
+	if dropped := m.dropBadEdges(); dropped > 0 {
+		add(fmt.Sprintf("Removed %d duplicate or self-linking edges", dropped), nil)
+	}
+	if added := m.addMissingParentEdges(); len(added) > 0 {
+		add(fmt.Sprintf("Added %d missing parent edges", len(added)), edgeList(added))
+	}
+
+	if len(report) == 2 {
+		m.restoreSnapshot(m.UndoStack[len(m.UndoStack)-1])
+		m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+		m.Dirty = wasDirty
+		m.setStatus(StatusInfo, fmt.Sprintf("Check: %s, no problems found", report[0]))
+		return
+	}
+	m.CheckReport = report
+	m.ShowCheck = true
+	m.setStatus(StatusInfo, "Check: repaired the map (u undoes the repairs)")
+}
+
+// edgeList describes edges as "from → to", one per entry
+func edgeList(edges []Edge) []string {
+	list := make([]string, len(edges))
+	for i, edge := range edges {
+		list[i] = fmt.Sprintf("%s → %s", edge.FromID, edge.ToID)
+	}
+	return list
+}
+
+// loadWarningSuffix returns the repairs made by the last load, ready to append to a
+// status message, or "" if the file was fine
+func (m *Model) loadWarningSuffix() string {
+	if m.LoadWarning == "" {
+		return ""
+	}
+	return "; " + m.LoadWarning
+}
+
+// loadStatusLevel is the level of the message reporting a load: a warning when it made repairs
+func (m *Model) loadStatusLevel() StatusLevel {
+	if m.LoadWarning == "" {
+		return StatusInfo
+	}
+	return StatusWarn
+}
+
+// dropBadEdges removes edges from a node to itself and repeats of an edge, which older
+// files may have, along with their entries in the nodes' links. It returns how many edges went.
+func (m *Model) dropBadEdges() int {
+	seen := make(map[Edge]bool, len(m.Edges))
+	kept := m.Edges[:0]
+	for _, edge := range m.Edges {
+		if edge.FromID == edge.ToID || seen[edge] {
+			continue
+		}
+		seen[edge] = true
+		kept = append(kept, edge)
+	}
+	dropped := len(m.Edges) - len(kept)
+	m.Edges = kept
+
+	for _, node := range m.Nodes {
+		linked := make(map[string]bool, len(node.Links))
+		node.Links = slices.DeleteFunc(node.Links, func(id string) bool {
+			drop := id == node.ID || linked[id]
+			linked[id] = true
+			return drop
+		})
+	}
+	return dropped
+}
+
+// dropDanglingEdges removes edges with an end that isn't a node, and links to missing
+// nodes, as a hand-edited or merged file may have. It returns the removed edges and how
+// many links went.
+func (m *Model) dropDanglingEdges() (dangling []Edge, links int) {
+	m.Edges = slices.DeleteFunc(m.Edges, func(edge Edge) bool {
+		if m.Nodes[edge.FromID] == nil || m.Nodes[edge.ToID] == nil {
+			dangling = append(dangling, edge)
+			return true
+		}
+		return false
+	})
+	for _, node := range m.Nodes {
+		before := len(node.Links)
+		node.Links = slices.DeleteFunc(node.Links, func(id string) bool { return m.Nodes[id] == nil })
+		links += before - len(node.Links)
+	}
+	if len(dangling) > 0 || links > 0 {
+		m.invalidateSpatialIndex()
+	}
+	return dangling, links
+}
+
+// addMissingParentEdges adds the edge from a parent to each child that lacks one,
+// in ID order, and returns the added edges
+func (m *Model) addMissingParentEdges() []Edge {
+	nodes := make([]*Node, 0, len(m.Nodes))
+	for _, node := range m.Nodes {
+		nodes = append(nodes, node)
+	}
+	slices.SortFunc(nodes, func(a, b *Node) int { return compareIDs(a.ID, b.ID) })
+
+	var added []Edge
+	for _, node := range nodes {
+		if node.ParentID != "" && m.Nodes[node.ParentID] != nil && !m.hasEdge(node.ParentID, node.ID) {
+			m.linkNodes(node.ParentID, node.ID)
+			added = append(added, Edge{FromID: node.ParentID, ToID: node.ID})
+		}
+	}
+	return added
+}
+
+// handleCheckKey closes the :check report on any key
+func (m Model) handleCheckKey() (tea.Model, tea.Cmd) {
+	m.ShowCheck = false
+	m.CheckReport = nil
+	return m, nil
+}
+
+// renderCheckOverlay lists the repairs :check made
+func (m Model) renderCheckOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	summaryStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	lines := []string{titleStyle.Render("🩺 Check"), ""}
+	for i, line := range m.CheckReport {
+		switch {
+		case i == 0 || strings.HasPrefix(line, " "):
+			lines = append(lines, descStyle.Render(line))
+		default:
+			lines = append(lines, summaryStyle.Render(line))
+		}
+	}
+	lines = append(lines, "", footerStyle.Render("Press any key to close"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
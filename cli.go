@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runConvert implements "convert --from <file> --to <file>", converting between formats
+// without starting the UI. It returns the process exit code.
+func runConvert(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "input: a map (.json), an outline (.opml, .md, .txt), a canvas (.canvas) or a FreeMind map (.mm)")
+	to := fs.String("to", "", "output: format chosen by extension (.json, .md, .opml, .canvas, .png)")
+	node := fs.String("node", "", "write only this node and its descendants")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" || *to == "" || fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: terminalnode convert --from <file> --to <file> [--node <id>]")
+		return 2
+	}
+
+	m := NewModel()
+	if err := m.OpenFile(*from); err != nil {
+		fmt.Fprintf(stderr, "Error loading %s: %v\n", *from, err)
+		return 1
+	}
+	if m.LoadWarning != "" {
+		fmt.Fprintf(stderr, "Warning: %s: %s\n", *from, m.LoadWarning)
+	}
+	export := m.ExportTo
+	if *node != "" {
+		export = func(path string) error { return m.ExportSubtree(*node, path) }
+	}
+	if err := export(*to); err != nil {
+		if isBackupError(err) {
+			fmt.Fprintf(stderr, "Warning: %v\n", err)
+			return 0
+		}
+		fmt.Fprintf(stderr, "Error writing %s: %v\n", *to, err)
+		return 1
+	}
+	return 0
+}
+
+// runAdd implements "add [-f file] [--under id] <text>", appending a child node to a map
+// without starting the UI and printing the new node's ID. It returns the process exit code.
+func runAdd(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("f", "mindmap.json", "map to add the node to")
+	under := fs.String("under", "0", "ID of the parent node")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if text == "" {
+		fmt.Fprintln(stderr, "usage: terminalnode add [-f file] [--under id] <text>")
+		return 2
+	}
+
+	m := NewModel()
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: reading config: %v\n", err)
+	}
+	m.Config = cfg
+	if validWrapWidth(cfg.WrapWidth) {
+		m.WrapWidth = cfg.WrapWidth
+	}
+	m.useConfigSpacing(cfg)
+
+	// Remember what was on disk so a concurrent save isn't silently overwritten
+	original, err := os.ReadFile(*file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(stderr, "Error reading %s: %v\n", *file, err)
+		return 1
+	}
+	if err == nil {
+		if err := m.LoadFromFile(*file); err != nil {
+			fmt.Fprintf(stderr, "Error loading %s: %v\n", *file, err)
+			return 1
+		}
+		if m.LoadWarning != "" {
+			fmt.Fprintf(stderr, "Warning: %s: %s\n", *file, m.LoadWarning)
+		}
+	}
+
+	parent := m.Nodes[*under]
+	if parent == nil {
+		fmt.Fprintf(stderr, "Error: %s has no node with ID %s\n", *file, *under)
+		return 1
+	}
+	node := m.createNode(m.planChild(parent), text)
+
+	current, err := os.ReadFile(*file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) || !bytes.Equal(current, original) {
+		fmt.Fprintf(stderr, "Error: %s changed while adding the node; not saving\n", *file)
+		return 1
+	}
+	if err := m.SaveToFile(*file); err != nil {
+		if !isBackupError(err) {
+			fmt.Fprintf(stderr, "Error writing %s: %v\n", *file, err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Fprintln(stdout, node.ID)
+	return 0
+}
+
+// ExportTo writes the map to path in the format given by its extension
+func (m *Model) ExportTo(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return m.SaveToFile(path)
+	case ".md", ".markdown":
+		return m.ExportMarkdown(path)
+	case ".opml":
+		return m.ExportOPML(path)
+	case ".canvas":
+		return m.ExportCanvas(path)
+	case ".png":
+		return m.ExportImage(path)
+	}
+	return fmt.Errorf("unknown output format %q (use .json, .md, .opml, .canvas or .png)", filepath.Ext(path))
+}
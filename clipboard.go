@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// clipboardTool is a program that copies its input to, or prints, the system clipboard
+type clipboardTool struct {
+	Copy  []string
+	Paste []string
+}
+
+// clipboardTools are tried in order; the first one installed is used
+var clipboardTools = []clipboardTool{
+	{[]string{"pbcopy"}, []string{"pbpaste"}},
+	{[]string{"wl-copy"}, []string{"wl-paste", "--no-newline"}},
+	{[]string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}},
+	{[]string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+	{[]string{"clip.exe"}, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"}},
+}
+
+// errNoClipboard is returned when there is no way to reach the system clipboard
+var errNoClipboard = errors.New("no clipboard available")
+
+// findClipboardTool returns the first installed clipboard program. Over SSH the local
+// programs would reach the remote machine's clipboard, so none is used.
+func findClipboardTool() (clipboardTool, bool) {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return clipboardTool{}, false
+	}
+	for _, tool := range clipboardTools {
+		if _, err := exec.LookPath(tool.Copy[0]); err == nil {
+			return tool, true
+		}
+	}
+	return clipboardTool{}, false
+}
+
+// writeClipboard copies text to the system clipboard with a clipboard program, or else
+// asks the terminal to do it with an OSC 52 escape sequence. It returns how it was copied.
+func writeClipboard(text string) (string, error) {
+	if tool, ok := findClipboardTool(); ok {
+		cmd := exec.Command(tool.Copy[0], tool.Copy[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s failed: %v", tool.Copy[0], err)
+		}
+		return tool.Copy[0], nil
+	}
+
+	// Terminals that don't support OSC 52 ignore it, so this can't tell whether it worked
+	if info, err := os.Stdout.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", errNoClipboard
+	}
+	termenv.NewOutput(os.Stdout).Copy(text)
+	return "the terminal", nil
+}
+
+// readClipboard returns the system clipboard's text. Only clipboard programs can read it;
+// over SSH, the terminal's own paste still works in edit mode.
+func readClipboard() (string, error) {
+	tool, ok := findClipboardTool()
+	if !ok {
+		return "", errNoClipboard
+	}
+	if _, err := exec.LookPath(tool.Paste[0]); err != nil {
+		return "", errNoClipboard
+	}
+	out, err := exec.Command(tool.Paste[0], tool.Paste[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v", tool.Paste[0], err)
+	}
+	return string(out), nil
+}
+
+// YankSelected copies the selected node's text, or its whole branch as an indented
+// Markdown outline, to the system clipboard
+func (m *Model) YankSelected(branch bool) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+
+	text, what := node.Text, fmt.Sprintf("node %s", node.ID)
+	if branch {
+		var sb strings.Builder
+		m.writeMarkdownNode(&sb, node, 0, make(map[string]bool))
+		text = strings.TrimSuffix(sb.String(), "\n")
+		what = fmt.Sprintf("branch of %s (%d nodes)", node.ID, len(m.GetDescendantsOf(node.ID))+1)
+	}
+
+	via, err := writeClipboard(text)
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Couldn't copy: %v", err))
+		return
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Copied %s via %s", what, via))
+}
+
+// pasteIntoEdit inserts text at the edit cursor, keeping line breaks
+func (m *Model) pasteIntoEdit(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	runes := []rune(m.EditBuffer)
+	cursor := min(max(m.EditCursor, 0), len(runes))
+	insert := []rune(text)
+	m.EditBuffer = string(insertRunes(runes, cursor, insert))
+	m.EditCursor = cursor + len(insert)
+}
+
+// PasteClipboard inserts the system clipboard's text into the edit buffer
+func (m *Model) PasteClipboard() {
+	text, err := readClipboard()
+	if errors.Is(err, errNoClipboard) {
+		m.setStatus(StatusWarn, "No clipboard program found; paste with your terminal instead")
+		return
+	}
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Couldn't paste: %v", err))
+		return
+	}
+	m.pasteIntoEdit(strings.TrimRight(text, "\r\n"))
+}
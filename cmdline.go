@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxCommandHistory is how many previous command lines up/down can bring back
+const maxCommandHistory = 100
+
+// commandNames lists the commands Tab completes, in the order they are offered
+var commandNames = []string{
+	"check", "coloring", "delete", "edit", "export", "filter", "goto", "image", "import",
+	"marks", "messages", "recent", "set", "sort", "tag", "task", "template", "theme", "trash", "untangle", "wrap", "write",
+}
+
+// pathCommands take a file path as their last argument
+var pathCommands = map[string]bool{
+	"w": true, "w!": true, "write": true, "write!": true,
+	"e": true, "e!": true, "edit": true, "edit!": true,
+	"import": true, "image": true,
+}
+
+// setOptions are the options :set shows and changes, each with the command that changes it
+var setOptions = map[string]func(m *Model, value string){
+	"wrap":     (*Model).commandWrap,
+	"theme":    (*Model).commandTheme,
+	"coloring": (*Model).SetColorMode,
+	"align":    (*Model).SetTextAlign,
+	"layout":   (*Model).SetLayout,
+	"hspace":   (*Model).commandHSpace,
+	"vspace":   (*Model).commandVSpace,
+}
+
+// recordCommand adds a command line to the history, skipping a repeat of the last one
+func (m *Model) recordCommand(line string) {
+	m.CommandHistoryBack = 0
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(m.CommandHistory); n > 0 && m.CommandHistory[n-1] == line {
+		return
+	}
+	m.CommandHistory = append(m.CommandHistory, line)
+	if len(m.CommandHistory) > maxCommandHistory {
+		m.CommandHistory = m.CommandHistory[1:]
+	}
+}
+
+// browseCommandHistory replaces the command line with an older (dir < 0) or newer (dir > 0)
+// one. Going past the newest brings back what was being typed.
+func (m *Model) browseCommandHistory(dir int) {
+	back := m.CommandHistoryBack - dir
+	if back < 0 || back > len(m.CommandHistory) {
+		return
+	}
+	if m.CommandHistoryBack == 0 {
+		m.CommandDraft = m.CommandBuffer
+	}
+	m.CommandHistoryBack = back
+	if back == 0 {
+		m.CommandBuffer = m.CommandDraft
+	} else {
+		m.CommandBuffer = m.CommandHistory[len(m.CommandHistory)-back]
+	}
+}
+
+// completeCommand completes the word being typed: a command name, a file path,
+// a :set option or a tag. Several matches are listed in the status bar.
+func (m *Model) completeCommand(line string) string {
+	fields := strings.Fields(line)
+	start := strings.LastIndex(line, " ") + 1
+	word := line[start:]
+
+	var matches []string
+	switch {
+	case len(fields) <= 1 && start == 0:
+		matches = matchPrefix(commandNames, word)
+	case fields[0] == "tag" || fields[0] == "filter":
+		return m.completeTag(line)
+	case fields[0] == "set":
+		names := make([]string, 0, len(setOptions))
+		for name := range setOptions {
+			names = append(names, name+"=")
+		}
+		sort.Strings(names)
+		matches = matchPrefix(names, word)
+	case fields[0] == "export":
+		// A format comes first, after an optional "branch", then the path
+		before := strings.Fields(line[:start])[1:]
+		formats := []string{"branch", "canvas", "json", "md", "opml", "png"}
+		if len(before) > 0 && before[0] == "branch" {
+			before, formats = before[1:], formats[1:]
+		}
+		if len(before) == 0 {
+			matches = matchPrefix(formats, word)
+		} else {
+			matches = completePath(word)
+		}
+	case pathCommands[fields[0]]:
+		matches = completePath(word)
+	}
+
+	if len(matches) == 0 {
+		m.setStatus(StatusWarn, "No completions")
+		return line
+	}
+	completion := commonPrefix(matches)
+	if len(matches) > 1 {
+		m.setPrompt(strings.Join(matches, "  "))
+	} else if !strings.HasSuffix(completion, "/") && !strings.HasSuffix(completion, "=") {
+		completion += " "
+	}
+	return line[:start] + completion
+}
+
+// matchPrefix returns the words that start with prefix
+func matchPrefix(words []string, prefix string) []string {
+	var matches []string
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			matches = append(matches, word)
+		}
+	}
+	return matches
+}
+
+// completePath lists the files and directories starting with prefix, directories ending in '/'.
+// Hidden files are only offered once a '.' has been typed.
+func completePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return nil
+	}
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		matches = append(matches, dir+name)
+	}
+	return matches
+}
+
+// commonPrefix returns the longest prefix shared by all the words
+func commonPrefix(words []string) string {
+	common := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	return common
+}
+
+// commandSet handles ":set [option=value]...". An option without a value shows it,
+// and no options shows them all.
+func (m *Model) commandSet(args []string) {
+	if len(args) == 0 {
+		m.setStatus(StatusInfo, fmt.Sprintf("wrap=%d theme=%s coloring=%s align=%s layout=%s hspace=%g vspace=%g",
+			m.WrapWidth, m.Theme.Name, m.colorModeName(), m.textAlignName(), m.layoutName(), m.HSpacing, m.VSpacing))
+		return
+	}
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		set, ok := setOptions[name]
+		if !ok {
+			m.setStatus(StatusWarn, fmt.Sprintf("Unknown option: %s (use wrap, theme, coloring, align, layout, hspace or vspace)", name))
+			return
+		}
+		if !hasValue {
+			m.commandSet(nil)
+			return
+		}
+		if value == "" {
+			m.setStatus(StatusWarn, fmt.Sprintf("Usage: :set %s=<value>", name))
+			return
+		}
+		set(m, value)
+	}
+}
+
+// commandEdit handles ":e[!] [file]", opening a map or importing an outline in place of the
+// current one (the current file again without a name). Unsaved changes need the '!'.
+func (m *Model) commandEdit(path string, force bool) {
+	if m.Dirty && !force {
+		m.setStatus(StatusWarn, "Unsaved changes (save with :w, or use :e! to discard them)")
+		return
+	}
+	if path == "" {
+		path = m.FileName()
+	}
+	if err := m.OpenFile(path); err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error loading: %v", err))
+		return
+	}
+	m.setStatus(m.loadStatusLevel(), fmt.Sprintf("Loaded from %s", path)+m.loadWarningSuffix())
+	m.rememberFile()
+	m.offerRecovery()
+}
+
+// commandDelete handles ":delete [id]", deleting a node and its subtree (the selected one by default)
+func (m *Model) commandDelete(id string) {
+	if id == "" {
+		id = m.Selected
+	}
+	if m.Nodes[id] == nil {
+		m.setStatus(StatusWarn, fmt.Sprintf("No node with ID %s", id))
+		return
+	}
+	m.DeleteNode(id)
+}
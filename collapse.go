@@ -0,0 +1,107 @@
+package main
+
+import "fmt"
+
+// visibleAncestor returns the outermost collapsed ancestor hiding a node, or the node
+// itself if nothing hides it
+func (m *Model) visibleAncestor(id string) *Node {
+	visible := m.Nodes[id]
+	if visible == nil {
+		return nil
+	}
+	visited := map[string]bool{id: true}
+	for node := m.Nodes[visible.ParentID]; node != nil && !visited[node.ID]; node = m.Nodes[node.ParentID] {
+		if node.Collapsed {
+			visible = node
+		}
+		visited[node.ID] = true
+	}
+	return visible
+}
+
+// isHidden reports whether a node is inside a collapsed branch
+func (m *Model) isHidden(id string) bool {
+	visible := m.visibleAncestor(id)
+	return visible != nil && visible.ID != id
+}
+
+// expandTo expands the collapsed ancestors of a node, so that jumping to it shows it
+func (m *Model) expandTo(id string) {
+	if !m.isHidden(id) {
+		return
+	}
+	visited := make(map[string]bool)
+	for node := m.Nodes[m.Nodes[id].ParentID]; node != nil && !visited[node.ID]; node = m.Nodes[node.ParentID] {
+		node.Collapsed = false
+		visited[node.ID] = true
+	}
+	m.collapseChanged()
+}
+
+// collapseChanged updates what depends on which nodes are shown. If the selected node
+// was hidden, the nearest ancestor still shown is selected and centered instead.
+func (m *Model) collapseChanged() {
+	m.invalidateSpatialIndex()
+	m.Dirty = true
+	if visible := m.visibleAncestor(m.Selected); visible != nil && visible.ID != m.Selected {
+		m.Selected = visible.ID
+		m.centerOn(visible)
+	}
+}
+
+// ToggleCollapse hides or shows the selected node's descendants
+func (m *Model) ToggleCollapse() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+	if len(m.GetChildrenOf(node.ID)) == 0 && !node.Collapsed {
+		m.setStatus(StatusWarn, "No children to collapse")
+		return
+	}
+	node.Collapsed = !node.Collapsed
+	m.collapseChanged()
+	if node.Collapsed {
+		m.setStatus(StatusInfo, fmt.Sprintf("Collapsed %d nodes", len(m.GetDescendantsOf(node.ID))))
+	} else {
+		m.setStatus(StatusInfo, "Expanded")
+	}
+}
+
+// CollapseAll collapses every node that has children
+func (m *Model) CollapseAll() {
+	m.collapseBelow(0)
+	m.setStatus(StatusInfo, "Collapsed all")
+}
+
+// ExpandAll shows every node
+func (m *Model) ExpandAll() {
+	for _, node := range m.Nodes {
+		node.Collapsed = false
+	}
+	m.collapseChanged()
+	m.setStatus(StatusInfo, "Expanded all")
+}
+
+// CollapseToLevel shows only the first levels below the root, collapsing every node
+// with children that many levels down or deeper
+func (m *Model) CollapseToLevel(levels int) {
+	m.collapseBelow(levels)
+	m.setStatus(StatusInfo, fmt.Sprintf("Showing the root and %d levels below it", levels))
+	if levels == 1 {
+		m.setStatus(StatusInfo, "Showing the root and its children")
+	}
+}
+
+// collapseBelow expands the nodes above depth and collapses those at or below it that have children
+func (m *Model) collapseBelow(depth int) {
+	parents := make(map[string]bool)
+	for _, node := range m.Nodes {
+		parents[node.ParentID] = true
+	}
+	for id, node := range m.Nodes {
+		node.Collapsed = parents[id] && m.nodeDepth(id) >= depth
+	}
+	m.collapseChanged()
+}
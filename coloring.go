@@ -0,0 +1,140 @@
+package main
+
+import "fmt"
+
+// Coloring modes: by top-level branch (the stored colors), by depth below the root, or none
+const (
+	ColorByBranch = "branch"
+	ColorByDepth  = "depth"
+	ColorNone     = "none"
+)
+
+// colorModes lists the coloring modes in the order :coloring cycles through them
+var colorModes = []string{ColorByBranch, ColorByDepth, ColorNone}
+
+// depthGradient colors nodes by level in depth mode, root first; deeper levels use the last color
+var depthGradient = []string{
+	"#E8697D", // Rose
+	"#F08A5D", // Coral
+	"#F4B860", // Amber
+	"#C9D46C", // Lime
+	"#7FCF9A", // Green
+	"#5BBFC9", // Teal
+	"#6C9BE0", // Blue
+	"#9B82DD", // Violet
+}
+
+// depthCache holds each node's distance from its root, filled in as nodes are drawn,
+// and the status bar's summary of the selected node. It is cleared whenever the tree
+// may have changed shape.
+type depthCache struct {
+	depths map[string]int
+
+	summary    string // Summary of the node summaryID, valid when hasSummary is set
+	summaryID  string
+	hasSummary bool
+}
+
+// invalidateDepths forgets cached depths after the tree changed
+func (m *Model) invalidateDepths() {
+	if m.depths != nil {
+		m.depths.depths = nil
+		m.depths.hasSummary = false
+	}
+}
+
+// nodeDepth returns how many parents a node has, caching the answer for it and its
+// ancestors. A parent cycle stops the count where it loops back.
+func (m *Model) nodeDepth(id string) int {
+	if m.depths == nil {
+		m.depths = &depthCache{}
+	}
+	if m.depths.depths == nil {
+		m.depths.depths = make(map[string]int)
+	}
+	cache := m.depths.depths
+
+	// Walk up to the root or to an ancestor whose depth is known
+	var path []string
+	seen := make(map[string]bool)
+	depth := -1
+	for node := m.Nodes[id]; node != nil && !seen[node.ID]; node = m.Nodes[node.ParentID] {
+		if d, ok := cache[node.ID]; ok {
+			depth = d
+			break
+		}
+		seen[node.ID] = true
+		path = append(path, node.ID)
+	}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		depth++
+		cache[path[i]] = depth
+	}
+	return cache[id]
+}
+
+// displayColor returns the color a node is drawn in under the current coloring mode.
+// Colors picked by hand win over any mode.
+func (m *Model) displayColor(node *Node) string {
+	if node.OwnColor {
+		return node.Color
+	}
+	switch m.ColorMode {
+	case ColorByDepth:
+		return depthGradient[min(m.nodeDepth(node.ID), len(depthGradient)-1)]
+	case ColorNone:
+		return ""
+	}
+	return node.Color
+}
+
+// displayNode returns the node as drawn: itself, or a copy in its display color
+func (m *Model) displayNode(node *Node) *Node {
+	color := m.displayColor(node)
+	if color == node.Color {
+		return node
+	}
+	colored := *node
+	colored.Color = color
+	return &colored
+}
+
+// SetColorMode switches the coloring mode; an empty name moves on to the next one
+func (m *Model) SetColorMode(mode string) {
+	if mode == "" {
+		current := 0
+		for i, name := range colorModes {
+			if name == m.ColorMode {
+				current = i
+			}
+		}
+		mode = colorModes[(current+1)%len(colorModes)]
+	}
+
+	valid := false
+	for _, name := range colorModes {
+		valid = valid || name == mode
+	}
+	if !valid {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown coloring %q (use branch, depth or none)", mode))
+		return
+	}
+
+	if mode == ColorByBranch {
+		mode = "" // The default isn't written to the file
+	}
+	if mode != m.ColorMode {
+		m.ColorMode = mode
+		m.Dirty = true // The mode is saved with the map
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Coloring: %s", m.colorModeName()))
+}
+
+// colorModeName returns the current coloring mode's name, with the default spelled out
+func (m *Model) colorModeName() string {
+	if m.ColorMode == "" {
+		return ColorByBranch
+	}
+	return m.ColorMode
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// executeCommand runs an ex-style command line such as "w notes.json"
+func (m *Model) executeCommand(line string) tea.Cmd {
+	if trimmed := strings.TrimSpace(line); isSubstitute(trimmed) {
+		m.commandSubstitute(trimmed)
+		return nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	name := fields[0]
+	arg := strings.Join(fields[1:], " ")
+
+	switch name {
+	case "w", "write":
+		m.saveAs(arg, false)
+	case "w!", "write!":
+		m.saveAs(arg, true)
+	case "e", "edit":
+		m.commandEdit(arg, false)
+	case "e!", "edit!":
+		m.commandEdit(arg, true)
+	case "import":
+		m.commandImport(arg)
+	case "export":
+		m.commandExport(fields[1:])
+	case "image":
+		return m.commandImage(arg)
+	case "tag":
+		if len(fields) < 2 {
+			m.setStatus(StatusWarn, "Usage: :tag <tag>...")
+			return nil
+		}
+		m.ToggleTags(fields[1:])
+	case "filter":
+		m.SetTagFilter(arg)
+	case "task":
+		m.ToggleTask()
+	case "theme":
+		m.commandTheme(arg)
+	case "wrap":
+		m.commandWrap(arg)
+	case "untangle":
+		m.Untangle()
+	case "check":
+		m.CheckMap()
+	case "goto":
+		m.GotoNode(arg)
+	case "marks":
+		m.ToggleMarks()
+	case "messages":
+		m.OpenMessages()
+	case "recent":
+		m.OpenRecent()
+	case "trash":
+		m.commandTrash(arg)
+	case "template":
+		m.commandTemplate(fields[1:])
+	case "coloring":
+		m.SetColorMode(arg)
+	case "sort":
+		m.commandSort(arg)
+	case "set":
+		m.commandSet(fields[1:])
+	case "d", "delete":
+		m.commandDelete(arg)
+	default:
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown command: %s", name))
+	}
+	return nil
+}
+
+// saveAs saves the map to path (or the current file if path is empty).
+// Writing over an existing file other than the current one asks first unless force is set.
+func (m *Model) saveAs(path string, force bool) {
+	if path == "" {
+		path = m.CurrentFile
+	}
+	if path == "" {
+		m.setStatus(StatusWarn, "No file name (use :w <file>)")
+		return
+	}
+
+	if !force && path != m.CurrentFile && fileExists(path) {
+		m.Mode = ModeConfirm
+		m.ConfirmAction = ConfirmOverwrite
+		m.ConfirmTarget = path
+		m.ConfirmPrompt = fmt.Sprintf("Overwrite existing file %s? [y/N]", path)
+		return
+	}
+
+	err := m.SaveToFile(path)
+	if err != nil && !isBackupError(err) {
+		m.setStatus(StatusError, fmt.Sprintf("Error: %v", err))
+		return
+	}
+	m.CurrentFile = path
+	m.rememberFile()
+	if err != nil {
+		m.setStatus(StatusWarn, fmt.Sprintf("Saved to %s, but %v", path, err))
+		return
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Saved to %s", path))
+}
+
+// exportFormats maps the formats :export takes to their exporter and file extension
+var exportFormats = map[string]struct {
+	Export func(m *Model, path string) error
+	Ext    string
+}{
+	"md":       {(*Model).ExportMarkdown, ".md"},
+	"markdown": {(*Model).ExportMarkdown, ".md"},
+	"opml":     {(*Model).ExportOPML, ".opml"},
+	"canvas":   {(*Model).ExportCanvas, ".canvas"},
+	"json":     {(*Model).ExportJSON, ".json"},
+	"png":      {(*Model).ExportImage, ".png"},
+}
+
+// commandExport handles ":export [branch] <format> [file]". With "branch", only the
+// selected node and its descendants are written.
+func (m *Model) commandExport(args []string) {
+	branch := len(args) > 0 && args[0] == "branch"
+	if branch {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		m.setStatus(StatusWarn, "Usage: :export [branch] md|opml|canvas|json|png [file]")
+		return
+	}
+
+	format, ok := exportFormats[args[0]]
+	if !ok {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown export format: %s", args[0]))
+		return
+	}
+	path := strings.Join(args[1:], " ")
+
+	source := m
+	if branch {
+		node := m.GetSelectedNode()
+		if node == nil {
+			m.setStatus(StatusWarn, "No node selected")
+			return
+		}
+		sub, err := m.subtreeMap(node.ID)
+		if err != nil {
+			m.setStatus(StatusError, fmt.Sprintf("Error exporting: %v", err))
+			return
+		}
+		source = sub
+		if path == "" {
+			path = m.exportFilename("-" + node.ID + format.Ext)
+		}
+	} else if path == "" {
+		path = m.exportFilename(format.Ext)
+	}
+
+	if err := format.Export(source, path); err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error exporting: %v", err))
+		return
+	}
+	if branch {
+		m.setStatus(StatusInfo, fmt.Sprintf("Exported %d nodes to %s", len(source.Nodes), path))
+		return
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Exported to %s", path))
+}
+
+// commandImport handles ":import <file>", reading an OPML file, an Obsidian canvas, a FreeMind map or a Markdown/plain-text outline
+func (m *Model) commandImport(path string) {
+	if path == "" {
+		m.setStatus(StatusWarn, "Usage: :import <file>")
+		return
+	}
+	if err := m.importFile(path); err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error importing: %v", err))
+		return
+	}
+	m.CurrentFile = ""
+	m.setStatus(m.loadStatusLevel(), fmt.Sprintf("Imported %d nodes from %s", len(m.Nodes), path)+m.loadWarningSuffix())
+}
+
+// fileExists reports whether a file exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// commandTheme handles ":theme [name]"; without a name it toggles dark/light
+func (m *Model) commandTheme(name string) {
+	if name == "" {
+		m.ToggleTheme()
+		return
+	}
+	theme, ok := ThemeByName(name)
+	if !ok {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown theme: %s (use dark, light or auto)", name))
+		return
+	}
+	m.SetTheme(theme)
+	m.setStatus(StatusInfo, "Theme: "+m.Theme.Name)
+}
+
+// commandWrap shows the wrap width, or sets it to the given number of columns
+func (m *Model) commandWrap(arg string) {
+	if arg == "" {
+		m.setStatus(StatusInfo, fmt.Sprintf("Wrap width: %d", m.WrapWidth))
+		return
+	}
+	width, err := strconv.Atoi(arg)
+	if err != nil {
+		m.setStatus(StatusWarn, "Usage: :wrap <columns>")
+		return
+	}
+	m.SetWrapWidth(width)
+}
+
+// commandSort handles ":sort [alpha|reverse|id]", sorting the selected node's children
+func (m *Model) commandSort(arg string) {
+	switch arg {
+	case "", "alpha", "a":
+		m.SortChildren("a")
+	case "reverse", "r":
+		m.SortChildren("r")
+	case "id", "created", "c":
+		m.SortChildren("c")
+	default:
+		m.setStatus(StatusWarn, "Usage: :sort [alpha|reverse|id]")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Config holds user settings read from the config file
+type Config struct {
+	AutosaveSeconds int    `json:"autosave_seconds"`   // Autosave interval; 0 disables autosave
+	Theme           string `json:"theme"`              // "auto", "dark" or "light"
+	Backup          bool   `json:"backup"`             // Keep the previous version as <file>.bak when saving
+	WrapWidth       int    `json:"wrap_width"`         // Widest a line of node text gets before wrapping
+	HSpacing        int    `json:"horizontal_spacing"` // Gap between a parent and its children when placing nodes
+	VSpacing        int    `json:"vertical_spacing"`   // Gap between stacked siblings when placing nodes
+	FollowSelection bool   `json:"follow_selection"`   // Start with follow mode on
+	UntangleOnLoad  bool   `json:"untangle_on_load"`   // Move overlapping nodes apart when opening a map
+	ResumeSession   bool   `json:"resume_session"`     // Reopen the last map without asking when started without a file
+	SaveOnQuit      bool   `json:"save_on_quit"`       // q saves changes to the current file instead of asking
+	DirectedLinks   bool   `json:"directed_links"`     // Allow a link back from B to A alongside A to B
+	SaveTrash       bool   `json:"save_trash"`         // Keep the trash in the map file so it survives restarts
+	SoftLimit       int    `json:"soft_limit"`         // Characters of node text before the edit counter warns; 0 never warns
+
+	Templates []Template `json:"templates"` // Outlines Ctrl+N inserts under the selected node
+}
+
+// DefaultConfig returns the settings used when there is no config file
+func DefaultConfig() Config {
+	return Config{
+		AutosaveSeconds: 60,
+		Theme:           "auto",
+		Backup:          true,
+		WrapWidth:       defaultWrapWidth,
+		HSpacing:        defaultHorizontalSpacing,
+		VSpacing:        defaultVerticalSpacing,
+		SaveOnQuit:      true,
+		SoftLimit:       defaultSoftLimit,
+	}
+}
+
+// configDir returns the directory holding terminalnode's config files
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "terminalnode"), nil
+}
+
+// LoadConfig reads config.json from the config directory.
+// A missing file or missing fields fall back to the defaults.
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+
+	dir, err := configDir()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig(), err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import "fmt"
+
+// edgesTouching returns the indices of all edges that start or end at a node
+func (m *Model) edgesTouching(id string) []int {
+	indices := make([]int, 0)
+	for i, edge := range m.Edges {
+		if edge.FromID == id || edge.ToID == id {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// currentEdge returns the index into m.Edges of the edge chosen in edge mode, or -1
+func (m *Model) currentEdge() int {
+	indices := m.edgesTouching(m.Selected)
+	if len(indices) == 0 {
+		return -1
+	}
+	return indices[m.EdgeIndex%len(indices)]
+}
+
+// cycleEdge moves the edge mode choice forward or backward
+func (m *Model) cycleEdge(offset int) {
+	n := len(m.edgesTouching(m.Selected))
+	if n == 0 {
+		return
+	}
+	m.EdgeIndex = ((m.EdgeIndex+offset)%n + n) % n
+	m.setPrompt(m.edgeStatus())
+}
+
+// edgeStatus describes the edge chosen in edge mode, e.g. "Link 2/3: 3 → 7"
+func (m *Model) edgeStatus() string {
+	idx := m.currentEdge()
+	if idx < 0 {
+		return "No links on this node"
+	}
+	edge := m.Edges[idx]
+	return fmt.Sprintf("Link %d/%d: %s → %s", m.EdgeIndex+1, len(m.edgesTouching(m.Selected)), edge.FromID, edge.ToID)
+}
+
+// DeleteEdge removes an edge. Removing a parent→child edge detaches the child from the tree.
+func (m *Model) DeleteEdge(fromID, toID string) {
+	m.pushUndo(fmt.Sprintf("delete link %s → %s", fromID, toID))
+	m.removeEdge(fromID, toID)
+	if child := m.Nodes[toID]; child != nil && child.ParentID == fromID {
+		child.Order = m.nextOrder("")
+		child.ParentID = ""
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Deleted link %s → %s", fromID, toID))
+}
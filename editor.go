@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorFinishedMsg is sent when the external editor exits
+type editorFinishedMsg struct {
+	NodeID string
+	Path   string // Temp file holding the text
+	Notes  bool   // Editing the node's notes rather than its text
+	Err    error
+}
+
+// openInEditor suspends the UI and edits a node's text, or its notes, in $EDITOR (falling back to vi)
+func (m *Model) openInEditor(node *Node, notes bool) tea.Cmd {
+	f, err := os.CreateTemp("", "terminalnode-*.txt")
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error creating temp file: %v", err))
+		return nil
+	}
+	path := f.Name()
+	text := node.Text
+	if notes {
+		text = node.Notes
+	}
+	_, err = f.WriteString(text + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.setStatus(StatusError, fmt.Sprintf("Error writing temp file: %v", err))
+		return nil
+	}
+
+	// $EDITOR may carry arguments, e.g. "code --wait"
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+
+	nodeID := node.ID
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{NodeID: nodeID, Path: path, Notes: notes, Err: err}
+	})
+}
+
+// finishEditor applies the text written by the external editor
+func (m *Model) finishEditor(msg editorFinishedMsg) {
+	defer os.Remove(msg.Path)
+
+	if msg.Err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Editor failed: %v", msg.Err))
+		return
+	}
+
+	content, err := os.ReadFile(msg.Path)
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error reading edited text: %v", err))
+		return
+	}
+
+	node := m.Nodes[msg.NodeID]
+	if node == nil {
+		m.setStatus(StatusWarn, "Node was deleted while editing")
+		return
+	}
+
+	// Editors usually append a trailing newline
+	text := strings.TrimRight(string(content), "\r\n")
+	if msg.Notes {
+		m.setNotes(node, text)
+		return
+	}
+	if strings.TrimSpace(text) == "" || text == node.Text {
+		m.setStatus(StatusInfo, "Node unchanged")
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("edit node %s", node.ID))
+	node.Text = text
+	node.UpdateSize(m.WrapWidth)
+	m.setStatus(StatusInfo, "Node updated")
+}
+
+// setNotes replaces a node's notes; blank notes remove them
+func (m *Model) setNotes(node *Node, notes string) {
+	if strings.TrimSpace(notes) == "" {
+		notes = ""
+	}
+	if notes == node.Notes {
+		m.setStatus(StatusInfo, "Notes unchanged")
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("edit notes of node %s", node.ID))
+	node.Notes = notes
+	if notes == "" {
+		m.setStatus(StatusInfo, "Notes removed")
+	} else {
+		m.setStatus(StatusInfo, "Notes updated")
+	}
+}
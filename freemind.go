@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// freeMindMap is the document element of a FreeMind or Freeplane .mm file
+type freeMindMap struct {
+	XMLName xml.Name       `xml:"map"`
+	Nodes   []freeMindNode `xml:"node"`
+}
+
+// freeMindNode is one <node> element with the attributes we carry over
+type freeMindNode struct {
+	ID            string          `xml:"ID,attr"`
+	Text          string          `xml:"TEXT,attr"`
+	LocalizedText string          `xml:"LOCALIZED_TEXT,attr"`
+	Color         string          `xml:"COLOR,attr"`
+	Position      string          `xml:"POSITION,attr"`
+	Created       string          `xml:"CREATED,attr"`
+	Modified      string          `xml:"MODIFIED,attr"`
+	Rich          []freeMindRich  `xml:"richcontent"`
+	Arrows        []freeMindArrow `xml:"arrowlink"`
+	Children      []freeMindNode  `xml:"node"`
+}
+
+// freeMindRich is a <richcontent> body: the node text itself, a note or details, as HTML
+type freeMindRich struct {
+	Type  string `xml:"TYPE,attr"`
+	Inner []byte `xml:",innerxml"`
+}
+
+// freeMindArrow is a cross-link to the node with the given ID
+type freeMindArrow struct {
+	Destination string `xml:"DESTINATION,attr"`
+}
+
+// ImportFreeMind replaces the mind map with a FreeMind/Freeplane map. Node colors, notes and
+// arrow links come along, and branches Freeplane put left of the root are placed on the left.
+func (m *Model) ImportFreeMind(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var doc freeMindMap
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("%s is not a valid FreeMind map: %v", filepath.Base(filename), err)
+	}
+	if len(doc.Nodes) == 0 {
+		return fmt.Errorf("no nodes found in %s", filename)
+	}
+
+	// Arrow links point at FreeMind IDs, which lead to the items and so to the nodes made from
+	// them. Unlike links restored by text, that works however many nodes share a text.
+	byID := make(map[string]*outlineItem)
+	var arrows []freeMindArrowFrom
+	items := freeMindItems(doc.Nodes, byID, &arrows)
+
+	rootText := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	m.buildFromOutline(rootText, items, nil)
+	missing := 0
+	for _, arrow := range arrows {
+		to, ok := byID[arrow.Destination]
+		if !ok {
+			missing++
+			continue
+		}
+		if arrow.From.Node != to.Node {
+			m.linkNodes(arrow.From.Node.ID, to.Node.ID)
+		}
+	}
+	if missing > 0 {
+		m.LoadWarning = fmt.Sprintf("dropped %s to nodes that aren't in the file", plural(missing, "arrow link"))
+	}
+	m.placeLeftBranches()
+	return nil
+}
+
+// freeMindArrowFrom is an arrow link together with the item of the node it starts at
+type freeMindArrowFrom struct {
+	From        *outlineItem
+	Destination string
+}
+
+// freeMindItems converts <node> elements into outline items, recording each item by its
+// FreeMind ID and the arrow links found along the way
+func freeMindItems(nodes []freeMindNode, byID map[string]*outlineItem, arrows *[]freeMindArrowFrom) []*outlineItem {
+	items := make([]*outlineItem, 0, len(nodes))
+	for _, node := range nodes {
+		item := &outlineItem{Text: node.Text, Color: node.Color}
+		if item.Text == "" {
+			item.Text = node.LocalizedText
+		}
+		for _, rich := range node.Rich {
+			switch strings.ToUpper(rich.Type) {
+			case "NODE":
+				if item.Text == "" {
+					item.Text = flattenHTML(rich.Inner)
+				}
+			default: // NOTE and DETAILS
+				item.Notes = strings.TrimSpace(item.Notes + "\n\n" + flattenHTML(rich.Inner))
+			}
+		}
+
+		for name, value := range map[string]string{"POSITION": node.Position, "CREATED": node.Created, "MODIFIED": node.Modified} {
+			if value != "" {
+				if item.Attrs == nil {
+					item.Attrs = make(map[string]string)
+				}
+				item.Attrs[name] = value
+			}
+		}
+
+		if node.ID != "" {
+			byID[node.ID] = item
+		}
+		for _, arrow := range node.Arrows {
+			*arrows = append(*arrows, freeMindArrowFrom{From: item, Destination: arrow.Destination})
+		}
+
+		item.Children = freeMindItems(node.Children, byID, arrows)
+		items = append(items, item)
+	}
+	return items
+}
+
+// flattenHTML turns a rich-text body into plain text, one line per paragraph, list item or break
+func flattenHTML(inner []byte) string {
+	var lines []string
+	var line strings.Builder
+	endLine := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(inner))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			line.Write(t)
+		case xml.StartElement:
+			if isHTMLBlock(t.Name.Local) {
+				endLine()
+			}
+		case xml.EndElement:
+			if isHTMLBlock(t.Name.Local) {
+				endLine()
+			}
+		}
+	}
+	endLine()
+	return strings.Join(lines, "\n")
+}
+
+// isHTMLBlock reports whether an HTML element starts a new line of text
+func isHTMLBlock(name string) bool {
+	switch strings.ToLower(name) {
+	case "p", "br", "div", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+		return true
+	}
+	return false
+}
+
+// placeLeftBranches moves the root's children marked POSITION="left", with their subtrees,
+// to the left of the root. Each side's branches are then stacked from the root's row down.
+func (m *Model) placeLeftBranches() {
+	root := m.Nodes["0"]
+	if root == nil {
+		return
+	}
+	children := m.GetChildrenOf(root.ID)
+	hasLeft := false
+	for _, child := range children {
+		hasLeft = hasLeft || child.Attrs["POSITION"] == "left"
+	}
+	if !hasLeft {
+		return
+	}
+
+	centerX, _ := root.GetCenter()
+	for _, left := range []bool{false, true} {
+		y := root.Y
+		for _, child := range children {
+			if (child.Attrs["POSITION"] == "left") != left {
+				continue
+			}
+			branch := append([]*Node{child}, m.GetDescendantsOf(child.ID)...)
+			top, bottom := child.Y, child.Y+float64(child.Height)
+			for _, node := range branch {
+				top = min(top, node.Y)
+				bottom = max(bottom, node.Y+float64(node.Height))
+			}
+			for _, node := range branch {
+				node.Y += y - top
+				if left {
+					node.X = 2*centerX - (node.X + float64(node.Width)) // Mirror around the root
+				}
+			}
+			y += bottom - top + m.VSpacing
+		}
+	}
+	m.invalidateSpatialIndex()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// importTestFreeMind writes a .mm file and imports it into a fresh model
+func importTestFreeMind(t *testing.T, doc string) Model {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "map.mm")
+	if err := os.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestModel(t)
+	if err := m.ImportFreeMind(path); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestImportFreeMindLinksNodesWithTheSameText(t *testing.T) {
+	m := importTestFreeMind(t, `<map version="1.0.1">
+<node ID="root" TEXT="Root">
+  <node ID="a" TEXT="Todo"><arrowlink DESTINATION="b"/></node>
+  <node ID="b" TEXT="Todo"/>
+  <node ID="c" TEXT="Todo"><arrowlink DESTINATION="a"/></node>
+</node>
+</map>`)
+
+	ids := make(map[string]string) // Node ID by position among the root's children
+	for i, child := range m.GetChildrenOf("0") {
+		ids[string(rune('a'+i))] = child.ID
+	}
+	want := map[[2]string]bool{{ids["a"], ids["b"]}: true, {ids["c"], ids["a"]}: true}
+	links := 0
+	for _, edge := range m.Edges {
+		if m.isChildOf(edge.ToID, edge.FromID) {
+			continue
+		}
+		links++
+		if !want[[2]string{edge.FromID, edge.ToID}] {
+			t.Errorf("unexpected link %s → %s", edge.FromID, edge.ToID)
+		}
+	}
+	if links != len(want) {
+		t.Errorf("imported %d links, want %d", links, len(want))
+	}
+	if m.LoadWarning != "" {
+		t.Errorf("warning %q for a file whose links all resolve", m.LoadWarning)
+	}
+}
+
+func TestImportFreeMindWarnsAboutMissingDestinations(t *testing.T) {
+	m := importTestFreeMind(t, `<map version="1.0.1">
+<node ID="root" TEXT="Root">
+  <node ID="a" TEXT="A"><arrowlink DESTINATION="gone"/></node>
+</node>
+</map>`)
+	if !strings.Contains(m.LoadWarning, "1 arrow link") {
+		t.Errorf("warning %q, want it to mention the dropped arrow link", m.LoadWarning)
+	}
+}
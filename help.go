@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// helpChromeRows is the height of everything in the help overlay except the key list:
+// a row of margin above and below, the border, the padding, the title and the footer
+const helpChromeRows = 2 + 2 + 2 + 2 + 2
+
+// Room an overlay's border and padding take up around its content
+const (
+	overlayChromeRows = 2 + 2
+	overlayChromeCols = 2 + 4
+)
+
+// ToggleHelp shows or hides the help overlay, starting at the top
+func (m *Model) ToggleHelp() {
+	m.ShowHelp = !m.ShowHelp
+	m.HelpScroll = 0
+}
+
+// handleHelpKey scrolls or closes the help overlay; other keys are ignored while it is open
+func (m Model) handleHelpKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	page := max(m.helpRows()-1, 1)
+	switch msg.String() {
+	case "?", "esc", "q":
+		m.ToggleHelp()
+	case "ctrl+c":
+		return m, tea.Quit
+	case "j", "down":
+		m.scrollHelp(1)
+	case "k", "up":
+		m.scrollHelp(-1)
+	case "pgdown", " ", "ctrl+f":
+		m.scrollHelp(page)
+	case "pgup", "ctrl+b":
+		m.scrollHelp(-page)
+	case "g", "home":
+		m.HelpScroll = 0
+	case "G", "end":
+		m.scrollHelp(len(m.helpLines()))
+	}
+	return m, nil
+}
+
+// scrollHelp moves the help overlay by delta lines, staying within the key list
+func (m *Model) scrollHelp(delta int) {
+	maxScroll := max(len(m.helpLines())-m.helpRows(), 0)
+	m.HelpScroll = min(max(m.HelpScroll+delta, 0), maxScroll)
+}
+
+// helpRows returns how many lines of the key list fit on screen
+func (m Model) helpRows() int {
+	return max(m.Height-helpChromeRows, 1)
+}
+
+// helpLines returns the key list, one styled line per binding under category headings
+func (m Model) helpLines() []string {
+	// Group the keymap by category, keeping the table's order
+	var categories []string
+	byCategory := make(map[string][]KeyBinding)
+	keyWidth := 0
+	for _, binding := range keymap {
+		if _, ok := byCategory[binding.Category]; !ok {
+			categories = append(categories, binding.Category)
+		}
+		byCategory[binding.Category] = append(byCategory[binding.Category], binding)
+		keyWidth = max(keyWidth, lipgloss.Width(bindingLabel(binding)))
+	}
+
+	categoryStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Edit))
+
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	var lines []string
+	for i, category := range categories {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, categoryStyle.Render(category))
+
+		for _, binding := range byCategory[category] {
+			label := bindingLabel(binding)
+			padding := strings.Repeat(" ", keyWidth-lipgloss.Width(label))
+			lines = append(lines, "  "+keyStyle.Render(label)+padding+" "+descStyle.Render(binding.Desc))
+		}
+	}
+	return lines
+}
+
+// renderHelpOverlay creates a centered help panel with keybindings. When the list is
+// taller than the screen it shows a window of it and keeps the footer in view.
+func (m Model) renderHelpOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	body, indicator := scrollWindow(m.helpLines(), m.HelpScroll, m.helpRows())
+	lines := []string{titleStyle.Render("⌨  Keybindings"), ""}
+	lines = append(lines, body...)
+	lines = append(lines, footerStyle.Render(indicator), footerStyle.Render("Press ? or Esc to close"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
+
+// scrollWindow returns the lines of body that fit in rows, starting at scroll, and a line
+// saying which part is shown (empty when all of it fits)
+func scrollWindow(body []string, scroll, rows int) ([]string, string) {
+	if len(body) <= rows {
+		return body, ""
+	}
+	scroll = min(scroll, len(body)-rows)
+	arrows := ""
+	if scroll > 0 {
+		arrows += "▲"
+	}
+	if scroll+rows < len(body) {
+		arrows += "▼"
+	}
+	indicator := fmt.Sprintf("%s %d-%d of %d, j/k to scroll", arrows, scroll+1, scroll+rows, len(body))
+	return body[scroll : scroll+rows], indicator
+}
+
+// placeOverlay draws content in a bordered box centered over the whole screen. Content
+// too big for the screen is cut off at the bottom and the right rather than wrapped.
+func (m Model) placeOverlay(content string) string {
+	lines := strings.Split(content, "\n")
+	lines = lines[:min(len(lines), max(0, m.Height-overlayChromeRows))]
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, max(0, m.Width-overlayChromeCols), "")
+	}
+	content = strings.Join(lines, "\n")
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(m.Theme.Accent)).
+		Padding(1, 2).
+		Render(content)
+
+	// Create semi-transparent background
+	bgStyle := lipgloss.NewStyle().
+		Background(lipgloss.Color(m.Theme.Overlay)).
+		Width(m.Width).
+		Height(m.Height)
+
+	// Position the box
+	positioned := lipgloss.Place(
+		m.Width,
+		m.Height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceChars(" "),
+	)
+
+	return bgStyle.Render(positioned)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hintChars are the home-row keys used to build hint labels
+const hintChars = "asdfghjkl"
+
+// startHintMode labels every node on screen so one can be selected by typing its label
+func (m *Model) startHintMode() {
+	var visible []*Node
+	for _, node := range m.Nodes {
+		if m.nodeOnScreen(node, m.Width, m.canvasHeight()) && !m.isHidden(node.ID) {
+			visible = append(visible, node)
+		}
+	}
+	if len(visible) == 0 {
+		m.setStatus(StatusWarn, "No nodes on screen")
+		return
+	}
+
+	// Label in reading order so nearby nodes get similar labels
+	sort.Slice(visible, func(i, j int) bool {
+		if visible[i].Y != visible[j].Y {
+			return visible[i].Y < visible[j].Y
+		}
+		return visible[i].X < visible[j].X
+	})
+
+	labels := hintLabels(len(visible))
+	m.HintLabels = make(map[string]string, len(visible))
+	for i, node := range visible {
+		m.HintLabels[labels[i]] = node.ID
+	}
+	m.HintInput = ""
+	m.Mode = ModeHint
+	m.clearStatus()
+}
+
+// hintLabels returns n labels: single keys when they suffice, otherwise two keys each,
+// so no label is a prefix of another
+func hintLabels(n int) []string {
+	labels := make([]string, 0, n)
+	if n <= len(hintChars) {
+		for _, ch := range hintChars[:n] {
+			labels = append(labels, string(ch))
+		}
+		return labels
+	}
+	for _, first := range hintChars {
+		for _, second := range hintChars {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(first)+string(second))
+		}
+	}
+	return labels // More nodes than labels: the rest stay unlabeled
+}
+
+// handleHintMode narrows the hints with each typed key and selects the node once a label matches
+func (m Model) handleHintMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.endHintMode()
+		m.setStatus(StatusInfo, "Cancelled")
+		return m, nil
+
+	case tea.KeyBackspace:
+		m.HintInput = dropLastRune(m.HintInput)
+		return m, nil
+
+	case tea.KeyRunes:
+		input := m.HintInput + string(msg.Runes)
+		if id, ok := m.HintLabels[input]; ok {
+			m.endHintMode()
+			if node := m.Nodes[id]; node != nil {
+				m.Selected = id
+				m.revealNode(node)
+			}
+			return m, nil
+		}
+		for label := range m.HintLabels {
+			if strings.HasPrefix(label, input) {
+				m.HintInput = input
+				return m, nil
+			}
+		}
+		m.setStatus(StatusWarn, "No hint "+input)
+	}
+	return m, nil
+}
+
+// endHintMode returns to normal mode and drops the labels
+func (m *Model) endHintMode() {
+	m.Mode = ModeNormal
+	m.HintLabels = nil
+	m.HintInput = ""
+}
+
+// drawHints draws each node's label over its top-left corner. Labels that no longer
+// match the typed keys are hidden, and the typed part of the rest is dimmed.
+func (m Model) drawHints(grid [][]ColoredCell) {
+	for label, id := range m.HintLabels {
+		node := m.Nodes[id]
+		if node == nil || !strings.HasPrefix(label, m.HintInput) {
+			continue
+		}
+		sx, sy, _, _ := m.nodeScreenRect(grid, node)
+		if sy < 0 || sy >= len(grid) {
+			continue
+		}
+		for i, ch := range label {
+			x := sx + i
+			if x < 0 || x >= len(grid[0]) {
+				continue
+			}
+			color := m.Theme.Search
+			if i < len(m.HintInput) {
+				color = m.Theme.Info
+			}
+			grid[sy][x] = ColoredCell{Char: ch, Color: color}
+		}
+	}
+}
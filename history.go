@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// maxHistory is the maximum number of undo steps kept
+const maxHistory = 100
+
+// Snapshot captures the mind map state before an operation so it can be undone
+type Snapshot struct {
+	Label          string // Description of the operation, e.g. "delete node 5"
+	Nodes          map[string]*Node
+	Edges          []Edge
+	Selected       string
+	NextID         int
+	NextColorIndex int
+	Trash          []TrashEntry // Entries are never changed in place, so sharing them is safe
+}
+
+// takeSnapshot deep-copies the current mind map state
+func (m *Model) takeSnapshot(label string) Snapshot {
+	nodes := make(map[string]*Node, len(m.Nodes))
+	for id, node := range m.Nodes {
+		nodes[id] = node.Clone()
+	}
+	edges := make([]Edge, len(m.Edges))
+	copy(edges, m.Edges)
+
+	return Snapshot{
+		Label:          label,
+		Nodes:          nodes,
+		Edges:          edges,
+		Selected:       m.Selected,
+		NextID:         m.NextID,
+		NextColorIndex: m.NextColorIndex,
+		Trash:          slices.Clone(m.Trash),
+	}
+}
+
+// restoreSnapshot replaces the mind map state with a snapshot
+func (m *Model) restoreSnapshot(s Snapshot) {
+	m.Nodes = s.Nodes
+	m.Edges = s.Edges
+	m.Selected = s.Selected
+	m.NextID = s.NextID
+	m.NextColorIndex = s.NextColorIndex
+	m.Trash = s.Trash
+	m.TrashIndex = 0
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+
+	// The wrap width may have changed since the snapshot was taken
+	m.resizeNodes()
+}
+
+// pushUndo records the current state before a mutating operation.
+// Any redo history is discarded since it no longer applies.
+func (m *Model) pushUndo(label string) {
+	if m.inBatch {
+		return // The batch already recorded the state before it started
+	}
+	m.finishLayoutAnimation()
+	m.UndoStack = append(m.UndoStack, m.takeSnapshot(label))
+	if len(m.UndoStack) > maxHistory {
+		m.UndoStack = m.UndoStack[len(m.UndoStack)-maxHistory:]
+	}
+	m.RedoStack = nil
+	m.Dirty = true
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+}
+
+// clearHistory drops all undo and redo steps
+func (m *Model) clearHistory() {
+	m.UndoStack = nil
+	m.RedoStack = nil
+}
+
+// Undo reverts the most recent operation
+func (m *Model) Undo() {
+	if len(m.UndoStack) == 0 {
+		m.setStatus(StatusWarn, "Nothing to undo")
+		return
+	}
+
+	s := m.UndoStack[len(m.UndoStack)-1]
+	m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+	m.RedoStack = append(m.RedoStack, m.takeSnapshot(s.Label))
+	m.restoreSnapshot(s)
+	m.Dirty = true
+
+	m.setStatus(StatusInfo, fmt.Sprintf("Undid: %s", s.Label))
+}
+
+// Redo re-applies the most recently undone operation
+func (m *Model) Redo() {
+	if len(m.RedoStack) == 0 {
+		m.setStatus(StatusWarn, "Nothing to redo")
+		return
+	}
+
+	s := m.RedoStack[len(m.RedoStack)-1]
+	m.RedoStack = m.RedoStack[:len(m.RedoStack)-1]
+	m.UndoStack = append(m.UndoStack, m.takeSnapshot(s.Label))
+	m.restoreSnapshot(s)
+	m.Dirty = true
+
+	m.setStatus(StatusInfo, fmt.Sprintf("Redid: %s", s.Label))
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Each grid cell becomes a block of cellPixelsX by cellPixelsY pixels; text is drawn
+// with a 5×7 font scaled by fontScale
+const (
+	cellPixelsX = 12
+	cellPixelsY = 24
+	fontScale   = 2
+)
+
+// boxGlyph is how a box-drawing glyph is drawn: the directions it reaches and its weight
+type boxGlyph struct {
+	arms   int
+	weight int // 1 light, 2 heavy, 3 double
+	dashed bool
+}
+
+// boxGlyphs covers the box-drawing glyphs the renderer uses besides lineGlyphs
+var boxGlyphs = map[rune]boxGlyph{
+	'╭': {lineDown | lineRight, 1, false},
+	'╮': {lineDown | lineLeft, 1, false},
+	'╰': {lineUp | lineRight, 1, false},
+	'╯': {lineUp | lineLeft, 1, false},
+	'╌': {lineLeft | lineRight, 1, true},
+	'╎': {lineUp | lineDown, 1, true},
+	'━': {lineLeft | lineRight, 2, false},
+	'┃': {lineUp | lineDown, 2, false},
+	'┏': {lineDown | lineRight, 2, false},
+	'┓': {lineDown | lineLeft, 2, false},
+	'┗': {lineUp | lineRight, 2, false},
+	'┛': {lineUp | lineLeft, 2, false},
+	'═': {lineLeft | lineRight, 3, false},
+	'║': {lineUp | lineDown, 3, false},
+	'╔': {lineDown | lineRight, 3, false},
+	'╗': {lineDown | lineLeft, 3, false},
+	'╚': {lineUp | lineRight, 3, false},
+	'╝': {lineUp | lineLeft, 3, false},
+}
+
+// font5x7 holds the printable ASCII characters, ' ' to '~', five columns each. Bit 0 of
+// a column is its top row.
+var font5x7 = [95][5]byte{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, {0x00, 0x00, 0x5F, 0x00, 0x00}, {0x00, 0x07, 0x00, 0x07, 0x00}, {0x14, 0x7F, 0x14, 0x7F, 0x14}, // space ! " #
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, {0x23, 0x13, 0x08, 0x64, 0x62}, {0x36, 0x49, 0x55, 0x22, 0x50}, {0x00, 0x05, 0x03, 0x00, 0x00}, // $ % & '
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, {0x00, 0x41, 0x22, 0x1C, 0x00}, {0x14, 0x08, 0x3E, 0x08, 0x14}, {0x08, 0x08, 0x3E, 0x08, 0x08}, // ( ) * +
+	{0x00, 0x50, 0x30, 0x00, 0x00}, {0x08, 0x08, 0x08, 0x08, 0x08}, {0x00, 0x60, 0x60, 0x00, 0x00}, {0x20, 0x10, 0x08, 0x04, 0x02}, // , - . /
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, {0x00, 0x42, 0x7F, 0x40, 0x00}, {0x42, 0x61, 0x51, 0x49, 0x46}, {0x21, 0x41, 0x45, 0x4B, 0x31}, // 0 1 2 3
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, {0x27, 0x45, 0x45, 0x45, 0x39}, {0x3C, 0x4A, 0x49, 0x49, 0x30}, {0x01, 0x71, 0x09, 0x05, 0x03}, // 4 5 6 7
+	{0x36, 0x49, 0x49, 0x49, 0x36}, {0x06, 0x49, 0x49, 0x29, 0x1E}, {0x00, 0x36, 0x36, 0x00, 0x00}, {0x00, 0x56, 0x36, 0x00, 0x00}, // 8 9 : ;
+	{0x08, 0x14, 0x22, 0x41, 0x00}, {0x14, 0x14, 0x14, 0x14, 0x14}, {0x00, 0x41, 0x22, 0x14, 0x08}, {0x02, 0x01, 0x51, 0x09, 0x06}, // < = > ?
+	{0x32, 0x49, 0x79, 0x41, 0x3E}, {0x7E, 0x11, 0x11, 0x11, 0x7E}, {0x7F, 0x49, 0x49, 0x49, 0x36}, {0x3E, 0x41, 0x41, 0x41, 0x22}, // @ A B C
+	{0x7F, 0x41, 0x41, 0x22, 0x1C}, {0x7F, 0x49, 0x49, 0x49, 0x41}, {0x7F, 0x09, 0x09, 0x09, 0x01}, {0x3E, 0x41, 0x49, 0x49, 0x7A}, // D E F G
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, {0x00, 0x41, 0x7F, 0x41, 0x00}, {0x20, 0x40, 0x41, 0x3F, 0x01}, {0x7F, 0x08, 0x14, 0x22, 0x41}, // H I J K
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, {0x7F, 0x02, 0x0C, 0x02, 0x7F}, {0x7F, 0x04, 0x08, 0x10, 0x7F}, {0x3E, 0x41, 0x41, 0x41, 0x3E}, // L M N O
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, {0x3E, 0x41, 0x51, 0x21, 0x5E}, {0x7F, 0x09, 0x19, 0x29, 0x46}, {0x46, 0x49, 0x49, 0x49, 0x31}, // P Q R S
+	{0x01, 0x01, 0x7F, 0x01, 0x01}, {0x3F, 0x40, 0x40, 0x40, 0x3F}, {0x1F, 0x20, 0x40, 0x20, 0x1F}, {0x3F, 0x40, 0x38, 0x40, 0x3F}, // T U V W
+	{0x63, 0x14, 0x08, 0x14, 0x63}, {0x07, 0x08, 0x70, 0x08, 0x07}, {0x61, 0x51, 0x49, 0x45, 0x43}, {0x00, 0x7F, 0x41, 0x41, 0x00}, // X Y Z [
+	{0x02, 0x04, 0x08, 0x10, 0x20}, {0x00, 0x41, 0x41, 0x7F, 0x00}, {0x04, 0x02, 0x01, 0x02, 0x04}, {0x40, 0x40, 0x40, 0x40, 0x40}, // \ ] ^ _
+	{0x00, 0x01, 0x02, 0x04, 0x00}, {0x20, 0x54, 0x54, 0x54, 0x78}, {0x7F, 0x48, 0x44, 0x44, 0x38}, {0x38, 0x44, 0x44, 0x44, 0x20}, // ` a b c
+	{0x38, 0x44, 0x44, 0x48, 0x7F}, {0x38, 0x54, 0x54, 0x54, 0x18}, {0x08, 0x7E, 0x09, 0x01, 0x02}, {0x0C, 0x52, 0x52, 0x52, 0x3E}, // d e f g
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, {0x00, 0x44, 0x7D, 0x40, 0x00}, {0x20, 0x40, 0x44, 0x3D, 0x00}, {0x7F, 0x10, 0x28, 0x44, 0x00}, // h i j k
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, {0x7C, 0x04, 0x18, 0x04, 0x78}, {0x7C, 0x08, 0x04, 0x04, 0x78}, {0x38, 0x44, 0x44, 0x44, 0x38}, // l m n o
+	{0x7C, 0x14, 0x14, 0x14, 0x08}, {0x08, 0x14, 0x14, 0x18, 0x7C}, {0x7C, 0x08, 0x04, 0x04, 0x08}, {0x48, 0x54, 0x54, 0x54, 0x20}, // p q r s
+	{0x04, 0x3F, 0x44, 0x40, 0x20}, {0x3C, 0x40, 0x40, 0x20, 0x7C}, {0x1C, 0x20, 0x40, 0x20, 0x1C}, {0x3C, 0x40, 0x30, 0x40, 0x3C}, // t u v w
+	{0x44, 0x28, 0x10, 0x28, 0x44}, {0x0C, 0x50, 0x50, 0x50, 0x3C}, {0x44, 0x64, 0x54, 0x4C, 0x44}, {0x00, 0x08, 0x36, 0x41, 0x00}, // x y z {
+	{0x00, 0x00, 0x7F, 0x00, 0x00}, {0x00, 0x41, 0x36, 0x08, 0x00}, {0x10, 0x08, 0x08, 0x10, 0x08}, // | } ~
+}
+
+// parseHexColor reads a "#RRGGBB" color
+func parseHexColor(s string) (color.RGBA, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xFF}, true
+}
+
+// rasterize paints a grid from renderSnapshot as an image, on the theme's overlay
+// background. Colors that aren't "#RRGGBB" fall back to the theme's text color.
+func (m Model) rasterize(grid [][]ColoredCell) *image.RGBA {
+	width, height := gridSize(grid)
+	img := image.NewRGBA(image.Rect(0, 0, width*cellPixelsX, height*cellPixelsY))
+	background, _ := parseHexColor(m.Theme.Overlay)
+	text, ok := parseHexColor(m.Theme.Text)
+	if !ok {
+		text = color.RGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}
+	}
+	fillRect(img, img.Rect, background)
+
+	for y, row := range grid {
+		for x, cell := range row {
+			if cell.Char == ' ' || cell.Char == wideContinuation {
+				continue
+			}
+			ink, ok := parseHexColor(m.Theme.nodeColor(cell.Color))
+			if !ok {
+				ink = text
+			}
+			wide := x+1 < len(row) && row[x+1].Char == wideContinuation
+			drawCell(img, x*cellPixelsX, y*cellPixelsY, cell.Char, wide, ink, background)
+		}
+	}
+	return img
+}
+
+// fillRect paints a rectangle, clipped to the image
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawCell draws one glyph with its cell's top left corner at (x, y). Glyphs without
+// a drawing of their own, such as emoji, show as an empty box.
+func drawCell(img *image.RGBA, x, y int, ch rune, wide bool, ink, background color.RGBA) {
+	cx, cy := x+cellPixelsX/2, y+cellPixelsY/2
+
+	if arms, ok := lineGlyphs[ch]; ok {
+		drawArms(img, x, y, boxGlyph{arms: arms, weight: 1}, ink, background)
+		return
+	}
+	if glyph, ok := boxGlyphs[ch]; ok {
+		drawArms(img, x, y, glyph, ink, background)
+		return
+	}
+	if ch >= ' ' && ch <= '~' {
+		drawText(img, x, y, font5x7[ch-' '], ink)
+		return
+	}
+
+	switch ch {
+	case '╱', '╲':
+		for dy := 0; dy < cellPixelsY; dy++ {
+			dx := dy * cellPixelsX / cellPixelsY
+			if ch == '╱' {
+				dx = cellPixelsX - 1 - dx
+			}
+			fillRect(img, image.Rect(x+dx-1, y+dy, x+dx+1, y+dy+1), ink)
+		}
+	case '▶', '◀', '▲', '▼':
+		drawArrowhead(img, x, y, ch, ink)
+	case '●':
+		const r = cellPixelsX / 3
+		for dy := -r; dy <= r; dy++ {
+			for dx := -r; dx <= r; dx++ {
+				if dx*dx+dy*dy <= r*r {
+					img.SetRGBA(cx+dx, cy+dy, ink)
+				}
+			}
+		}
+	case '■':
+		fillRect(img, image.Rect(cx-4, cy-4, cx+4, cy+4), ink)
+	case '·':
+		fillRect(img, image.Rect(cx-1, cy-1, cx+1, cy+1), ink)
+	case '≡':
+		for _, dy := range []int{-5, 0, 5} {
+			fillRect(img, image.Rect(x+2, cy+dy-1, x+cellPixelsX-2, cy+dy+1), ink)
+		}
+	default:
+		right := x + cellPixelsX - 2
+		if wide {
+			right += cellPixelsX
+		}
+		box := image.Rect(x+2, y+4, right, y+cellPixelsY-4)
+		fillRect(img, box, ink)
+		fillRect(img, box.Inset(1), background)
+	}
+}
+
+// drawArms draws a box-drawing glyph as strokes from the cell's center to the sides it
+// reaches. A double line is a wide stroke with its middle cut out, so corners join up.
+func drawArms(img *image.RGBA, x, y int, glyph boxGlyph, ink, background color.RGBA) {
+	cx, cy := x+cellPixelsX/2, y+cellPixelsY/2
+	arm := func(dir, half int) image.Rectangle {
+		switch dir {
+		case lineUp:
+			return image.Rect(cx-half, y, cx+half, cy+half)
+		case lineDown:
+			return image.Rect(cx-half, cy-half, cx+half, y+cellPixelsY)
+		case lineLeft:
+			return image.Rect(x, cy-half, cx+half, cy+half)
+		default:
+			return image.Rect(cx-half, cy-half, x+cellPixelsX, cy+half)
+		}
+	}
+
+	half := glyph.weight // Light strokes are 2 pixels wide, heavy ones 4 and double ones 6
+	for _, dir := range []int{lineUp, lineDown, lineLeft, lineRight} {
+		if glyph.arms&dir == 0 {
+			continue
+		}
+		r := arm(dir, half)
+		if glyph.dashed {
+			// Keep the middle half of the stroke, so neighbouring cells read as dashes
+			if dir == lineLeft || dir == lineRight {
+				r.Min.X, r.Max.X = max(r.Min.X, x+cellPixelsX/4), min(r.Max.X, x+cellPixelsX*3/4)
+			} else {
+				r.Min.Y, r.Max.Y = max(r.Min.Y, y+cellPixelsY/4), min(r.Max.Y, y+cellPixelsY*3/4)
+			}
+		}
+		fillRect(img, r, ink)
+	}
+	if glyph.weight == 3 {
+		for _, dir := range []int{lineUp, lineDown, lineLeft, lineRight} {
+			if glyph.arms&dir != 0 {
+				fillRect(img, arm(dir, 1), background)
+			}
+		}
+	}
+}
+
+// drawArrowhead fills a triangle pointing the way the glyph does
+func drawArrowhead(img *image.RGBA, x, y int, ch rune, ink color.RGBA) {
+	const size = cellPixelsX - 2
+	cx, cy := x+cellPixelsX/2, y+cellPixelsY/2
+	for i := 0; i < size; i++ {
+		span := (size - i) / 2 // Half the width of the triangle this far from its base
+		switch ch {
+		case '▶':
+			fillRect(img, image.Rect(cx-size/2+i, cy-span, cx-size/2+i+1, cy+span+1), ink)
+		case '◀':
+			fillRect(img, image.Rect(cx+size/2-i-1, cy-span, cx+size/2-i, cy+span+1), ink)
+		case '▼':
+			fillRect(img, image.Rect(cx-span, cy-size/2+i, cx+span+1, cy-size/2+i+1), ink)
+		case '▲':
+			fillRect(img, image.Rect(cx-span, cy+size/2-i-1, cx+span+1, cy+size/2-i), ink)
+		}
+	}
+}
+
+// drawText draws a font5x7 character, scaled up and centered in its cell
+func drawText(img *image.RGBA, x, y int, columns [5]byte, ink color.RGBA) {
+	left := x + (cellPixelsX-5*fontScale)/2
+	top := y + (cellPixelsY-7*fontScale)/2
+	for col, bits := range columns {
+		for row := 0; row < 7; row++ {
+			if bits&(1<<row) != 0 {
+				px, py := left+col*fontScale, top+row*fontScale
+				fillRect(img, image.Rect(px, py, px+fontScale, py+fontScale), ink)
+			}
+		}
+	}
+}
+
+// encodeImage renders the whole map as a PNG and returns it with its width in cells. It is
+// drawn from the same grid as the text snapshot, so both show the same picture.
+func (m Model) encodeImage() ([]byte, int, error) {
+	grid := m.renderSnapshot()
+	if grid == nil {
+		return nil, 0, fmt.Errorf("the map is empty")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m.rasterize(grid)); err != nil {
+		return nil, 0, err
+	}
+	width, _ := gridSize(grid)
+	return buf.Bytes(), width, nil
+}
+
+// ExportImage writes a PNG picture of the whole map to path
+func (m *Model) ExportImage(path string) error {
+	data, _, err := m.encodeImage()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// inlineImageProtocol returns which inline image protocol the terminal speaks, "kitty"
+// or "iterm", or "" when it isn't known to show images
+func inlineImageProtocol() string {
+	switch {
+	case os.Getenv("TERM") == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "":
+		return "kitty"
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm"
+	}
+	return ""
+}
+
+// inlineImage returns the escape sequence that shows a PNG in the terminal, columns
+// cells wide
+func inlineImage(data []byte, protocol string, columns int) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	if protocol == "iterm" {
+		return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;preserveAspectRatio=1:%s\a", len(data), columns, encoded)
+	}
+
+	// Kitty takes the image in chunks of at most 4096 bytes
+	var sb strings.Builder
+	for i := 0; i < len(encoded); i += 4096 {
+		chunk := encoded[i:min(i+4096, len(encoded))]
+		more := 0
+		if i+4096 < len(encoded) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,c=%d,m=%d;%s\x1b\\", columns, more, chunk)
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return sb.String()
+}
+
+// imageViewer shows an image on the normal screen until Enter is pressed. It runs with
+// tea.Exec, which leaves the alt screen while it runs.
+type imageViewer struct {
+	image  string // Escape sequence drawing the image
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+// Run draws the image and waits for Enter
+func (v *imageViewer) Run() error {
+	if _, err := fmt.Fprintf(v.stdout, "\n%s\n\nPress Enter to return to the map ", v.image); err != nil {
+		return err
+	}
+	_, err := bufio.NewReader(v.stdin).ReadString('\n')
+	if err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+// imageShownMsg is sent when the inline image has been dismissed
+type imageShownMsg struct{ Err error }
+
+// commandImage handles ":image [file]": with a file, or in a terminal that can't show
+// images, it writes a PNG of the map (next to the current file by default). Otherwise the
+// picture is shown in the terminal.
+func (m *Model) commandImage(path string) tea.Cmd {
+	protocol := inlineImageProtocol()
+	if path != "" || protocol == "" {
+		if path == "" {
+			path = m.exportFilename(".png")
+		}
+		if err := m.ExportImage(path); err != nil {
+			m.setStatus(StatusError, fmt.Sprintf("Error exporting: %v", err))
+			return nil
+		}
+		m.setStatus(StatusInfo, fmt.Sprintf("Image written to %s", path))
+		return nil
+	}
+
+	data, width, err := m.encodeImage()
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error exporting: %v", err))
+		return nil
+	}
+	viewer := &imageViewer{image: inlineImage(data, protocol, min(width, max(m.Width, 1)))}
+	return tea.Exec(viewer, func(err error) tea.Msg { return imageShownMsg{Err: err} })
+}
@@ -0,0 +1,152 @@
+package main
+
+// Directions a line glyph reaches out of its cell, combined as a bit mask
+const (
+	lineUp = 1 << iota
+	lineDown
+	lineLeft
+	lineRight
+)
+
+// lineGlyphs maps each light box-drawing glyph to the directions it connects
+var lineGlyphs = map[rune]int{
+	'─': lineLeft | lineRight,
+	'│': lineUp | lineDown,
+	'┌': lineDown | lineRight,
+	'┐': lineDown | lineLeft,
+	'└': lineUp | lineRight,
+	'┘': lineUp | lineLeft,
+	'├': lineUp | lineDown | lineRight,
+	'┤': lineUp | lineDown | lineLeft,
+	'┬': lineLeft | lineRight | lineDown,
+	'┴': lineLeft | lineRight | lineUp,
+	'┼': lineUp | lineDown | lineLeft | lineRight,
+}
+
+// glyphForLines is the inverse of lineGlyphs
+var glyphForLines = func() map[int]rune {
+	glyphs := make(map[int]rune, len(lineGlyphs))
+	for glyph, mask := range lineGlyphs {
+		glyphs[mask] = glyph
+	}
+	return glyphs
+}()
+
+// mergeGlyph returns the glyph for an edge drawn over a cell that already holds a line,
+// e.g. ─ over │ gives ┼. ok is false when the two don't combine and the cell should be
+// left as it is.
+func mergeGlyph(existing, incoming rune) (merged rune, ok bool) {
+	if existing == incoming {
+		return existing, true
+	}
+	if (existing == '╱' && incoming == '╲') || (existing == '╲' && incoming == '╱') || existing == '╳' {
+		return '╳', true
+	}
+	a, okA := lineGlyphs[existing]
+	b, okB := lineGlyphs[incoming]
+	if !okA || !okB {
+		return 0, false
+	}
+	merged, ok = glyphForLines[a|b]
+	return merged, ok
+}
+
+// borderJunctions gives, for each node border glyph, the glyph to use where an edge leaves
+// that border in a direction: light, heavy (selected) and double (link source) borders
+var borderJunctions = map[rune]map[int]rune{
+	'│': {lineRight: '├', lineLeft: '┤'},
+	'─': {lineUp: '┴', lineDown: '┬'},
+	'┃': {lineRight: '┠', lineLeft: '┨'},
+	'━': {lineUp: '┷', lineDown: '┯'},
+	'║': {lineRight: '╟', lineLeft: '╢'},
+	'═': {lineUp: '╧', lineDown: '╤'},
+}
+
+// edgePort is the border cell of a node where an edge leaves it, and which way it goes
+type edgePort struct {
+	x, y int
+	dir  int
+}
+
+// opposite returns the direction pointing back the other way
+func opposite(dir int) int {
+	switch dir {
+	case lineUp:
+		return lineDown
+	case lineDown:
+		return lineUp
+	case lineLeft:
+		return lineRight
+	default:
+		return lineLeft
+	}
+}
+
+// drawPorts turns node borders into junctions where an edge actually meets them. It runs
+// after the nodes are drawn; the cell just outside must hold a line heading into the border.
+func drawPorts(grid [][]ColoredCell, ports []edgePort) {
+	gridWidth, gridHeight := gridSize(grid)
+	inside := func(x, y int) bool { return x >= 0 && x < gridWidth && y >= 0 && y < gridHeight }
+	for _, port := range ports {
+		nx, ny := port.x, port.y
+		switch port.dir {
+		case lineUp:
+			ny--
+		case lineDown:
+			ny++
+		case lineLeft:
+			nx--
+		case lineRight:
+			nx++
+		}
+		if !inside(port.x, port.y) || !inside(nx, ny) {
+			continue
+		}
+		if lineGlyphs[grid[ny][nx].Char]&opposite(port.dir) == 0 {
+			continue
+		}
+		if junction, ok := borderJunctions[grid[port.y][port.x].Char][port.dir]; ok {
+			grid[port.y][port.x].Char = junction
+		}
+	}
+}
+
+// borderPort returns the border cell of a node drawn at (x, y) with the given size that
+// sits next to an edge endpoint (ex, ey) just outside it
+func borderPort(ex, ey, x, y, width, height int) edgePort {
+	switch {
+	case ex >= x+width:
+		return edgePort{x + width - 1, ey, lineRight}
+	case ex < x:
+		return edgePort{x, ey, lineLeft}
+	case ey >= y+height:
+		return edgePort{ex, y + height - 1, lineDown}
+	default:
+		return edgePort{ex, y, lineUp}
+	}
+}
+
+// plotLine puts a line glyph in a cell: on an empty cell as is, on another line merged
+// with it (─ over │ gives ┼), and not at all on anything else, like node text
+func plotLine(grid [][]ColoredCell, x, y int, glyph rune, color string) {
+	if y < 0 || y >= len(grid) || x < 0 || x >= len(grid[0]) {
+		return
+	}
+	existing := grid[y][x].Char
+	if existing == ' ' {
+		grid[y][x] = ColoredCell{Char: glyph, Color: color}
+	} else if merged, ok := mergeGlyph(existing, glyph); ok {
+		grid[y][x] = ColoredCell{Char: merged, Color: color}
+	}
+}
+
+// plotRouteCell plots a cell of an edge's route unless the same route already drew it
+func plotRouteCell(grid [][]ColoredCell, x, y int, glyph rune, color string, drawn map[cell]bool) {
+	if drawn != nil {
+		if drawn[cell{x, y}] {
+			return
+		}
+		drawn[cell{x, y}] = true
+	}
+	plotLine(grid, x, y, glyph, color)
+}
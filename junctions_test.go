@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// gridFromLines builds an uncolored grid from rows of text
+func gridFromLines(lines ...string) [][]ColoredCell {
+	grid := make([][]ColoredCell, len(lines))
+	for y, line := range lines {
+		for _, ch := range line {
+			grid[y] = append(grid[y], ColoredCell{Char: ch})
+		}
+	}
+	return grid
+}
+
+// gridLines returns a grid's rows as text
+func gridLines(grid [][]ColoredCell) []string {
+	lines := make([]string, len(grid))
+	for y, row := range grid {
+		var sb strings.Builder
+		for _, cell := range row {
+			sb.WriteRune(cell.Char)
+		}
+		lines[y] = sb.String()
+	}
+	return lines
+}
+
+func TestMergeGlyph(t *testing.T) {
+	tests := []struct {
+		existing, incoming rune
+		want               rune
+		ok                 bool
+	}{
+		{'─', '│', '┼', true},
+		{'│', '─', '┼', true},
+		{'─', '─', '─', true},
+		{'┌', '┘', '┼', true},
+		{'─', '┌', '┬', true},
+		{'│', '┘', '┤', true},
+		{'├', '─', '┼', true},
+		{'└', '┌', '├', true},
+		{'╱', '╲', '╳', true},
+		{'╳', '─', '╳', true},
+		{'a', '─', 0, false},
+		{'─', '╱', 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := mergeGlyph(tt.existing, tt.incoming)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("mergeGlyph(%q, %q) = %q, %v; want %q, %v", tt.existing, tt.incoming, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPlotLineOverGrid(t *testing.T) {
+	grid := gridFromLines(
+		"     ",
+		" ab  ",
+		"     ",
+	)
+	for x := range 5 {
+		plotLine(grid, x, 1, '─', "")
+	}
+	for y := range 3 {
+		plotLine(grid, 3, y, '│', "")
+	}
+	plotLine(grid, 9, 9, '│', "") // Off the grid
+
+	want := []string{
+		"   │ ",
+		"─ab┼─",
+		"   │ ",
+	}
+	if got := gridLines(grid); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDrawPorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		ports []edgePort
+		want  []string
+	}{
+		{
+			name:  "edge leaving the right border",
+			lines: []string{"┌──┐  ", "│ab│──", "└──┘  "},
+			ports: []edgePort{{3, 1, lineRight}},
+			want:  []string{"┌──┐  ", "│ab├──", "└──┘  "},
+		},
+		{
+			name:  "edge entering the left border",
+			lines: []string{"  ┌──┐", "──│ab│", "  └──┘"},
+			ports: []edgePort{{2, 1, lineLeft}},
+			want:  []string{"  ┌──┐", "──┤ab│", "  └──┘"},
+		},
+		{
+			name:  "edge leaving the bottom of a selected node",
+			lines: []string{"┏━━┓", "┃ab┃", "┗━━┛", " │  "},
+			ports: []edgePort{{1, 2, lineDown}},
+			want:  []string{"┏━━┓", "┃ab┃", "┗┯━┛", " │  "},
+		},
+		{
+			name:  "parallel edges into the same cell",
+			lines: []string{"┌──┐  ", "│ab│──", "└──┘  "},
+			ports: []edgePort{{3, 1, lineRight}, {3, 1, lineRight}},
+			want:  []string{"┌──┐  ", "│ab├──", "└──┘  "},
+		},
+		{
+			name:  "no line next to the border",
+			lines: []string{"┌──┐  ", "│ab│ x", "└──┘  "},
+			ports: []edgePort{{3, 1, lineRight}},
+			want:  []string{"┌──┐  ", "│ab│ x", "└──┘  "},
+		},
+		{
+			name:  "line next to the border going the other way",
+			lines: []string{"┌──┐  ", "│ab││ ", "└──┘  "},
+			ports: []edgePort{{3, 1, lineRight}},
+			want:  []string{"┌──┐  ", "│ab││ ", "└──┘  "},
+		},
+		{
+			name:  "port off the grid",
+			lines: []string{"┌──┐", "│ab│", "└──┘"},
+			ports: []edgePort{{3, 1, lineRight}, {9, 9, lineLeft}},
+			want:  []string{"┌──┐", "│ab│", "└──┘"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid := gridFromLines(tt.lines...)
+			drawPorts(grid, tt.ports)
+			if got := gridLines(grid); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestBorderPort(t *testing.T) {
+	// A node drawn at (10, 5), 6 wide and 3 tall
+	tests := []struct {
+		ex, ey int
+		want   edgePort
+	}{
+		{16, 6, edgePort{15, 6, lineRight}},
+		{9, 6, edgePort{10, 6, lineLeft}},
+		{12, 8, edgePort{12, 7, lineDown}},
+		{12, 4, edgePort{12, 5, lineUp}},
+	}
+	for _, tt := range tests {
+		if got := borderPort(tt.ex, tt.ey, 10, 5, 6, 3); got != tt.want {
+			t.Errorf("borderPort(%d, %d) = %+v, want %+v", tt.ex, tt.ey, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import "strings"
+
+// Action is something a key does in normal mode
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionQuit
+	ActionForceQuit
+	ActionQuitDiscard
+	ActionHelp
+	ActionSelectUp
+	ActionSelectDown
+	ActionSelectLeft
+	ActionSelectRight
+	ActionSelectNext
+	ActionSelectPrev
+	ActionStructural
+	ActionVisual
+	ActionHints
+	ActionMoveSiblingUp
+	ActionMoveSiblingDown
+	ActionPanUp
+	ActionPanDown
+	ActionPanLeft
+	ActionPanRight
+	ActionFastPanUp
+	ActionFastPanDown
+	ActionFastPanLeft
+	ActionFastPanRight
+	ActionHalfPageDown
+	ActionHalfPageUp
+	ActionPageDown
+	ActionPageUp
+	ActionLineDown
+	ActionLineUp
+	ActionCount
+	ActionZoomIn
+	ActionZoomOut
+	ActionResetCamera
+	ActionCenter
+	ActionFit
+	ActionZoomSelection
+	ActionFollow
+	ActionCreateSibling
+	ActionCreateChild
+	ActionInsertParent
+	ActionEdit
+	ActionEditExternal
+	ActionYank
+	ActionYankBranch
+	ActionDelete
+	ActionSplice
+	ActionDuplicate
+	ActionDuplicateSubtree
+	ActionMoveMode
+	ActionReparent
+	ActionRelayout
+	ActionSortChildren
+	ActionRepeat
+	ActionAlign
+	ActionTemplate
+	ActionCollapse
+	ActionUndo
+	ActionRedo
+	ActionToggleNotes
+	ActionEditNotes
+	ActionEditNotesExternal
+	ActionScrollNotesUp
+	ActionScrollNotesDown
+	ActionTag
+	ActionFilter
+	ActionClearFilter
+	ActionToggleDone
+	ActionLink
+	ActionEdges
+	ActionSearch
+	ActionSearchNext
+	ActionSearchPrev
+	ActionMinimap
+	ActionLegend
+	ActionToggleIDs
+	ActionEdgeStyle
+	ActionMark
+	ActionJumpMark
+	ActionStats
+	ActionTheme
+	ActionCommand
+	ActionSave
+	ActionSaveAs
+	ActionReload
+	ActionSnapshot
+)
+
+// KeyBinding ties a normal-mode action to its keys and describes it for the help overlay
+// and the status bar. Keys are written the way tea.KeyMsg.String() reports them.
+type KeyBinding struct {
+	Action   Action
+	Keys     []string
+	Desc     string // Shown in the help overlay
+	Category string // Help overlay section
+	Hint     string // Short label for the status bar, or "" to leave it out
+}
+
+// keymap lists every normal-mode key. The input handler, the help overlay and the
+// status bar hints are all built from it.
+var keymap = []KeyBinding{
+	{ActionPanUp, []string{"w", "k"}, "Pan up", "Navigation", ""},
+	{ActionPanDown, []string{"s", "j"}, "Pan down", "Navigation", ""},
+	{ActionPanLeft, []string{"a", "h"}, "Pan left", "Navigation", ""},
+	{ActionPanRight, []string{"d", "l"}, "Pan right", "Navigation", ""},
+	{ActionFastPanUp, []string{"K"}, "Pan up five times as far", "Navigation", ""},
+	{ActionFastPanDown, []string{"J"}, "Pan down five times as far", "Navigation", ""},
+	{ActionFastPanLeft, []string{"H"}, "Pan left five times as far", "Navigation", ""},
+	{ActionFastPanRight, []string{"L"}, "Pan right five times as far", "Navigation", ""},
+	{ActionHalfPageDown, []string{"ctrl+d"}, "Pan down half a screen", "Navigation", ""},
+	{ActionHalfPageUp, []string{"ctrl+u"}, "Pan up half a screen", "Navigation", ""},
+	{ActionPageDown, []string{"ctrl+f"}, "Pan down a whole screen", "Navigation", ""},
+	{ActionPageUp, []string{"ctrl+b"}, "Pan up a whole screen", "Navigation", ""},
+	{ActionLineDown, []string{"ctrl+e"}, "Pan down one line", "Navigation", ""},
+	{ActionLineUp, []string{"ctrl+y"}, "Pan up one line", "Navigation", ""},
+	{ActionCount, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, "Count for the next pan or zoom (10l pans ten steps)", "Navigation", ""},
+	{ActionZoomIn, []string{"+", "="}, "Zoom in", "Navigation", ""},
+	{ActionZoomOut, []string{"-", "_"}, "Zoom out", "Navigation", ""},
+	{ActionResetCamera, []string{"0"}, "Reset view to the root node", "Navigation", ""},
+	{ActionCenter, []string{"c"}, "Center on the selected node", "Navigation", ""},
+	{ActionFit, []string{"f"}, "Fit the whole map on screen", "Navigation", ""},
+	{ActionZoomSelection, []string{"Z"}, "Center and zoom to the selected node and its children", "Navigation", ""},
+	{ActionFollow, []string{"alt+c"}, "Toggle follow mode (camera tracks selection)", "Navigation", ""},
+
+	{ActionSelectUp, []string{"up"}, "Select the nearest node above", "Selection", ""},
+	{ActionSelectDown, []string{"down"}, "Select the nearest node below", "Selection", ""},
+	{ActionSelectLeft, []string{"left"}, "Select the nearest node to the left", "Selection", ""},
+	{ActionSelectRight, []string{"right"}, "Select the nearest node to the right", "Selection", ""},
+	{ActionSelectNext, []string{"]"}, "Select the next node", "Selection", ""},
+	{ActionSelectPrev, []string{"["}, "Select the previous node", "Selection", ""},
+	{ActionStructural, []string{"g"}, "Go to parent (g p), child (g c), sibling (g s / g S) or ID (g i)", "Selection", ""},
+	{ActionVisual, []string{"v"}, "Visual mode: select several nodes to delete, recolor, tag or move", "Selection", ""},
+	{ActionHints, []string{"F"}, "Jump to a node by typing its label", "Selection", ""},
+	{ActionMark, []string{"m"}, "Mark the node (or the view) under a letter: m a", "Selection", ""},
+	{ActionJumpMark, []string{"'"}, "Jump to a mark: ' a (:marks lists them)", "Selection", ""},
+	{ActionSearch, []string{"/"}, "Search node text", "Selection", ""},
+	{ActionSearchNext, []string{"n"}, "Next search match", "Selection", ""},
+	{ActionSearchPrev, []string{"N"}, "Previous search match", "Selection", ""},
+
+	{ActionCreateChild, []string{"tab"}, "Create child node (to the right)", "Editing", "child"},
+	{ActionCreateSibling, []string{"enter"}, "Create sibling node (below)", "Editing", "sibling"},
+	{ActionInsertParent, []string{"I"}, "Insert a node above the selection", "Editing", ""},
+	{ActionEdit, []string{"e"}, "Edit node text", "Editing", "edit"},
+	{ActionEditExternal, []string{"ctrl+x"}, "Edit node text in $EDITOR (was Ctrl+E, which now scrolls a line)", "Editing", ""},
+	{ActionYank, []string{"y"}, "Copy node text to the clipboard (Ctrl+V pastes while editing)", "Editing", ""},
+	{ActionYankBranch, []string{"Y"}, "Copy the branch to the clipboard as an outline", "Editing", ""},
+	{ActionDelete, []string{"x", "delete", "backspace"}, "Delete node (asks if it has children or links)", "Editing", "delete"},
+	{ActionSplice, []string{"X"}, "Delete node, keeping its children", "Editing", ""},
+	{ActionDuplicate, []string{"D"}, "Duplicate node", "Editing", ""},
+	{ActionDuplicateSubtree, []string{"alt+d"}, "Duplicate node and its subtree", "Editing", ""},
+	{ActionMoveMode, []string{"alt+m"}, "Move node with hjkl", "Editing", ""},
+	{ActionReparent, []string{"P"}, "Move node under a new parent", "Editing", ""},
+	{ActionMoveSiblingUp, []string{"alt+up"}, "Move node up among its siblings", "Editing", ""},
+	{ActionMoveSiblingDown, []string{"alt+down"}, "Move node down among its siblings", "Editing", ""},
+	{ActionSortChildren, []string{"S"}, "Sort children: alphabetical (S a), reverse (S r) or creation order (S c)", "Editing", ""},
+	{ActionRelayout, []string{"R", "alt+l"}, "Re-layout the whole tree", "Editing", ""},
+	{ActionAlign, []string{"A"}, "Align children on x (A x), space them evenly (A d) or center them (A c)", "Editing", ""},
+	{ActionTemplate, []string{"ctrl+n"}, "Insert a template under the selected node", "Editing", ""},
+	{ActionRepeat, []string{"."}, "Repeat the last change on the selected node", "Editing", ""},
+	{ActionUndo, []string{"u"}, "Undo", "Editing", ""},
+	{ActionRedo, []string{"ctrl+r"}, "Redo", "Editing", ""},
+
+	{ActionToggleNotes, []string{"i"}, "Show/hide the notes panel", "Notes, tags and tasks", ""},
+	{ActionEditNotes, []string{"alt+n"}, "Edit notes (Alt+Enter for a new line)", "Notes, tags and tasks", ""},
+	{ActionEditNotesExternal, []string{"alt+i"}, "Edit notes in $EDITOR", "Notes, tags and tasks", ""},
+	{ActionScrollNotesUp, []string{"{"}, "Scroll notes up", "Notes, tags and tasks", ""},
+	{ActionScrollNotesDown, []string{"}"}, "Scroll notes down", "Notes, tags and tasks", ""},
+	{ActionTag, []string{"t"}, "Add or remove tags", "Notes, tags and tasks", ""},
+	{ActionFilter, []string{"T"}, "Show only nodes with a tag", "Notes, tags and tasks", ""},
+	{ActionClearFilter, []string{"esc"}, "Clear the tag filter", "Notes, tags and tasks", ""},
+	{ActionToggleDone, []string{" "}, "Check/uncheck task", "Notes, tags and tasks", ""},
+
+	{ActionLink, []string{"ctrl+k"}, "Link to another node", "Links", ""},
+	{ActionEdges, []string{"E"}, "Manage the node's links", "Links", ""},
+
+	{ActionMinimap, []string{"M"}, "Toggle the minimap", "View", ""},
+	{ActionLegend, []string{"ctrl+l"}, "Toggle the legend of branch colors", "View", ""},
+	{ActionCollapse, []string{"z"}, "Collapse the branch (z a), everything (z M), nothing (z R) or below a level (z 1-3)", "View", ""},
+	{ActionToggleIDs, []string{"ctrl+g"}, "Show node IDs", "View", ""},
+	{ActionEdgeStyle, []string{"alt+e"}, "Switch between curved and right-angled edges", "View", ""},
+	{ActionStats, []string{"ctrl+t"}, "Statistics for the branch and the map", "View", ""},
+	{ActionTheme, []string{"alt+t"}, "Switch between dark and light themes", "View", ""},
+
+	{ActionSave, []string{"ctrl+s"}, "Save", "Files", ""},
+	{ActionSaveAs, []string{"W"}, "Save as", "Files", ""},
+	{ActionReload, []string{"ctrl+o"}, "Reload the current file", "Files", ""},
+	{ActionSnapshot, []string{"ctrl+p"}, "Write a text picture of the map", "Files", ""},
+
+	{ActionCommand, []string{":"}, "Command line", "General", ""},
+	{ActionHelp, []string{"?"}, "Toggle this help", "General", "help"},
+	{ActionQuit, []string{"q"}, "Quit, saving changes (asks for a file name if there is none)", "General", ""},
+	{ActionQuitDiscard, []string{"Q"}, "Quit without saving", "General", ""},
+	{ActionForceQuit, []string{"ctrl+c"}, "Quit immediately", "General", ""},
+}
+
+// keyActions maps each normal-mode key to its action
+var keyActions = func() map[string]Action {
+	actions := make(map[string]Action)
+	for _, binding := range keymap {
+		for _, key := range binding.Keys {
+			actions[key] = binding.Action
+		}
+	}
+	return actions
+}()
+
+// keyLabel returns how a key is shown to the user, e.g. "Ctrl+S" for "ctrl+s"
+func keyLabel(key string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case "alt+up":
+		return "Alt+↑"
+	case "alt+down":
+		return "Alt+↓"
+	}
+	if len(key) > 1 {
+		parts := strings.Split(key, "+")
+		for i, part := range parts {
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		}
+		return strings.Join(parts, "+")
+	}
+	return key
+}
+
+// bindingLabel returns a binding's keys as shown in the help, e.g. "w/k"
+func bindingLabel(binding KeyBinding) string {
+	if binding.Action == ActionCount {
+		return "1-9"
+	}
+	labels := make([]string, len(binding.Keys))
+	for i, key := range binding.Keys {
+		labels[i] = keyLabel(key)
+	}
+	return strings.Join(labels, "/")
+}
+
+// keyHints returns the normal-mode status bar hints, e.g. " [Tab]child [e]dit "
+func keyHints() string {
+	var sb strings.Builder
+	sb.WriteString(" ")
+	for _, binding := range keymap {
+		if binding.Hint == "" {
+			continue
+		}
+		key := keyLabel(binding.Keys[0])
+		if strings.HasPrefix(binding.Hint, binding.Keys[0]) {
+			// "[e]dit" reads better than "[e]edit"
+			sb.WriteString("[" + key + "]" + binding.Hint[len(binding.Keys[0]):] + " ")
+		} else {
+			sb.WriteString("[" + key + "]" + binding.Hint + " ")
+		}
+	}
+	return sb.String()
+}
+
+// normalKeyHints is the status bar hint text for normal mode
+var normalKeyHints = keyHints()
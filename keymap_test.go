@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestEveryBoundKeyIsInHelp(t *testing.T) {
+	m := newTestModel(t)
+	help := ansi.Strip(strings.Join(m.helpLines(), "\n"))
+	for _, binding := range keymap {
+		label := bindingLabel(binding)
+		found := false
+		for _, line := range strings.Split(help, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 0 && fields[0] == label && strings.HasSuffix(line, binding.Desc) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("help has no line for %s: %s", label, binding.Desc)
+		}
+		for _, key := range binding.Keys {
+			if binding.Action != ActionCount && !strings.Contains(label, keyLabel(key)) {
+				t.Errorf("help label %q leaves out key %q", label, key)
+			}
+		}
+	}
+}
+
+func TestKeysAreBoundOnce(t *testing.T) {
+	bound := make(map[string]string)
+	for _, binding := range keymap {
+		for _, key := range binding.Keys {
+			if other, ok := bound[key]; ok {
+				t.Errorf("%q is bound to both %q and %q", key, other, binding.Desc)
+			}
+			bound[key] = binding.Desc
+			if keyActions[key] != binding.Action {
+				t.Errorf("%q runs action %d, want %d (%s)", key, keyActions[key], binding.Action, binding.Desc)
+			}
+		}
+	}
+}
+
+func TestKeyHintsComeFromKeymap(t *testing.T) {
+	for _, binding := range keymap {
+		if binding.Hint == "" {
+			continue
+		}
+		if !strings.Contains(normalKeyHints, "["+keyLabel(binding.Keys[0])+"]") {
+			t.Errorf("status bar hints %q leave out %s", normalKeyHints, binding.Keys[0])
+		}
+	}
+}
+
+func TestKeyLabel(t *testing.T) {
+	tests := map[string]string{
+		"x":      "x",
+		" ":      "Space",
+		"up":     "↑",
+		"ctrl+s": "Ctrl+S",
+		"alt+up": "Alt+↑",
+		"tab":    "Tab",
+	}
+	for key, want := range tests {
+		if got := keyLabel(key); got != want {
+			t.Errorf("keyLabel(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
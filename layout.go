@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// layoutPoint is a node position the layout animation is moving towards
+type layoutPoint struct {
+	X, Y float64
+}
+
+// AutoLayout re-lays-out the whole tree as a tidy tree: each child column sits to the right
+// of its parent and children are stacked vertically, centered on the parent, so no two
+// node boxes overlap. In a balanced map the root's children are split between both sides
+// and those on the left grow leftward. Cross-links don't affect the layout.
+func (m *Model) AutoLayout() {
+	roots := m.GetRootNodes()
+	if len(roots) == 0 {
+		return
+	}
+
+	m.pushUndo("auto-layout")
+
+	heights := make(map[string]float64)
+	targets := make(map[string]layoutPoint)
+
+	// The root stays where it is; floating nodes are stacked below its tree
+	first := roots[0]
+	_, firstCY := first.GetCenter()
+	firstHeight := m.measureSubtree(first, heights, make(map[string]bool))
+	balanced := m.Layout == LayoutBalanced && first.ID == "0"
+	var right, left []*Node
+	if balanced {
+		right, left = m.balanceSides(m.GetChildrenOf(first.ID), heights)
+		firstHeight = max(float64(first.Height), stackHeight(right, heights, m.VSpacing), stackHeight(left, heights, m.VSpacing))
+		heights[first.ID] = firstHeight
+	}
+	top := math.Floor(firstCY - firstHeight/2)
+	for i, root := range roots {
+		h := firstHeight
+		if i > 0 {
+			h = m.measureSubtree(root, heights, make(map[string]bool))
+		}
+		if i == 0 && balanced {
+			m.placeBalanced(root, right, left, first.X, top, heights, targets)
+		} else {
+			m.placeSubtree(root, first.X, top, heights, targets, make(map[string]bool), false)
+		}
+		top += h + m.VSpacing
+	}
+
+	m.animateTo(targets)
+	m.setStatus(StatusInfo, fmt.Sprintf("Re-laid out %d nodes", len(targets)))
+}
+
+// measureSubtree computes the height of the band each subtree needs, bottom-up
+func (m *Model) measureSubtree(node *Node, heights map[string]float64, visited map[string]bool) float64 {
+	visited[node.ID] = true
+
+	childrenHeight := 0.0
+	count := 0
+	for _, child := range m.GetChildrenOf(node.ID) {
+		if visited[child.ID] {
+			continue // Guard against parent cycles
+		}
+		if count > 0 {
+			childrenHeight += m.VSpacing
+		}
+		childrenHeight += m.measureSubtree(child, heights, visited)
+		count++
+	}
+
+	h := math.Max(float64(node.Height), childrenHeight)
+	heights[node.ID] = h
+	return h
+}
+
+// placeSubtree positions a node centered in its band starting at top, and its children to
+// the right, or to the left when left is set
+func (m *Model) placeSubtree(node *Node, x, top float64, heights map[string]float64, targets map[string]layoutPoint, visited map[string]bool, left bool) {
+	visited[node.ID] = true
+	band := heights[node.ID]
+	targets[node.ID] = layoutPoint{X: x, Y: top + math.Floor((band-float64(node.Height))/2)}
+
+	children := make([]*Node, 0)
+	for _, child := range m.GetChildrenOf(node.ID) {
+		if !visited[child.ID] {
+			children = append(children, child)
+		}
+	}
+
+	childX := x + float64(node.Width) + m.HSpacing
+	if left {
+		childX = x - m.HSpacing
+	}
+	m.placeChildren(children, childX, top, band, heights, targets, visited, left)
+}
+
+// placeBalanced positions the root centered in its band, with the right branches stacked
+// to its right and the left ones to its left
+func (m *Model) placeBalanced(root *Node, right, left []*Node, x, top float64, heights map[string]float64, targets map[string]layoutPoint) {
+	visited := map[string]bool{root.ID: true}
+	band := heights[root.ID]
+	targets[root.ID] = layoutPoint{X: x, Y: top + math.Floor((band-float64(root.Height))/2)}
+	m.placeChildren(right, x+float64(root.Width)+m.HSpacing, top, band, heights, targets, visited, false)
+	m.placeChildren(left, x-m.HSpacing, top, band, heights, targets, visited, true)
+}
+
+// placeChildren stacks subtrees centered in the band starting at top, in a column starting
+// at x, or ending at x when they grow to the left
+func (m *Model) placeChildren(children []*Node, x, top, band float64, heights map[string]float64, targets map[string]layoutPoint, visited map[string]bool, left bool) {
+	childTop := top + math.Floor((band-stackHeight(children, heights, m.VSpacing))/2)
+	for _, child := range children {
+		childX := x
+		if left {
+			childX -= float64(child.Width)
+		}
+		m.placeSubtree(child, childX, childTop, heights, targets, visited, left)
+		childTop += heights[child.ID] + m.VSpacing
+	}
+}
+
+// animateTo starts moving nodes smoothly towards target positions
+func (m *Model) animateTo(targets map[string]layoutPoint) {
+	m.LayoutTargets = targets
+}
+
+// stepLayoutAnimation moves animating nodes a step towards their targets.
+// Returns true while any node is still moving.
+func (m *Model) stepLayoutAnimation(smoothness float64) bool {
+	const threshold = 0.05
+
+	m.invalidateSpatialIndex()
+	for id, target := range m.LayoutTargets {
+		node := m.Nodes[id]
+		if node == nil {
+			delete(m.LayoutTargets, id)
+			continue
+		}
+		if math.Abs(node.X-target.X) < threshold && math.Abs(node.Y-target.Y) < threshold {
+			node.X, node.Y = target.X, target.Y
+			delete(m.LayoutTargets, id)
+			continue
+		}
+		node.X += (target.X - node.X) * smoothness
+		node.Y += (target.Y - node.Y) * smoothness
+	}
+	return len(m.LayoutTargets) > 0
+}
+
+// finishLayoutAnimation snaps animating nodes to their targets so edits and saves see final positions
+func (m *Model) finishLayoutAnimation() {
+	for id, target := range m.LayoutTargets {
+		if node := m.Nodes[id]; node != nil {
+			node.X, node.Y = target.X, target.Y
+		}
+	}
+	m.LayoutTargets = nil
+	m.invalidateSpatialIndex()
+}
+
+// SetWrapWidth changes the width node text wraps at, resizing every node to match
+func (m *Model) SetWrapWidth(width int) {
+	if !validWrapWidth(width) {
+		m.setStatus(StatusWarn, fmt.Sprintf("Wrap width must be between %d and %d", minWrapWidth, maxWrapWidth))
+		return
+	}
+	m.finishLayoutAnimation()
+	m.WrapWidth = width
+	if m.resizeNodes() {
+		m.Dirty = true
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Wrap width: %d", width))
+}
+
+// validWrapWidth reports whether width is within the supported wrap widths
+func validWrapWidth(width int) bool {
+	return width >= minWrapWidth && width <= maxWrapWidth
+}
+
+// resizeNodes recalculates every node's size for the current wrap width and moves apart
+// any boxes that grew into their neighbours. It reports whether any size changed.
+func (m *Model) resizeNodes() bool {
+	changed := false
+	for _, node := range m.Nodes {
+		width, height := node.Width, node.Height
+		node.UpdateSize(m.WrapWidth)
+		if node.Width != width || node.Height != height {
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+
+	visited := make(map[string]bool)
+	for _, root := range m.GetRootNodes() {
+		m.resolveOverlaps(root, visited)
+	}
+	m.invalidateSpatialIndex()
+	return true
+}
+
+// resolveOverlaps works bottom-up through a subtree, moving children clear of their
+// parent's edge and stacking sibling subtrees on each side so they no longer overlap
+func (m *Model) resolveOverlaps(node *Node, visited map[string]bool) {
+	visited[node.ID] = true
+
+	var children []*Node
+	for _, child := range m.GetChildrenOf(node.ID) {
+		if visited[child.ID] {
+			continue // Guard against parent cycles
+		}
+		m.resolveOverlaps(child, visited)
+		children = append(children, child)
+	}
+
+	// Children to the right of the parent start past its (possibly wider) box, and
+	// children to its left end before it
+	right, left := node.X+float64(node.Width)+m.HSpacing, node.X-m.HSpacing
+	for _, child := range children {
+		if child.X >= node.X && child.X < right {
+			m.moveSubtree(child.ID, right-child.X, 0)
+		} else if end := child.X + float64(child.Width); child.X < node.X && end > left {
+			m.moveSubtree(child.ID, left-end, 0)
+		}
+	}
+
+	rightChildren, leftChildren := m.sides(children)
+	m.stackSubtrees(rightChildren)
+	m.stackSubtrees(leftChildren)
+}
+
+// untangleGap is the number of empty rows untangle leaves between boxes it separates
+const untangleGap = 1.0
+
+// Untangle moves overlapping nodes apart and reports how many moved
+func (m *Model) Untangle() {
+	if !m.hasOverlaps() {
+		m.setStatus(StatusInfo, "No overlapping nodes")
+		return
+	}
+	m.pushUndo("untangle")
+	m.setStatus(StatusInfo, fmt.Sprintf("Untangled: moved %d nodes", m.untangle()))
+}
+
+// hasOverlaps reports whether any two node boxes intersect
+func (m *Model) hasOverlaps() bool {
+	nodes := m.nodesByPosition()
+	for i, a := range nodes {
+		for _, b := range nodes[i+1:] {
+			if boxesOverlap(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// untangle moves nodes down until no two boxes intersect, returning how many moved.
+// Nodes are settled one at a time in position order, starting with the root, which never
+// moves: each node drops below any settled node it overlaps. Settled nodes stay put, so
+// this always finishes and the same map always untangles the same way.
+func (m *Model) untangle() int {
+	m.finishLayoutAnimation()
+	nodes := m.nodesByPosition()
+	settled := make([]*Node, 0, len(nodes))
+	if root := m.Nodes["0"]; root != nil {
+		settled = append(settled, root)
+	}
+
+	moved := 0
+	for _, node := range nodes {
+		if node.ID == "0" {
+			continue
+		}
+		startY := node.Y
+		for {
+			blocker := firstOverlap(node, settled)
+			if blocker == nil {
+				break
+			}
+			node.Y = blocker.Y + float64(blocker.Height) + untangleGap
+		}
+		if node.Y != startY {
+			moved++
+		}
+		settled = append(settled, node)
+	}
+
+	if moved > 0 {
+		m.invalidateSpatialIndex()
+	}
+	return moved
+}
+
+// firstOverlap returns the first of the given nodes whose box intersects node's, or nil
+func firstOverlap(node *Node, others []*Node) *Node {
+	for _, other := range others {
+		if boxesOverlap(node, other) {
+			return other
+		}
+	}
+	return nil
+}
+
+// nodesByPosition returns all nodes sorted top to bottom, then left to right, then by ID
+func (m *Model) nodesByPosition() []*Node {
+	nodes := make([]*Node, 0, len(m.Nodes))
+	for _, node := range m.Nodes {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.ID < b.ID
+	})
+	return nodes
+}
+
+// boxesOverlap reports whether two nodes' boxes intersect
+func boxesOverlap(a, b *Node) bool {
+	return a.X < b.X+float64(b.Width) && b.X < a.X+float64(a.Width) &&
+		a.Y < b.Y+float64(b.Height) && b.Y < a.Y+float64(a.Height)
+}
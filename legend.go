@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// legendTextWidth is the widest a branch's text gets in the legend before it is truncated
+const legendTextWidth = 20
+
+// ToggleLegend shows or hides the legend of top-level branches
+func (m *Model) ToggleLegend() {
+	m.ShowLegend = !m.ShowLegend
+}
+
+// drawLegend draws a box listing the root's children, in order, each with a swatch of
+// its color. It sits in the top-right corner, or the bottom-left one if it would cover
+// the selected node there.
+func (m Model) drawLegend(grid [][]ColoredCell) {
+	branches := m.GetChildrenOf("0")
+	if len(branches) == 0 {
+		return
+	}
+	gridWidth, gridHeight := gridSize(grid)
+
+	// Fit as many rows as the screen allows, saying how many were left out
+	rows := min(len(branches), gridHeight-4)
+	if rows < 1 {
+		return
+	}
+	more := rows < len(branches)
+	lines := make([]string, 0, rows)
+	for i, branch := range branches[:rows] {
+		if more && i == rows-1 {
+			lines = append(lines, fmt.Sprintf("… %d more", len(branches)-i))
+			break
+		}
+		lines = append(lines, truncateWidth(singleLine(branch.Text), legendTextWidth))
+	}
+
+	title := " branches "
+	width := runewidth.StringWidth(title) + 2
+	for _, line := range lines {
+		width = max(width, runewidth.StringWidth(line)+6) // Border, padding and swatch
+	}
+	height := len(lines) + 2
+	if width+2 > gridWidth {
+		return
+	}
+
+	left, top := gridWidth-width-1, 1
+	if node := m.GetSelectedNode(); node != nil {
+		x, y, w, h := m.nodeScreenRect(grid, node)
+		box := screenRect{left, top, width, height}
+		if box.overlaps(screenRect{x - 2, y, w + 2, h}) { // Include the selection marker
+			left, top = 1, gridHeight-height-1
+		}
+	}
+
+	border := m.Theme.Info
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ch := ' '
+			switch {
+			case y == 0 && x == 0:
+				ch = '╭'
+			case y == 0 && x == width-1:
+				ch = '╮'
+			case y == height-1 && x == 0:
+				ch = '╰'
+			case y == height-1 && x == width-1:
+				ch = '╯'
+			case y == 0 || y == height-1:
+				ch = '─'
+			case x == 0 || x == width-1:
+				ch = '│'
+			}
+			grid[top+y][left+x] = ColoredCell{Char: ch, Color: border}
+		}
+	}
+	putText(grid[top], left+2, title, border)
+
+	for i, line := range lines {
+		row := grid[top+1+i][:left+width-1]
+		if more && i == len(lines)-1 {
+			putText(row, left+2, line, m.Theme.Info)
+			continue
+		}
+		putText(row, left+2, "■", m.displayColor(branches[i]))
+		putText(row, left+4, line, m.Theme.Text)
+	}
+}
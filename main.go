@@ -1,22 +1,90 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 func main() {
+	// Subcommands run without the UI
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			os.Exit(runConvert(os.Args[2:], os.Stderr))
+		case "add":
+			os.Exit(runAdd(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	// Create the model
 	m := NewModel()
 
+	// Load user settings
+	cfg, err := LoadConfig()
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error reading config: %v", err))
+	}
+	m.Config = cfg
+
+	m.Follow = cfg.FollowSelection
+
+	// NO_COLOR (or a terminal without color) gets plain monochrome output
+	m.NoColor = lipgloss.ColorProfile() == termenv.Ascii
+	if theme, ok := ThemeByName(cfg.Theme); ok {
+		m.SetTheme(theme)
+	} else {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown theme: %s", cfg.Theme))
+	}
+	if validWrapWidth(cfg.WrapWidth) {
+		m.WrapWidth = cfg.WrapWidth
+		m.resizeNodes()
+	} else {
+		m.setStatus(StatusWarn, fmt.Sprintf("Invalid wrap_width: %d", cfg.WrapWidth))
+	}
+	if !m.useConfigSpacing(cfg) {
+		m.setStatus(StatusWarn, fmt.Sprintf("Invalid spacing: horizontal_spacing and vertical_spacing must be between %d and %d", minSpacing, maxSpacing))
+	}
+
+	// Open the file given on the command line, if any
+	if len(os.Args) > 1 {
+		filename := os.Args[1]
+		if err := m.OpenFile(filename); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", filename, err)
+				os.Exit(1)
+			}
+			// File doesn't exist yet: start fresh but save to that path
+			m.CurrentFile = filename
+			m.setStatus(StatusInfo, fmt.Sprintf("New file: %s", filename))
+		} else {
+			m.setStatus(m.loadStatusLevel(), fmt.Sprintf("Loaded %s", filename)+m.loadWarningSuffix())
+			m.rememberFile()
+		}
+		// Offer to restore changes left behind by a crash
+		m.offerRecovery()
+	} else {
+		m.startWithoutFile(cfg.ResumeSession)
+	}
+
 	// Create the program
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
 	}
+
+	// Remember the map and view for next time
+	if final, ok := final.(Model); ok {
+		if err := final.saveSession(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+		}
+	}
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Mark is a bookmarked node, or a camera position when no node was selected
+type Mark struct {
+	NodeID string  `json:"node,omitempty"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	Zoom   float64 `json:"zoom,omitempty"`
+}
+
+// isMarkName reports whether key can name a mark: a single ASCII letter
+func isMarkName(key string) bool {
+	return len(key) == 1 && (key[0] >= 'a' && key[0] <= 'z' || key[0] >= 'A' && key[0] <= 'Z')
+}
+
+// SetMark records the selected node, or the camera position if nothing is selected, under name
+func (m *Model) SetMark(name string) {
+	if !isMarkName(name) {
+		m.setStatus(StatusWarn, fmt.Sprintf("Marks are named by a letter, not %q", name))
+		return
+	}
+	if m.Marks == nil {
+		m.Marks = make(map[string]Mark)
+	}
+
+	if node := m.GetSelectedNode(); node != nil {
+		m.Marks[name] = Mark{NodeID: node.ID}
+		m.setStatus(StatusInfo, fmt.Sprintf("Mark '%s set on node %s", name, node.ID))
+	} else {
+		m.Marks[name] = Mark{X: m.Camera.TargetX, Y: m.Camera.TargetY, Zoom: m.Camera.TargetZoom}
+		m.setStatus(StatusInfo, fmt.Sprintf("Mark '%s set on this view", name))
+	}
+	m.Dirty = true // Marks are saved with the map
+}
+
+// JumpToMark selects a mark's node and moves the camera to it, or restores its camera position.
+// A mark whose node has been deleted is cleared.
+func (m *Model) JumpToMark(name string) {
+	mark, ok := m.Marks[name]
+	if !ok {
+		m.setStatus(StatusWarn, fmt.Sprintf("Mark '%s not set", name))
+		return
+	}
+
+	if mark.NodeID == "" {
+		m.Camera.TargetX, m.Camera.TargetY = mark.X, mark.Y
+		if mark.Zoom > 0 {
+			m.Camera.TargetZoom = mark.Zoom
+		}
+		m.setStatus(StatusInfo, fmt.Sprintf("Mark '%s", name))
+		return
+	}
+
+	node := m.Nodes[mark.NodeID]
+	if node == nil {
+		delete(m.Marks, name)
+		m.Dirty = true
+		m.setStatus(StatusWarn, fmt.Sprintf("Mark '%s pointed at node %s, which was deleted; mark cleared", name, mark.NodeID))
+		return
+	}
+	m.expandTo(node.ID)
+	m.Selected = node.ID
+	m.centerOn(node)
+	m.setStatus(StatusInfo, fmt.Sprintf("Mark '%s: node %s", name, node.ID))
+}
+
+// ToggleMarks shows or hides the list of marks
+func (m *Model) ToggleMarks() {
+	m.ShowMarks = !m.ShowMarks
+	if m.ShowMarks && len(m.Marks) == 0 {
+		m.ShowMarks = false
+		m.setStatus(StatusWarn, "No marks set (m<letter> sets one)")
+	}
+}
+
+// renderMarksOverlay lists the marks with what each one points at
+func (m Model) renderMarksOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	goneStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Danger))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	names := make([]string, 0, len(m.Marks))
+	for name := range m.Marks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{titleStyle.Render("🔖 Marks"), ""}
+	for _, name := range names {
+		mark := m.Marks[name]
+		var desc string
+		switch node := m.Nodes[mark.NodeID]; {
+		case mark.NodeID == "":
+			desc = descStyle.Render(fmt.Sprintf("view at %.0f,%.0f, zoom %.2g", mark.X, mark.Y, mark.Zoom))
+		case node == nil:
+			desc = goneStyle.Render(fmt.Sprintf("node %s (deleted)", mark.NodeID))
+		default:
+			desc = descStyle.Render(fmt.Sprintf("node %s: %s", node.ID, truncateWidth(singleLine(node.Text), 30)))
+		}
+		lines = append(lines, "  "+keyStyle.Render("'"+name)+"  "+desc)
+	}
+	lines = append(lines, "", footerStyle.Render("Press any key to close"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
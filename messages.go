@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// OpenMessages shows the status message history, newest first
+func (m *Model) OpenMessages() {
+	m.ShowMessages = true
+	m.MessagesScroll = 0
+}
+
+// handleMessagesKey scrolls or closes the message history like the help overlay
+func (m Model) handleMessagesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	page := max(m.helpRows()-1, 1)
+	switch msg.String() {
+	case "esc", "q", "enter":
+		m.ShowMessages = false
+	case "ctrl+c":
+		return m, tea.Quit
+	case "j", "down":
+		m.scrollMessages(1)
+	case "k", "up":
+		m.scrollMessages(-1)
+	case "pgdown", " ", "ctrl+f":
+		m.scrollMessages(page)
+	case "pgup", "ctrl+b":
+		m.scrollMessages(-page)
+	case "g", "home":
+		m.MessagesScroll = 0
+	case "G", "end":
+		m.scrollMessages(len(m.Messages))
+	}
+	return m, nil
+}
+
+// scrollMessages moves the message history by delta lines, staying within the list
+func (m *Model) scrollMessages(delta int) {
+	maxScroll := max(len(m.Messages)-m.helpRows(), 0)
+	m.MessagesScroll = min(max(m.MessagesScroll+delta, 0), maxScroll)
+}
+
+// messageLines returns the history newest first, one line per message with its time and level
+func (m Model) messageLines() []string {
+	timeStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	levelStyles := map[StatusLevel]lipgloss.Style{
+		StatusInfo:  lipgloss.NewStyle().Foreground(lipgloss.Color(m.Theme.Link)),
+		StatusWarn:  lipgloss.NewStyle().Foreground(lipgloss.Color(m.Theme.Message)).Bold(true),
+		StatusError: lipgloss.NewStyle().Foreground(lipgloss.Color(m.Theme.Danger)).Bold(true),
+	}
+
+	textStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	textWidth := max(m.Width-30, 20)
+	lines := make([]string, 0, len(m.Messages))
+	for i := len(m.Messages) - 1; i >= 0; i-- {
+		entry := m.Messages[i]
+		lines = append(lines, timeStyle.Render(entry.Time.Format("15:04:05"))+"  "+
+			levelStyles[entry.Level].Render(fmt.Sprintf("%-5s", statusLevelNames[entry.Level]))+"  "+
+			textStyle.Render(truncateWidth(singleLine(entry.Text), textWidth)))
+	}
+	return lines
+}
+
+// renderMessagesOverlay lists the status message history in a panel like the help overlay
+func (m Model) renderMessagesOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	body, indicator := scrollWindow(m.messageLines(), m.MessagesScroll, m.helpRows())
+	if len(body) == 0 {
+		body = []string{footerStyle.Render("No messages yet")}
+	}
+	lines := []string{titleStyle.Render("🗒  Messages"), ""}
+	lines = append(lines, body...)
+	lines = append(lines, footerStyle.Render(indicator), footerStyle.Render("Press Esc to close"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
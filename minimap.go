@@ -0,0 +1,91 @@
+package main
+
+import "math"
+
+// Minimap box size in cells, including its border
+const (
+	minimapWidth  = 20
+	minimapHeight = 8
+)
+
+// drawMinimap draws an overview of the whole map in the bottom-right corner of the grid:
+// one dot per node, a marker for the selected node, and an outline of the visible area
+func (m Model) drawMinimap(grid [][]ColoredCell) {
+	if len(grid) < minimapHeight+2 || len(grid[0]) < minimapWidth+2 {
+		return // Not enough room to be useful
+	}
+	minX, minY, maxX, maxY, ok := m.nodeBounds()
+	if !ok {
+		return
+	}
+
+	// Box position, one cell in from the corner
+	left := len(grid[0]) - minimapWidth - 1
+	top := len(grid) - minimapHeight - 1
+	innerW, innerH := minimapWidth-2, minimapHeight-2
+
+	// Border and cleared interior
+	border := m.Theme.Info
+	for y := 0; y < minimapHeight; y++ {
+		for x := 0; x < minimapWidth; x++ {
+			ch := ' '
+			switch {
+			case y == 0 && x == 0:
+				ch = '╭'
+			case y == 0 && x == minimapWidth-1:
+				ch = '╮'
+			case y == minimapHeight-1 && x == 0:
+				ch = '╰'
+			case y == minimapHeight-1 && x == minimapWidth-1:
+				ch = '╯'
+			case y == 0 || y == minimapHeight-1:
+				ch = '─'
+			case x == 0 || x == minimapWidth-1:
+				ch = '│'
+			}
+			grid[top+y][left+x] = ColoredCell{Char: ch, Color: border}
+		}
+	}
+	for i, ch := range " map " {
+		grid[top][left+2+i] = ColoredCell{Char: ch, Color: border}
+	}
+
+	// Scale world coordinates into the interior. Nodes stacked in one spot still get
+	// a non-zero span so nothing divides by zero.
+	spanX := math.Max(maxX-minX, 1)
+	spanY := math.Max(maxY-minY, 1)
+	toCell := func(wx, wy float64) (int, int) {
+		cx := int((wx - minX) / spanX * float64(innerW-1))
+		cy := int((wy - minY) / spanY * float64(innerH-1))
+		cx = max(0, min(innerW-1, cx))
+		cy = max(0, min(innerH-1, cy))
+		return left + 1 + cx, top + 1 + cy
+	}
+
+	// Outline of what the camera currently shows
+	vx1, vy1 := m.Camera.ScreenToWorld(0, 0, m.Width, m.canvasHeight())
+	vx2, vy2 := m.Camera.ScreenToWorld(m.Width-1, m.canvasHeight()-1, m.Width, m.canvasHeight())
+	x1, y1 := toCell(vx1, vy1)
+	x2, y2 := toCell(vx2, vy2)
+	for x := x1; x <= x2; x++ {
+		grid[y1][x] = ColoredCell{Char: '┄', Color: m.Theme.Hint}
+		grid[y2][x] = ColoredCell{Char: '┄', Color: m.Theme.Hint}
+	}
+	for y := y1; y <= y2; y++ {
+		grid[y][x1] = ColoredCell{Char: '┆', Color: m.Theme.Hint}
+		grid[y][x2] = ColoredCell{Char: '┆', Color: m.Theme.Hint}
+	}
+
+	// Nodes on top of the outline, the selected node last so it's never hidden
+	for id, node := range m.Nodes {
+		if id == m.Selected || m.isHidden(id) {
+			continue
+		}
+		x, y := toCell(node.GetCenter())
+		grid[y][x] = ColoredCell{Char: '•', Color: m.displayColor(node)}
+	}
+	if node := m.GetSelectedNode(); node != nil {
+		x, y := toCell(node.GetCenter())
+		grid[y][x] = ColoredCell{Char: '◆', Color: m.Theme.Accent}
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"sort"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,11 +15,42 @@ import (
 type Mode int
 
 const (
-	ModeNormal Mode = iota // Navigation mode
-	ModeEdit               // Editing node text
-	ModeLink               // Creating links between nodes
+	ModeNormal   Mode = iota // Navigation mode
+	ModeEdit                 // Editing node text
+	ModeLink                 // Creating links between nodes
+	ModeConfirm              // Waiting for an answer to a confirmation prompt
+	ModeCommand              // Typing an ex-style command after ':'
+	ModeReparent             // Choosing a new parent for a node
+	ModeSearch               // Typing a search query
+	ModeEdge                 // Choosing an edge of the selected node to delete
+	ModeHint                 // Typing a label to jump to a node
+	ModeMove                 // Nudging the selected node with the movement keys
+	ModeVisual               // Extending a selection of several nodes
 )
 
+// ConfirmAction identifies what a confirmation prompt acts on
+type ConfirmAction int
+
+const (
+	ConfirmNone      ConfirmAction = iota
+	ConfirmDelete                  // Delete a node that has descendants or incoming cross-links
+	ConfirmOverwrite               // Save over an existing file
+	ConfirmQuit                    // Quit with unsaved changes
+	ConfirmRecover                 // Load unsaved changes from the recovery file
+	ConfirmResume                  // Reopen the map from the last session
+	ConfirmDeleteSet               // Delete every node in the visual selection
+	ConfirmReplace                 // Make the pending :s replacement
+)
+
+// Spacing used when placing new nodes, unless configured
+const (
+	defaultHorizontalSpacing = 5 // Gap between a parent and its children
+	defaultVerticalSpacing   = 3 // Gap between stacked siblings
+)
+
+// defaultFilename is used for saving and loading when no file was given
+const defaultFilename = "mindmap.json"
+
 // Model is the Bubble Tea model for the mind map
 type Model struct {
 	// Mind map data
@@ -24,23 +59,119 @@ type Model struct {
 	Camera   Camera
 	Selected string // Currently selected node ID
 
+	// Visual selection: extra nodes selected along with Selected while in visual mode
+	// (or in the reparent/command mode started from it); empty otherwise
+	SelectedSet map[string]bool
+
+	// File state
+	CurrentFile string                     // Path the map was loaded from and is saved to
+	Dirty       bool                       // True when there are changes since the last save or load
+	ExtraFields map[string]json.RawMessage // Unknown top-level fields of the loaded file, saved back unchanged
+	Marks       map[string]Mark            // Bookmarks set with m<letter>, saved with the map
+
+	// User settings
+	Config    Config
+	WrapWidth int     // Widest a line of node text gets before wrapping
+	HSpacing  float64 // Gap left between a parent and its children when placing nodes
+	VSpacing  float64 // Gap left between stacked siblings when placing nodes
+
 	// UI state
-	Mode            Mode
-	EditBuffer      string
-	IsCreatingNode  bool // True when creating new node, false when editing
-	IsCreatingChild bool // True for child (Tab), false for sibling (Enter)
-	Width           int
-	Height          int
-	NextID          int
-	StatusMsg       string
-	LinkSourceID    string // When in link mode, the source node
-	ShowHelp        bool   // True when help overlay is visible
+	TagFilter      string // When set, nodes without this tag (and not above one) are dimmed
+	Mode           Mode
+	EditBuffer     string
+	EditCursor     int               // Cursor position in EditBuffer, in runes
+	HintLabels     map[string]string // In hint mode, node IDs by label
+	HintInput      string            // Label keys typed so far in hint mode
+	MoveSubtree    bool              // In move mode, descendants move along with the node
+	Moved          bool              // In move mode, the node has moved (and undo was recorded)
+	PendingKey     string            // First key of a two-key command like "g p"
+	Count          int               // Count typed before a pan or zoom, like vim's 10l (0 when none)
+	Accel          Acceleration      // Repeats of the last key, to speed up held pan and zoom keys
+	CommandBuffer  string            // Text typed after ':' in command mode
+	Creating       CreateParams      // Node being created in edit mode (Kind is CreateNone when editing)
+	EditingNotes   bool              // In edit mode, EditBuffer holds the selected node's notes rather than its text
+	Width          int
+	Height         int
+	NextID         int
+	StatusMsg      string
+	StatusLevel    StatusLevel   // How serious StatusMsg is; errors get their own color
+	Messages       []StatusEntry // The last maxMessages status messages, oldest first
+	ShowMessages   bool          // Message history overlay (:messages)
+	MessagesScroll int           // First history line shown when the overlay is taller than the screen
+	inBatch        bool          // Inside batch: changes join the undo step already recorded
+	statusSeq      int           // Bumped for each new status message so stale expiry timers are ignored
+	LinkSourceID   string        // When in link mode, the source node
+	ConfirmAction  ConfirmAction // What the pending confirmation prompt will do
+	ConfirmTarget  string        // Node ID or path the pending confirmation applies to
+	ConfirmPrompt  string        // Question shown in the status bar
+	PendingReplace Replacement   // The :s replacement waiting for confirmation
+	QuitAfterSave  bool          // Quit once the pending save-as succeeds (q on an unnamed map)
+	LoadWarning    string        // Repairs made while loading the current file
+	NoColor        bool          // Render without color, for NO_COLOR or monochrome terminals
+	ShowMinimap    bool          // Overview of the whole map in the corner
+	ShowLegend     bool          // Colors of the top-level branches in a corner
+	ShowIDs        bool          // Label each node with its ID in the top border
+	ColorMode      string        // Coloring mode: "" (by branch), "depth" or "none"; saved with the map
+	EdgeStyle      string        // "" for curved edges or "orthogonal" for right angles; saved with the map
+	TextAlign      string        // "" (auto), "left" or "center": where text sits in node boxes; saved with the map
+	Layout         string        // "" (right) or "balanced": which sides of the root new branches go on; saved with the map
+	Follow         bool          // Move the camera to keep the selected node near the middle
+	ShowNotes      bool          // Notes panel for the selected node above the status bar
+	NotesScroll    int           // First notes line shown in the panel
+	NotesScrollID  string        // Node NotesScroll applies to; other nodes start at the top
+	ShowHelp       bool          // True when help overlay is visible
+	HelpScroll     int           // First help line shown when the overlay is taller than the screen
+	ShowStats      bool          // Branch statistics overlay; any key closes it
+	ShowMarks      bool          // List of marks (:marks); any key closes it
+	ShowCheck      bool          // Repairs made by :check; any key closes it
+	CheckReport    []string      // Lines of the :check report: a summary, then each kind of repair
+	ShowTrash      bool          // List of deleted nodes (:trash) to restore from
+	TrashIndex     int           // Chosen entry in the trash list, counting from the newest
+	ShowTemplates  bool          // Template picker (Ctrl+N)
+	TemplateIndex  int           // Chosen template in the picker
+	ShowRecent     bool          // Recent files picker, shown on startup without a file
+	RecoverOnClose bool          // The picker was shown at startup, so closing it offers crash recovery
+	RecentIndex    int           // Chosen entry in the picker: a recent map, then "New map" and "Open path…"
+	Recent         []RecentFile  // Maps in the picker, newest first
+	Ticking        bool          // True while the animation tick loop is scheduled
+	Dragging       bool          // True while the left mouse button pans the canvas
+	DragX, DragY   int           // Last mouse position during a drag
+
+	// Last change, for '.' to repeat
+	LastAction LastAction
+
+	// Deleted branches that can be restored, oldest first; saved with the map if save_trash is set
+	Trash []TrashEntry
+
+	// Command line history
+	CommandHistory     []string // Previous command lines, oldest first
+	CommandHistoryBack int      // While browsing the history, how many lines back (0 for the line being typed)
+	CommandDraft       string   // The line being typed when browsing started
+
+	// Search state
+	SearchQuery   string
+	SearchMatches []string // IDs of matching nodes, top to bottom
+	SearchIndex   int      // Index of the highlighted match
+
+	// Edge mode state
+	EdgeIndex int // Which of the selected node's edges is chosen
+
+	// Node positions being animated towards after an auto-layout
+	LayoutTargets map[string]layoutPoint
+
+	// Undo/redo history
+	UndoStack []Snapshot
+	RedoStack []Snapshot
 
 	// Colors
 	ColorPalette   []string
+	Theme          Theme // Colors used for drawing
 	NextColorIndex int
 
 	// Styles
+	styleCache    map[string]lipgloss.Style // Foreground styles by color, shared across copies
+	spatial       *spatialIndex             // Node lookup by position, shared across copies
+	depths        *depthCache               // Node depths for depth coloring, shared across copies
 	normalStyle   lipgloss.Style
 	selectedStyle lipgloss.Style
 	statusStyle   lipgloss.Style
@@ -51,31 +182,31 @@ func NewModel() Model {
 	nodes := make(map[string]*Node)
 
 	// Create initial node at center
-	initialNode := NewNode("0", "Root Idea", 0, 0)
+	initialNode := NewNode("0", "Root Idea", 0, 0, defaultWrapWidth)
 	nodes["0"] = initialNode
 
 	return Model{
-		Nodes:    nodes,
-		Edges:    make([]Edge, 0),
-		Camera:   NewCamera(),
-		Selected: "0",
-		Mode:     ModeNormal,
-		NextID:   1,
-		Width:    80,
-		Height:   24,
+		Nodes:     nodes,
+		Edges:     make([]Edge, 0),
+		Camera:    NewCamera(),
+		Selected:  "0",
+		Mode:      ModeNormal,
+		NextID:    1,
+		Config:    DefaultConfig(),
+		Width:     80,
+		WrapWidth: defaultWrapWidth,
+		HSpacing:  defaultHorizontalSpacing,
+		VSpacing:  defaultVerticalSpacing,
+		Height:    24,
 
 		// Color palette for root children branches
-		ColorPalette: []string{
-			"#FF6B6B", // Red
-			"#4ECDC4", // Cyan
-			"#45B7D1", // Blue
-			"#FFA07A", // Light Salmon
-			"#98D8C8", // Mint
-			"#F7DC6F", // Yellow
-			"#BB8FCE", // Purple
-			"#85C1E2", // Sky Blue
-		},
+		ColorPalette:   branchPalette,
 		NextColorIndex: 0,
+		Theme:          darkTheme,
+
+		styleCache: make(map[string]lipgloss.Style),
+		spatial:    &spatialIndex{},
+		depths:     &depthCache{},
 
 		normalStyle: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
@@ -93,9 +224,42 @@ func NewModel() Model {
 	}
 }
 
+// CreateKind says whether edit mode creates a node, and where
+type CreateKind int
+
+const (
+	CreateNone    CreateKind = iota // Editing an existing node
+	CreateChild                     // New child of the anchor (Tab)
+	CreateSibling                   // New sibling below the anchor (Enter)
+	CreateParent                    // New node between the anchor and its parent (I)
+)
+
+// CreateParams are captured when edit mode starts creating a node. They are applied in
+// full when the text is confirmed, or dropped on cancel without touching the map.
+type CreateParams struct {
+	Kind     CreateKind
+	AnchorID string  // Node the new one is placed relative to (empty for a floating node)
+	X, Y     float64 // Precomputed position of the new node
+	Push     bool    // Whether the following nodes must make room
+	Left     bool    // The node grows leftward: X is where its right edge goes
+}
+
+// nodeX returns the X of a planned node of the given width
+func (p CreateParams) nodeX(width int) float64 {
+	if p.Left {
+		return p.X - float64(width)
+	}
+	return p.X
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return doTick()
+	// Animation ticks are started on demand by Update
+	cmds := []tea.Cmd{m.scheduleAutosave(), scheduleRecovery()}
+	if m.StatusMsg != "" {
+		cmds = append(cmds, statusTimer(m.statusSeq, statusTimeout))
+	}
+	return tea.Batch(cmds...)
 }
 
 // GetSelectedNode returns the currently selected node
@@ -106,7 +270,15 @@ func (m *Model) GetSelectedNode() *Node {
 	return nil
 }
 
-// GetChildrenOf returns all children of a given parent node
+// FileName returns the path used for saving and loading
+func (m *Model) FileName() string {
+	if m.CurrentFile != "" {
+		return m.CurrentFile
+	}
+	return defaultFilename
+}
+
+// GetChildrenOf returns all children of a given parent node in sibling order
 func (m *Model) GetChildrenOf(parentID string) []*Node {
 	children := make([]*Node, 0)
 	for _, node := range m.Nodes {
@@ -114,200 +286,864 @@ func (m *Model) GetChildrenOf(parentID string) []*Node {
 			children = append(children, node)
 		}
 	}
+	slices.SortFunc(children, compareSiblings)
 	return children
 }
 
+// sortNodes orders nodes by vertical position, breaking ties by numeric ID
+func sortNodes(nodes []*Node) {
+	slices.SortFunc(nodes, func(a, b *Node) int {
+		if c := compareFloat(a.Y, b.Y); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+}
+
+// GetRootNodes returns nodes without a parent: the root first, then any floating nodes
+func (m *Model) GetRootNodes() []*Node {
+	roots := m.GetChildrenOf("")
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].ID == "0" && roots[j].ID != "0"
+	})
+	return roots
+}
+
+// GetDescendantsOf returns all nodes below a given node in the hierarchy
+func (m *Model) GetDescendantsOf(id string) []*Node {
+	descendants := make([]*Node, 0)
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range m.GetChildrenOf(current) {
+			if visited[child.ID] {
+				continue // Guard against parent cycles in hand-edited files
+			}
+			visited[child.ID] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child.ID)
+		}
+	}
+	return descendants
+}
+
 // AddChildNode creates a new child node to the right of the selected node
 func (m *Model) AddChildNode(text string) {
-	id := fmt.Sprintf("%d", m.NextID)
-	m.NextID++
+	m.applyCreate(m.planCreate(CreateChild), text)
+}
 
-	var x, y float64
-	var parentID string
-
-	// Position new node to the right of selected node
-	if selectedNode := m.GetSelectedNode(); selectedNode != nil {
-		spacing := 5.0          // Horizontal spacing
-		verticalSpacing := 3.0  // Vertical spacing between children
-
-		x = selectedNode.X + float64(selectedNode.Width) + spacing
-		parentID = selectedNode.ID
-
-		// Find existing children of this parent and position below them
-		existingChildren := m.GetChildrenOf(selectedNode.ID)
-		if len(existingChildren) > 0 {
-			// Find the lowest child and position below it
-			lowestY := selectedNode.Y
-			lowestHeight := selectedNode.Height
-			for _, child := range existingChildren {
-				childBottom := child.Y + float64(child.Height)
-				if childBottom > lowestY + float64(lowestHeight) {
-					lowestY = child.Y
-					lowestHeight = child.Height
-				}
+// AddSiblingNode creates a new sibling node below the selected node
+func (m *Model) AddSiblingNode(text string) {
+	m.applyCreate(m.planCreate(CreateSibling), text)
+}
+
+// planCreate works out where a new child or sibling of the selected node will go.
+// Nothing changes until the plan is passed to applyCreate.
+func (m *Model) planCreate(kind CreateKind) CreateParams {
+	anchor := m.GetSelectedNode()
+	if (kind == CreateSibling || kind == CreateParent) && anchor != nil && anchor.ID != "0" {
+		// Left of the root, nodes line up on their right edges
+		x, left := anchor.X, m.onLeft(anchor)
+		if left {
+			x += float64(anchor.Width)
+		}
+
+		if kind == CreateSibling {
+			// Same X as the selected node, just below it
+			return CreateParams{
+				Kind:     CreateSibling,
+				AnchorID: anchor.ID,
+				X:        x,
+				Y:        anchor.Y + float64(anchor.Height) + m.VSpacing,
+				Push:     true,
+				Left:     left,
 			}
-			y = lowestY + float64(lowestHeight) + verticalSpacing
+		}
 
-			// Push down nodes below this position
-			newNodeHeight := 3 // Default height
-			spaceNeeded := float64(newNodeHeight) + verticalSpacing
-			m.pushDownNodesBelow(y, spaceNeeded)
-		} else {
-			// First child, align with parent
-			y = selectedNode.Y
+		// Take the selected node's place; it moves outward to make room
+		return CreateParams{
+			Kind:     CreateParent,
+			AnchorID: anchor.ID,
+			X:        x,
+			Y:        anchor.Y,
+			Left:     left,
 		}
+	}
+
+	// Root can't have siblings or a parent, and without a selection the node floats - create a child instead
+	return m.planChild(anchor)
+}
+
+// planChild works out where a new child of parent will go, or a floating node at the
+// camera center if parent is nil
+func (m *Model) planChild(parent *Node) CreateParams {
+	p := CreateParams{Kind: CreateChild}
+	if parent == nil {
+		p.X, p.Y = m.Camera.GetViewportCenter()
+		return p
+	}
+
+	// Position new node to the right of the parent, or to its left in a left branch or
+	// when a balanced map's root needs one there
+	p.AnchorID = parent.ID
+	p.Left = m.onLeft(parent) || (parent.ID == "0" && m.newBranchOnLeft())
+	p.X = parent.X + float64(parent.Width) + m.HSpacing
+	if p.Left {
+		p.X = parent.X - m.HSpacing
+	}
+
+	// Find existing children of this parent on that side and position below them
+	existingChildren, leftChildren := m.sides(m.GetChildrenOf(parent.ID))
+	if p.Left {
+		existingChildren = leftChildren
+	}
+	if len(existingChildren) == 0 {
+		// First child, align with parent
+		p.Y = parent.Y
+		return p
+	}
+
+	// Find the lowest child and position below it
+	lowestY := parent.Y
+	lowestHeight := parent.Height
+	for _, child := range existingChildren {
+		childBottom := child.Y + float64(child.Height)
+		if childBottom > lowestY+float64(lowestHeight) {
+			lowestY = child.Y
+			lowestHeight = child.Height
+		}
+	}
+	p.Y = lowestY + float64(lowestHeight) + m.VSpacing
+	p.Push = true
+	return p
+}
+
+// applyCreate creates a planned node as one undoable step and selects it
+func (m *Model) applyCreate(p CreateParams, text string) {
+	m.pushUndo(fmt.Sprintf("create node %d", m.NextID))
+	node := m.createNode(p, text)
+	m.remember(LastAction{Kind: RepeatCreate, Create: p.Kind, Text: text})
+
+	m.expandTo(node.ID) // A child of a collapsed node would be created out of sight
+	m.Selected = node.ID
+	if p.Kind == CreateSibling {
+		m.setStatus(StatusInfo, fmt.Sprintf("Created sibling node %s", node.ID))
+	} else if p.Kind == CreateParent {
+		m.setStatus(StatusInfo, fmt.Sprintf("Inserted node %s above %s", node.ID, p.AnchorID))
 	} else {
-		// Fallback to camera center if no selected node
-		x, y = m.Camera.GetViewportCenter()
+		m.setStatus(StatusInfo, fmt.Sprintf("Created child node %s", node.ID))
 	}
+}
 
-	node := NewNode(id, text, x, y)
-	node.ParentID = parentID
+// addChild creates a child of parent, or a floating node at the camera center if parent is nil
+func (m *Model) addChild(parent *Node, text string) *Node {
+	return m.createNode(m.planChild(parent), text)
+}
+
+// createNode adds a planned node, making room for it and linking it to its parent
+func (m *Model) createNode(p CreateParams, text string) *Node {
+	id := fmt.Sprintf("%d", m.NextID)
+	m.NextID++
+
+	anchor := m.Nodes[p.AnchorID]
+	parent := anchor
+	if (p.Kind == CreateSibling || p.Kind == CreateParent) && anchor != nil {
+		parent = m.Nodes[anchor.ParentID] // Same parent as the anchor
+	}
+
+	// Push down the following nodes of this branch by the new node's real height
+	if p.Push && anchor != nil {
+		_, newNodeHeight := calculateNodeSize(text, m.WrapWidth)
+		m.makeRoomBelow(anchor, p.Y, float64(newNodeHeight)+m.VSpacing)
+	}
+
+	node := NewNode(id, text, p.X, p.Y, m.WrapWidth)
+	node.X = p.nodeX(node.Width)
 
 	// Assign color based on parent
-	if parentID == "0" {
+	if parent != nil && parent.ID == "0" {
 		// Child of root: assign next color from palette
 		node.Color = m.ColorPalette[m.NextColorIndex%len(m.ColorPalette)]
 		m.NextColorIndex++
-	} else if parentID != "" {
+	} else if parent != nil {
 		// Inherit parent's color
-		if parent := m.Nodes[parentID]; parent != nil {
-			node.Color = parent.Color
-		}
+		node.Color = parent.Color
+	} else if anchor != nil {
+		// Sibling of a floating node shares its color
+		node.Color = anchor.Color
 	}
 
+	// Siblings go right after their anchor, children and inserted parents after the last child.
+	// An inserted parent takes over its anchor's place.
+	switch {
+	case p.Kind == CreateSibling && anchor != nil:
+		node.Order = m.insertOrder(anchor)
+	case p.Kind == CreateParent && anchor != nil:
+		node.Order = anchor.Order
+		anchor.Order = 0
+	case parent != nil:
+		node.Order = m.nextOrder(parent.ID)
+	default:
+		node.Order = m.nextOrder("")
+	}
 	m.Nodes[id] = node
 
 	// Automatically create edge from parent to new node
-	if parentID != "" {
-		m.AddEdge(parentID, id)
+	if parent != nil {
+		node.ParentID = parent.ID
+		m.linkNodes(parent.ID, id)
 	}
 
-	m.Selected = id
-	m.StatusMsg = fmt.Sprintf("Created child node %s", id)
+	// An inserted parent adopts the anchor, whose subtree shifts right and takes on its color
+	if p.Kind == CreateParent && anchor != nil {
+		if parent != nil {
+			m.removeEdge(parent.ID, anchor.ID)
+		}
+		anchor.ParentID = id
+		m.linkNodes(id, anchor.ID)
+		shift := float64(node.Width) + m.HSpacing
+		if p.Left {
+			shift = -shift
+		}
+		m.moveSubtree(anchor.ID, shift, 0)
+		if parent != nil {
+			anchor.Color = node.Color
+			for _, descendant := range m.GetDescendantsOf(anchor.ID) {
+				descendant.Color = node.Color
+			}
+		}
+	}
+
+	return node
 }
 
-// AddSiblingNode creates a new sibling node below the selected node
-func (m *Model) AddSiblingNode(text string) {
-	selectedNode := m.GetSelectedNode()
-	if selectedNode == nil {
-		// If no selection, create a child instead
-		m.AddChildNode(text)
-		return
+// makeRoomBelow moves subtrees down to make room for a node inserted at thresholdY next to anchor.
+// Only siblings of anchor and of each of its ancestors move, so unrelated trees stay put,
+// and branches on the other side of the root stay put too.
+func (m *Model) makeRoomBelow(anchor *Node, thresholdY, amount float64) {
+	left := m.onLeft(anchor)
+	visited := make(map[string]bool)
+	for node := anchor; node != nil && node.ParentID != "" && !visited[node.ID]; node = m.Nodes[node.ParentID] {
+		visited[node.ID] = true
+		for _, sibling := range m.GetChildrenOf(node.ParentID) {
+			if sibling.ID != node.ID && sibling.Y >= thresholdY && m.onLeft(sibling) == left {
+				m.moveSubtree(sibling.ID, 0, amount)
+			}
+		}
 	}
+}
 
-	// Root node can't have siblings - create child instead
-	if selectedNode.ID == "0" {
-		m.AddChildNode(text)
+// DeleteNode removes a node together with all of its descendants
+func (m *Model) DeleteNode(id string) {
+	if id == "0" {
+		m.setStatus(StatusWarn, "Cannot delete root node")
 		return
 	}
 
-	id := fmt.Sprintf("%d", m.NextID)
-	m.NextID++
+	node := m.Nodes[id]
+	if node == nil {
+		return
+	}
 
-	// Position at same X as selected node, but below it
-	verticalSpacing := 3.0
-	x := selectedNode.X
-	newNodeHeight := 3 // Default height for new node
-	y := selectedNode.Y + float64(selectedNode.Height) + verticalSpacing
+	m.pushUndo(fmt.Sprintf("delete node %s", id))
 
-	// Calculate how much space the new node will take
-	spaceNeeded := float64(newNodeHeight) + verticalSpacing
+	ids := []string{id}
+	for _, descendant := range m.GetDescendantsOf(id) {
+		ids = append(ids, descendant.ID)
+	}
+	m.trashNodes(ids)
+	m.removeNodes(ids)
+	m.selectAfterDelete(node)
+	m.remember(LastAction{Kind: RepeatDelete})
 
-	// Push down all nodes that are below this Y position
-	m.pushDownNodesBelow(y, spaceNeeded)
+	if len(ids) > 1 {
+		m.setStatus(StatusInfo, fmt.Sprintf("Deleted node %s and %d descendants", id, len(ids)-1))
+	} else {
+		m.setStatus(StatusInfo, fmt.Sprintf("Deleted node %s", id))
+	}
+}
 
-	node := NewNode(id, text, x, y)
-	node.ParentID = selectedNode.ParentID // Same parent as sibling
+// SpliceNode removes a single node and reattaches its children to its parent,
+// shifting them toward the parent into the gap. Cross-links to or from the node are dropped.
+func (m *Model) SpliceNode(id string) {
+	if id == "0" {
+		m.setStatus(StatusWarn, "Cannot splice the root node")
+		return
+	}
 
-	// Assign color based on parent
-	if selectedNode.ParentID == "0" {
-		// Sibling of root's child: assign NEW color from palette
-		node.Color = m.ColorPalette[m.NextColorIndex%len(m.ColorPalette)]
-		m.NextColorIndex++
-	} else {
-		// Regular sibling: inherit color
-		node.Color = selectedNode.Color
+	node := m.Nodes[id]
+	if node == nil {
+		return
 	}
 
-	m.Nodes[id] = node
+	m.pushUndo(fmt.Sprintf("splice node %s", id))
 
-	// Connect to same parent as the selected node
-	if selectedNode.ParentID != "" {
-		m.AddEdge(selectedNode.ParentID, id)
+	children := m.GetChildrenOf(id)
+	crossLinks := 0
+	for _, edge := range m.Edges {
+		if edge.FromID == id && !m.isChildOf(edge.ToID, id) || edge.ToID == id && edge.FromID != node.ParentID {
+			crossLinks++
+		}
 	}
 
-	m.Selected = id
-	m.StatusMsg = fmt.Sprintf("Created sibling node %s", id)
-}
+	// Move the children back by the same amount so they keep their relative layout. Left of
+	// the root they line up on their right edges, so those take the node's right edge.
+	if len(children) > 0 {
+		shift := node.X - children[0].X
+		if m.onLeft(node) {
+			right := node.X + float64(node.Width)
+			shift = right - (children[0].X + float64(children[0].Width))
+			for _, child := range children[1:] {
+				shift = min(shift, right-(child.X+float64(child.Width)))
+			}
+		} else {
+			for _, child := range children[1:] {
+				shift = max(shift, node.X-child.X)
+			}
+		}
+		for _, child := range children {
+			m.moveSubtree(child.ID, shift, 0)
+		}
+	}
 
-// pushDownNodesBelow moves all nodes below a certain Y position downward
-func (m *Model) pushDownNodesBelow(thresholdY, amount float64) {
-	for _, node := range m.Nodes {
-		if node.Y >= thresholdY {
-			node.Y += amount
+	// The children take the node's place among its siblings
+	var siblings []*Node
+	for _, sibling := range m.GetChildrenOf(node.ParentID) {
+		if sibling.ID == id {
+			siblings = append(siblings, children...)
+		} else {
+			siblings = append(siblings, sibling)
 		}
 	}
+	for _, child := range children {
+		child.ParentID = node.ParentID
+	}
+	renumber(siblings)
+	m.removeNodes([]string{id})
+
+	// Replace the removed parent edges with edges from the grandparent
+	if node.ParentID != "" {
+		for _, child := range children {
+			m.linkNodes(node.ParentID, child.ID)
+		}
+	}
+
+	m.selectAfterDelete(node)
+	m.remember(LastAction{Kind: RepeatSplice})
+	msg := fmt.Sprintf("Spliced node %s, reparented %d children", id, len(children))
+	if crossLinks > 0 {
+		msg += fmt.Sprintf(", removed %d cross-links", crossLinks)
+	}
+	m.setStatus(StatusInfo, msg)
 }
 
-// DeleteNode removes a node and its associated edges
-func (m *Model) DeleteNode(id string) {
-	if id == "0" {
-		m.StatusMsg = "Cannot delete root node"
+// isChildOf reports whether id's tree parent is parentID
+func (m *Model) isChildOf(id, parentID string) bool {
+	node := m.Nodes[id]
+	return node != nil && node.ParentID == parentID
+}
+
+// DuplicateNode copies a node, or its whole subtree, as a new sibling just below the original.
+// Edges between copied nodes are copied too; links to nodes outside the copy are not.
+func (m *Model) DuplicateNode(id string, subtree bool) {
+	node := m.Nodes[id]
+	if node == nil {
 		return
 	}
+	if node.ParentID == "" {
+		m.setStatus(StatusWarn, "Cannot duplicate a root node")
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("duplicate node %s", id))
+
+	originals := []*Node{node}
+	top, bottom := node.Y, node.Y+float64(node.Height)
+	if subtree {
+		originals = append(originals, m.GetDescendantsOf(id)...)
+		top, bottom = m.subtreeBounds(id)
+	}
+
+	// Place the copy below the original (and its subtree) and push the following siblings down
+	y := bottom + m.VSpacing
+	m.makeRoomBelow(node, y, bottom-top+m.VSpacing)
+	order := m.insertOrder(node)
+
+	copies := make(map[string]*Node, len(originals))
+	for _, original := range originals {
+		c := original.Clone()
+		c.ID = fmt.Sprintf("%d", m.NextID)
+		m.NextID++
+		c.Y += y - top
+		c.Links = make([]string, 0)
+		copies[original.ID] = c
+	}
+	for _, c := range copies {
+		if parent, ok := copies[c.ParentID]; ok {
+			c.ParentID = parent.ID
+		}
+		m.Nodes[c.ID] = c
+	}
+
+	// A new branch under root gets its own color, like a new sibling would
+	duplicate := copies[id]
+	duplicate.Order = order
+	if duplicate.ParentID == "0" {
+		color := m.ColorPalette[m.NextColorIndex%len(m.ColorPalette)]
+		m.NextColorIndex++
+		for _, c := range copies {
+			c.Color = color
+		}
+	}
 
-	delete(m.Nodes, id)
+	m.linkNodes(duplicate.ParentID, duplicate.ID)
+	for _, edge := range append([]Edge(nil), m.Edges...) {
+		from, fromOK := copies[edge.FromID]
+		to, toOK := copies[edge.ToID]
+		if fromOK && toOK {
+			m.linkNodes(from.ID, to.ID)
+		}
+	}
+
+	m.Selected = duplicate.ID
+	m.revealNode(duplicate)
+	m.remember(LastAction{Kind: RepeatDuplicate, Subtree: subtree})
+	if len(copies) > 1 {
+		m.setStatus(StatusInfo, fmt.Sprintf("Duplicated node %s and %d descendants", id, len(copies)-1))
+	} else {
+		m.setStatus(StatusInfo, fmt.Sprintf("Duplicated node %s", id))
+	}
+}
+
+// removeNodes deletes nodes along with every edge and link that touches them
+func (m *Model) removeNodes(ids []string) {
+	removed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+		delete(m.Nodes, id)
+	}
 
-	// Remove associated edges
-	newEdges := make([]Edge, 0)
+	newEdges := make([]Edge, 0, len(m.Edges))
 	for _, edge := range m.Edges {
-		if edge.FromID != id && edge.ToID != id {
+		if !removed[edge.FromID] && !removed[edge.ToID] {
 			newEdges = append(newEdges, edge)
 		}
 	}
 	m.Edges = newEdges
 
-	// Deselect if this was selected
-	if m.Selected == id {
-		m.Selected = ""
-		// Select first available node
-		for nodeID := range m.Nodes {
-			m.Selected = nodeID
-			break
+	for _, node := range m.Nodes {
+		links := node.Links[:0]
+		for _, linkID := range node.Links {
+			if !removed[linkID] {
+				links = append(links, linkID)
+			}
 		}
+		node.Links = links
 	}
+}
 
-	m.StatusMsg = fmt.Sprintf("Deleted node %s", id)
+// selectAfterDelete moves the selection to the parent of a deleted node, or to the
+// node closest to where it was when the parent is gone too
+func (m *Model) selectAfterDelete(deleted *Node) {
+	if m.Nodes[m.Selected] != nil {
+		return
+	}
+	if m.Nodes[deleted.ParentID] != nil {
+		m.Selected = deleted.ParentID
+		return
+	}
+	m.Selected = ""
+	if nearest := m.nearestNode(deleted.GetCenter()); nearest != nil {
+		m.Selected = nearest.ID
+	}
 }
 
-// AddEdge creates a link between two nodes
+// nearestNode returns the shown node whose center is closest to a world position,
+// preferring the lower ID on ties so the choice doesn't depend on map order
+func (m *Model) nearestNode(x, y float64) *Node {
+	var best *Node
+	bestDist := 0.0
+	for _, node := range m.Nodes {
+		if m.isHidden(node.ID) {
+			continue
+		}
+		cx, cy := node.GetCenter()
+		dist := (cx-x)*(cx-x) + (cy-y)*(cy-y)
+		if best == nil || dist < bestDist || dist == bestDist && compareIDs(node.ID, best.ID) < 0 {
+			best, bestDist = node, dist
+		}
+	}
+	return best
+}
+
+// AddEdge creates a link between two nodes. Two nodes are only linked once: unless
+// directed_links is set, a link back the other way counts as the same link.
 func (m *Model) AddEdge(fromID, toID string) {
-	// Check if edge already exists
+	if fromID == toID {
+		m.setStatus(StatusWarn, "Cannot link a node to itself")
+		return
+	}
 	for _, edge := range m.Edges {
 		if edge.FromID == fromID && edge.ToID == toID {
-			m.StatusMsg = "Edge already exists"
+			m.setStatus(StatusWarn, "Edge already exists")
+			return
+		}
+		if edge.FromID == toID && edge.ToID == fromID && !m.Config.DirectedLinks {
+			m.setStatus(StatusWarn, fmt.Sprintf("Already linked %s → %s", toID, fromID))
 			return
 		}
 	}
 
+	m.pushUndo(fmt.Sprintf("link %s → %s", fromID, toID))
+	m.linkNodes(fromID, toID)
+	m.setStatus(StatusInfo, fmt.Sprintf("Created link %s → %s", fromID, toID))
+}
+
+// subtreeBounds returns the vertical extent of a node together with its descendants
+func (m *Model) subtreeBounds(id string) (top, bottom float64) {
+	node := m.Nodes[id]
+	top, bottom = node.Y, node.Y+float64(node.Height)
+	for _, descendant := range m.GetDescendantsOf(id) {
+		top = math.Min(top, descendant.Y)
+		bottom = math.Max(bottom, descendant.Y+float64(descendant.Height))
+	}
+	return top, bottom
+}
+
+// moveSubtree shifts a node and all of its descendants by the given offset
+func (m *Model) moveSubtree(id string, dx, dy float64) {
+	node := m.Nodes[id]
+	node.X += dx
+	node.Y += dy
+	for _, descendant := range m.GetDescendantsOf(id) {
+		descendant.X += dx
+		descendant.Y += dy
+	}
+}
+
+// MoveSibling swaps the selected node with its previous (dir < 0) or next (dir > 0) sibling.
+// Each node's subtree moves along with it.
+func (m *Model) MoveSibling(dir int) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+
+	siblings := m.GetChildrenOf(node.ParentID)
+	idx := -1
+	for i, sibling := range siblings {
+		if sibling.ID == node.ID {
+			idx = i
+			break
+		}
+	}
+	other := idx + dir
+	if node.ParentID == "" || other < 0 || other >= len(siblings) {
+		m.setStatus(StatusWarn, "No sibling to swap with")
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("move node %s", node.ID))
+
+	// Swap the two subtree blocks, keeping the gap between them
+	upper, lower := siblings[idx], siblings[other]
+	if dir < 0 {
+		upper, lower = lower, upper
+	}
+	upperTop, upperBottom := m.subtreeBounds(upper.ID)
+	lowerTop, lowerBottom := m.subtreeBounds(lower.ID)
+	gap := lowerTop - upperBottom
+
+	m.moveSubtree(lower.ID, 0, upperTop-lowerTop)
+	m.moveSubtree(upper.ID, 0, (lowerBottom-lowerTop)+gap)
+	siblings[idx], siblings[other] = siblings[other], siblings[idx]
+	renumber(siblings)
+
+	m.revealNode(node)
+	m.remember(LastAction{Kind: RepeatMoveSibling, Dir: dir})
+	if dir < 0 {
+		m.setStatus(StatusInfo, fmt.Sprintf("Moved node %s up", node.ID))
+	} else {
+		m.setStatus(StatusInfo, fmt.Sprintf("Moved node %s down", node.ID))
+	}
+}
+
+// ReparentNode moves a node and its subtree under a new parent
+func (m *Model) ReparentNode(id, newParentID string) {
+	node, newParent := m.Nodes[id], m.Nodes[newParentID]
+	if node == nil || newParent == nil {
+		return
+	}
+	if id == "0" {
+		m.setStatus(StatusWarn, "Cannot move root node")
+		return
+	}
+	if node.ParentID == newParentID {
+		m.setStatus(StatusWarn, fmt.Sprintf("Node %s is already a child of %s", id, newParentID))
+		return
+	}
+
+	subtree := m.GetDescendantsOf(id)
+	moving := map[string]bool{id: true}
+	for _, descendant := range subtree {
+		moving[descendant.ID] = true
+	}
+	if moving[newParentID] {
+		m.setStatus(StatusWarn, "Cannot move a node under its own descendant")
+		return
+	}
+
+	// Work out where it goes before it joins the new parent's children, like AddChildNode
+	p := m.planChild(newParent)
+	wasLeft := m.onLeft(node)
+	if p.Push {
+		// A whole subtree has to clear the new siblings' subtrees, not just the siblings
+		siblings, leftSiblings := m.sides(m.GetChildrenOf(newParentID))
+		if p.Left {
+			siblings = leftSiblings
+		}
+		for _, sibling := range siblings {
+			_, bottom := m.subtreeBounds(sibling.ID)
+			p.Y = max(p.Y, bottom+m.VSpacing)
+		}
+	}
+
+	m.pushUndo(fmt.Sprintf("reparent node %s", id))
+
+	// Replace the old parent edge with one from the new parent
+	if node.ParentID != "" {
+		m.removeEdge(node.ParentID, id)
+	}
+	node.Order = m.nextOrder(newParentID)
+	node.ParentID = newParentID
+	m.linkNodes(newParentID, id)
+
+	// Recolor the branch: new palette color under root, otherwise inherit
+	color := newParent.Color
+	if newParentID == "0" {
+		color = m.ColorPalette[m.NextColorIndex%len(m.ColorPalette)]
+		m.NextColorIndex++
+	}
+	node.Color = color
+	for _, descendant := range subtree {
+		descendant.Color = color
+	}
+
+	// Only the new parent's branch makes room for the whole subtree
+	top, bottom := m.subtreeBounds(id)
+	if p.Push {
+		m.makeRoomBelow(newParent, p.Y, bottom-top+m.VSpacing)
+	}
+
+	// A subtree crossing to the other side of the root turns around to grow the other way
+	if p.Left != wasLeft {
+		centerX, _ := node.GetCenter()
+		for _, descendant := range subtree {
+			descendant.X = 2*centerX - descendant.X - float64(descendant.Width)
+		}
+	}
+	m.moveSubtree(id, p.nodeX(node.Width)-node.X, p.Y-top)
+
+	m.revealNode(node)
+	m.setStatus(StatusInfo, fmt.Sprintf("Moved node %s under %s", id, newParentID))
+}
+
+// removeEdge deletes the edge between two nodes along with the matching link entry
+func (m *Model) removeEdge(fromID, toID string) {
+	edges := m.Edges[:0]
+	for _, edge := range m.Edges {
+		if edge.FromID != fromID || edge.ToID != toID {
+			edges = append(edges, edge)
+		}
+	}
+	m.Edges = edges
+
+	if node := m.Nodes[fromID]; node != nil {
+		links := node.Links[:0]
+		for _, linkID := range node.Links {
+			if linkID != toID {
+				links = append(links, linkID)
+			}
+		}
+		node.Links = links
+	}
+}
+
+// centerOn moves the camera to the center of a node
+func (m *Model) centerOn(node *Node) {
+	if node == nil {
+		return
+	}
+	m.Camera.TargetX, m.Camera.TargetY = node.GetCenter()
+}
+
+// followMargin is the fraction of the view on each side outside which follow mode recenters
+const followMargin = 0.2
+
+// followSelection centers the camera on the selected node when it falls outside the
+// middle of the view, judged by where the camera is heading. Nodes already well in view
+// leave the camera alone.
+func (m *Model) followSelection() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+
+	target := m.Camera
+	target.X, target.Y, target.Zoom = m.Camera.TargetX, m.Camera.TargetY, m.Camera.TargetZoom
+	cx, cy := node.GetCenter()
+	sx, sy := target.WorldToScreen(cx, cy, m.Width, m.canvasHeight())
+	w, h := float64(m.Width), float64(m.canvasHeight())
+	if float64(sx) >= w*followMargin && float64(sx) <= w*(1-followMargin) &&
+		float64(sy) >= h*followMargin && float64(sy) <= h*(1-followMargin) {
+		return
+	}
+	m.centerOn(node)
+}
+
+// ToggleFollow turns follow mode on or off
+func (m *Model) ToggleFollow() {
+	m.Follow = !m.Follow
+	if m.Follow {
+		m.followSelection()
+		m.setStatus(StatusInfo, "Follow selection: on")
+	} else {
+		m.setStatus(StatusInfo, "Follow selection: off")
+	}
+}
+
+// revealNode moves the camera to a node if it isn't fully on screen
+func (m *Model) revealNode(node *Node) {
+	sx1, sy1 := m.Camera.WorldToScreen(node.X, node.Y, m.Width, m.canvasHeight())
+	sx2, sy2 := m.Camera.WorldToScreen(node.X+float64(node.Width), node.Y+float64(node.Height), m.Width, m.canvasHeight())
+	if sx1 >= 0 && sy1 >= 0 && sx2 <= m.Width && sy2 <= m.canvasHeight() {
+		return
+	}
+	m.Camera.TargetX, m.Camera.TargetY = node.GetCenter()
+}
+
+// zoomBy zooms around the selected node so it stays put on screen,
+// or around the camera center when nothing is selected
+func (m *Model) zoomBy(factor float64) {
+	if node := m.GetSelectedNode(); node != nil {
+		cx, cy := node.GetCenter()
+		m.Camera.ZoomAt(factor, cx, cy)
+		return
+	}
+	m.Camera.ZoomAt(factor, m.Camera.TargetX, m.Camera.TargetY)
+}
+
+// ResetCamera animates back to zoom 1.0 centered on the root node.
+// Without a root it centers on the middle of the map instead.
+func (m *Model) ResetCamera() {
+	m.Camera.TargetZoom = 1.0
+	if roots := m.GetRootNodes(); len(roots) > 0 {
+		m.centerOn(roots[0])
+		return
+	}
+	m.Camera.TargetX, m.Camera.TargetY = 0, 0
+	if minX, minY, maxX, maxY, ok := m.nodeBounds(); ok {
+		m.Camera.TargetX, m.Camera.TargetY = (minX+maxX)/2, (minY+maxY)/2
+	}
+}
+
+// nodeBounds returns the world-space bounding box of all nodes, or ok=false if there are none
+func (m *Model) nodeBounds() (minX, minY, maxX, maxY float64, ok bool) {
+	for _, node := range m.Nodes {
+		right := node.X + float64(node.Width)
+		bottom := node.Y + float64(node.Height)
+		if !ok {
+			minX, minY, maxX, maxY = node.X, node.Y, right, bottom
+			ok = true
+			continue
+		}
+		minX = math.Min(minX, node.X)
+		minY = math.Min(minY, node.Y)
+		maxX = math.Max(maxX, right)
+		maxY = math.Max(maxY, bottom)
+	}
+	return minX, minY, maxX, maxY, ok
+}
+
+// FitToScreen zooms and centers the camera so the whole map is visible
+func (m *Model) FitToScreen() {
+	minX, minY, maxX, maxY, ok := m.nodeBounds()
+	if !ok {
+		return
+	}
+
+	if len(m.Nodes) == 1 {
+		// A single node just gets centered at normal zoom
+		m.Camera.TargetX = (minX + maxX) / 2
+		m.Camera.TargetY = (minY + maxY) / 2
+		m.Camera.TargetZoom = 1.0
+	} else {
+		m.Camera.FitBounds(minX, minY, maxX, maxY, m.Width, m.canvasHeight())
+	}
+	m.setStatus(StatusInfo, "Fit map to screen")
+}
+
+// Zoom to selection: the selected node and its children fill selectionFill of the screen,
+// or a node without children is shown at readingZoom (smaller if it wouldn't fit)
+const (
+	selectionFill = 0.7
+	readingZoom   = 1.75
+)
+
+// ZoomToSelection centers on the selected node and zooms so that it and its children
+// fill most of the screen
+func (m *Model) ZoomToSelection() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+	minX, minY := node.X, node.Y
+	maxX, maxY := node.X+float64(node.Width), node.Y+float64(node.Height)
+	var children []*Node
+	if !node.Collapsed {
+		children = m.GetChildrenOf(node.ID)
+	}
+	for _, child := range children {
+		minX, minY = min(minX, child.X), min(minY, child.Y)
+		maxX, maxY = max(maxX, child.X+float64(child.Width)), max(maxY, child.Y+float64(child.Height))
+	}
+
+	fill := math.Min(float64(m.Width)/(maxX-minX), float64(m.canvasHeight())/(maxY-minY)) * selectionFill
+	zoom := fill
+	if len(children) == 0 {
+		zoom = math.Min(readingZoom, fill)
+	}
+	m.Camera.TargetX = (minX + maxX) / 2
+	m.Camera.TargetY = (minY + maxY) / 2
+	m.Camera.TargetZoom = math.Max(minZoom, math.Min(maxZoom, zoom))
+	target := node.ID
+	if len(children) > 0 {
+		target = fmt.Sprintf("%s and %d children", node.ID, len(children))
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Zoomed to %s (%.0f%%)", target, m.Camera.TargetZoom*100))
+}
+
+// linkNodes appends an edge and records it in the source node's links
+func (m *Model) linkNodes(fromID, toID string) {
 	m.Edges = append(m.Edges, Edge{FromID: fromID, ToID: toID})
 
 	// Also add to node's links
 	if node := m.Nodes[fromID]; node != nil {
 		node.Links = append(node.Links, toID)
 	}
-
-	m.StatusMsg = fmt.Sprintf("Created link %s → %s", fromID, toID)
 }
 
 // GetNodeAt returns the node at the given screen coordinates (if any)
 func (m *Model) GetNodeAt(screenX, screenY int) *Node {
-	wx, wy := m.Camera.ScreenToWorld(screenX, screenY, m.Width, m.Height)
+	// The last row is the status bar, so the canvas is one row shorter than the screen
+	wx, wy := m.Camera.ScreenToWorld(screenX, screenY, m.Width, m.canvasHeight())
 
-	for _, node := range m.Nodes {
-		if wx >= node.X && wx <= node.X+float64(node.Width) &&
-			wy >= node.Y && wy <= node.Y+float64(node.Height) {
+	for _, node := range m.nodesInRect(wx, wy, wx, wy) {
+		if wx >= node.X && wx < node.X+float64(node.Width) &&
+			wy >= node.Y && wy < node.Y+float64(node.Height) {
 			return node
 		}
 	}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel returns a fresh model that doesn't read the user's config or recent files
+func newTestModel(t testing.TB) Model {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	return NewModel()
+}
+
+// addTestChild adds a child with the given text under parentID and returns its ID
+func addTestChild(m *Model, parentID, text string) string {
+	return m.addChild(m.Nodes[parentID], text).ID
+}
+
+// testKeys maps key names, as msg.String() spells them, to the keys that produce them
+var testKeys = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEscape,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+	"backspace": tea.KeyBackspace,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	" ":         tea.KeySpace,
+}
+
+// keyMsg returns the key press named key, e.g. "x", "enter", "ctrl+d" or "alt+i"
+func keyMsg(key string) tea.KeyMsg {
+	alt := false
+	if rest, ok := strings.CutPrefix(key, "alt+"); ok && len(rest) > 0 {
+		alt, key = true, rest
+	}
+	if keyType, ok := testKeys[key]; ok {
+		return tea.KeyMsg{Type: keyType, Alt: alt}
+	}
+	if letter, ok := strings.CutPrefix(key, "ctrl+"); ok && len(letter) == 1 {
+		return tea.KeyMsg{Type: tea.KeyCtrlA + tea.KeyType(letter[0]-'a'), Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key), Alt: alt}
+}
+
+// press sends key presses to the model in turn and returns the updated model
+func press(m Model, keys ...string) Model {
+	for _, key := range keys {
+		updated, _ := m.Update(keyMsg(key))
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestKeyMsgNames(t *testing.T) {
+	for _, key := range []string{"x", "N", "enter", "esc", "tab", "ctrl+d", "ctrl+e", "alt+i", "alt+enter", " "} {
+		if got := keyMsg(key).String(); got != key {
+			t.Errorf("keyMsg(%q).String() = %q", key, got)
+		}
+	}
+}
+
+// mapState returns the nodes and edges as saved, so any change to them shows up
+func mapState(t *testing.T, m Model) string {
+	t.Helper()
+	data, err := json.Marshal(struct {
+		Nodes map[string]*Node
+		Edges []Edge
+	}{m.Nodes, m.Edges})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCancelledCreateLeavesMapUntouched(t *testing.T) {
+	tests := []struct {
+		name   string
+		create string // Key that starts creating a node
+		keys   []string
+	}{
+		{"sibling esc", "enter", []string{"esc"}},
+		{"sibling typed then esc", "enter", []string{"a", "b", "esc"}},
+		{"sibling empty enter", "enter", []string{"enter"}},
+		{"sibling blank enter", "enter", []string{" ", " ", "enter"}},
+		{"child esc", "tab", []string{"esc"}},
+		{"child typed then esc", "tab", []string{"a", "esc"}},
+		{"child empty enter", "tab", []string{"enter"}},
+		{"parent esc", "I", []string{"esc"}},
+		{"parent typed then esc", "I", []string{"a", "esc"}},
+		{"parent empty enter", "I", []string{"enter"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			first := addTestChild(&m, "0", "First")
+			addTestChild(&m, first, "First's child")
+			addTestChild(&m, "0", "Second")
+			m.Selected = first
+			before, nextID, undos := mapState(t, m), m.NextID, len(m.UndoStack)
+
+			m = press(m, tt.create)
+			if m.Mode != ModeEdit || m.Creating.Kind == CreateNone {
+				t.Fatalf("%q didn't start creating a node", tt.create)
+			}
+			m = press(m, tt.keys...)
+
+			if m.Mode != ModeNormal || m.Creating != (CreateParams{}) {
+				t.Errorf("left mode %v with creation %+v pending", m.Mode, m.Creating)
+			}
+			if after := mapState(t, m); after != before {
+				t.Errorf("map changed:\nbefore %s\nafter  %s", before, after)
+			}
+			if m.NextID != nextID || len(m.UndoStack) != undos {
+				t.Errorf("NextID %d and %d undo steps, want %d and %d", m.NextID, len(m.UndoStack), nextID, undos)
+			}
+
+			// The abandoned creation mustn't leak into the next edit
+			m = press(m, "e", "!", "enter")
+			if len(m.Nodes) != 4 || m.Nodes[first].Text != "First!" {
+				t.Errorf("next edit gave %d nodes and text %q, want 4 and %q", len(m.Nodes), m.Nodes[first].Text, "First!")
+			}
+		})
+	}
+}
+
+func TestConfirmedCreateAddsOneNode(t *testing.T) {
+	tests := []struct {
+		create     string
+		wantParent string
+	}{
+		{"enter", "0"},
+		{"tab", "1"},
+		{"I", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.create, func(t *testing.T) {
+			m := newTestModel(t)
+			first := addTestChild(&m, "0", "First")
+			m.Selected = first
+
+			m = press(m, tt.create, "N", "e", "w", "enter")
+			node := m.GetSelectedNode()
+			if len(m.Nodes) != 3 || node.Text != "New" || node.ParentID != tt.wantParent {
+				t.Fatalf("got %d nodes, selected %q under %q; want 3, %q under %q",
+					len(m.Nodes), node.Text, node.ParentID, "New", tt.wantParent)
+			}
+			if m.Creating != (CreateParams{}) {
+				t.Errorf("creation %+v still pending", m.Creating)
+			}
+		})
+	}
+}
+
+func TestReparentMakesRoomInNewBranchOnly(t *testing.T) {
+	m := newTestModel(t)
+	a := addTestChild(&m, "0", "A")
+	a1 := addTestChild(&m, a, "A1")
+	b := addTestChild(&m, "0", "B")
+	addTestChild(&m, a1, "A1 first")
+	addTestChild(&m, a1, "A1 second")
+	addTestChild(&m, b, "B1")
+	b2 := addTestChild(&m, b, "B2")
+	addTestChild(&m, b2, "B2 child")
+	m.Selected = ""
+	floating := m.addChild(nil, "Floating")
+	floating.Y = m.Nodes[b].Y + 2 // Unrelated, but level with B
+	floatingY, bY := floating.Y, m.Nodes[b].Y
+
+	m.ReparentNode(b2, a)
+
+	node, parent := m.Nodes[b2], m.Nodes[a]
+	if node.ParentID != a || !m.hasEdge(a, b2) {
+		t.Fatalf("node %s has parent %q, want %s with an edge", b2, node.ParentID, a)
+	}
+	if wantX := parent.X + float64(parent.Width) + m.HSpacing; node.X != wantX {
+		t.Errorf("moved node at x %v, want %v", node.X, wantX)
+	}
+	if _, bottom := m.subtreeBounds(a1); node.Y != bottom+m.VSpacing {
+		t.Errorf("moved node at y %v, want it just below its new sibling's subtree at %v", node.Y, bottom+m.VSpacing)
+	}
+	if floating.Y != floatingY {
+		t.Errorf("unrelated tree moved from y %v to %v", floatingY, floating.Y)
+	}
+	if m.Nodes[b].Y <= bY {
+		t.Errorf("the new parent's next sibling stayed at y %v, want it pushed down", bY)
+	}
+	if child := m.GetChildrenOf(b2)[0]; child.X <= node.X || child.Y != node.Y {
+		t.Errorf("subtree didn't move along: child at (%v, %v), node at (%v, %v)", child.X, child.Y, node.X, node.Y)
+	}
+	if m.hasOverlaps() {
+		t.Error("reparenting left overlapping nodes")
+	}
+}
+
+func TestReparentRefusesCycles(t *testing.T) {
+	m := newTestModel(t)
+	a := addTestChild(&m, "0", "A")
+	a1 := addTestChild(&m, a, "A1")
+	before := mapState(t, m)
+
+	m.ReparentNode(a, a1)
+	if mapState(t, m) != before || m.StatusLevel != StatusWarn {
+		t.Errorf("moving a node under its own child changed the map; status %q", m.StatusMsg)
+	}
+}
+
+// balancedTestModel returns a balanced map whose root has a branch on each side, each
+// with a child that has two children of different widths
+func balancedTestModel(t *testing.T) (m Model, right, left string) {
+	m = newTestModel(t)
+	m.Layout = LayoutBalanced
+	right = addTestChild(&m, "0", "Right")
+	left = addTestChild(&m, "0", "Left")
+	for _, branch := range []string{right, left} {
+		child := addTestChild(&m, branch, "Child of "+m.Nodes[branch].Text)
+		addTestChild(&m, child, "Short")
+		addTestChild(&m, child, "A much longer grandchild")
+	}
+	if !m.onLeft(m.Nodes[left]) || m.onLeft(m.Nodes[right]) {
+		t.Fatal("branches didn't go on both sides of the root")
+	}
+	return m, right, left
+}
+
+// rightEdge returns the X just past a node's right border
+func rightEdge(node *Node) float64 {
+	return node.X + float64(node.Width)
+}
+
+func TestSpliceKeepsChildrenAlignedOnTheirSide(t *testing.T) {
+	for _, side := range []string{"right", "left"} {
+		t.Run(side, func(t *testing.T) {
+			m, right, left := balancedTestModel(t)
+			branch := right
+			if side == "left" {
+				branch = left
+			}
+			spliced := m.GetChildrenOf(branch)[0]
+			children := m.GetChildrenOf(spliced.ID)
+			oldX, oldRight := spliced.X, rightEdge(spliced)
+			gap := children[1].X - children[0].X
+
+			m.SpliceNode(spliced.ID)
+			if side == "left" {
+				if rightEdge(children[0]) != oldRight || rightEdge(children[1]) != oldRight {
+					t.Errorf("children's right edges at %v and %v, want both at %v", rightEdge(children[0]), rightEdge(children[1]), oldRight)
+				}
+			} else if children[0].X != oldX || children[1].X != oldX {
+				t.Errorf("children at x %v and %v, want both at %v", children[0].X, children[1].X, oldX)
+			}
+			if children[1].X-children[0].X != gap {
+				t.Errorf("children's relative layout changed")
+			}
+		})
+	}
+}
+
+func TestReparentAcrossTheRoot(t *testing.T) {
+	m, right, left := balancedTestModel(t)
+	moved := m.GetChildrenOf(right)[0]
+	grandchildren := m.GetChildrenOf(moved.ID)
+
+	m.ReparentNode(moved.ID, left)
+	parent := m.Nodes[left]
+	if want := parent.X - m.HSpacing; rightEdge(moved) != want {
+		t.Errorf("moved node's right edge at %v, want %v", rightEdge(moved), want)
+	}
+	for _, grandchild := range grandchildren {
+		if rightEdge(grandchild) > moved.X {
+			t.Errorf("grandchild %q at x %v..%v is not left of its parent at %v", grandchild.Text, grandchild.X, rightEdge(grandchild), moved.X)
+		}
+	}
+	if rightEdge(grandchildren[0]) != rightEdge(grandchildren[1]) {
+		t.Errorf("grandchildren's right edges at %v and %v, want them aligned", rightEdge(grandchildren[0]), rightEdge(grandchildren[1]))
+	}
+	if m.hasOverlaps() {
+		t.Error("reparenting left overlapping nodes")
+	}
+
+	// And back again
+	m.ReparentNode(moved.ID, right)
+	if want := rightEdge(m.Nodes[right]) + m.HSpacing; moved.X != want {
+		t.Errorf("moved back to x %v, want %v", moved.X, want)
+	}
+	if grandchildren[0].X != grandchildren[1].X || grandchildren[0].X < rightEdge(moved) {
+		t.Errorf("grandchildren at x %v and %v, want them aligned right of %v", grandchildren[0].X, grandchildren[1].X, rightEdge(moved))
+	}
+}
+
+func TestZoomToSelectionLogsOnce(t *testing.T) {
+	m := newTestModel(t)
+	m.Width, m.Height = 80, 24
+	addTestChild(&m, m.Selected, "a")
+	addTestChild(&m, m.Selected, "b")
+	m.Messages = nil
+	m.ZoomToSelection()
+	if len(m.Messages) != 1 {
+		t.Fatalf("logged %d messages, want 1", len(m.Messages))
+	}
+	if want := "Zoomed to 0 and 2 children"; !strings.HasPrefix(m.StatusMsg, want) {
+		t.Errorf("status = %q, want it to start with %q", m.StatusMsg, want)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// moveStep is how far one key press moves a node in move mode; shifted keys move further
+const (
+	moveStep     = 1.0
+	moveFastStep = 5.0
+)
+
+// startMoveMode lets the movement keys nudge the selected node (and by default its subtree)
+func (m *Model) startMoveMode() {
+	if m.GetSelectedNode() == nil {
+		return
+	}
+	m.finishLayoutAnimation()
+	m.Mode = ModeMove
+	m.MoveSubtree = true
+	m.Moved = false
+	m.clearStatus()
+}
+
+// handleMoveMode moves the selected node with hjkl/arrows (HJKL/shift+arrows for bigger steps)
+func (m Model) handleMoveMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "h", "left":
+		m.nudgeSelected(-moveStep, 0)
+	case "l", "right":
+		m.nudgeSelected(moveStep, 0)
+	case "k", "up":
+		m.nudgeSelected(0, -moveStep)
+	case "j", "down":
+		m.nudgeSelected(0, moveStep)
+	case "H", "shift+left":
+		m.nudgeSelected(-moveFastStep, 0)
+	case "L", "shift+right":
+		m.nudgeSelected(moveFastStep, 0)
+	case "K", "shift+up":
+		m.nudgeSelected(0, -moveFastStep)
+	case "J", "shift+down":
+		m.nudgeSelected(0, moveFastStep)
+
+	case "t":
+		m.MoveSubtree = !m.MoveSubtree
+
+	case "enter", "esc":
+		m.Mode = ModeNormal
+		if m.Moved {
+			m.setStatus(StatusInfo, fmt.Sprintf("Moved node %s", m.Selected))
+		}
+	}
+	return m, nil
+}
+
+// nudgeSelected moves the selected node, and its subtree if enabled, by the given offset.
+// The first move records an undo step covering the whole move.
+func (m *Model) nudgeSelected(dx, dy float64) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+	if !m.Moved {
+		m.pushUndo(fmt.Sprintf("move node %s", node.ID))
+		m.Moved = true
+	}
+
+	if m.MoveSubtree {
+		m.moveSubtree(node.ID, dx, dy)
+	} else {
+		node.X += dx
+		node.Y += dy
+	}
+	m.invalidateSpatialIndex()
+	m.Dirty = true
+	m.revealNode(node)
+}
+
+// moveModeStatus describes move mode for the status bar: what moves and where it is
+func (m Model) moveModeStatus() string {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return "MOVE"
+	}
+	what := "node"
+	if m.MoveSubtree {
+		what = "subtree"
+	}
+	return fmt.Sprintf("MOVE %s: %g,%g", what, node.X, node.Y)
+}
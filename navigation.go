@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handlePrefixKey handles the second key of a two-key command such as "g p"
+func (m Model) handlePrefixKey(prefix, key string) (tea.Model, tea.Cmd) {
+	switch prefix {
+	case "m":
+		m.SetMark(key)
+		return m, nil
+	case "'":
+		m.JumpToMark(key)
+		return m, nil
+	}
+
+	switch prefix + " " + key {
+	case "g p":
+		m.SelectParent()
+	case "g c":
+		m.SelectFirstChild()
+	case "g s":
+		m.SelectSibling(1)
+	case "g S":
+		m.SelectSibling(-1)
+	case "g i":
+		m.startCommand("goto ")
+	case "S a", "S r", "S c":
+		m.SortChildren(key)
+	case "A x", "A d", "A c":
+		m.AlignChildren(key)
+	case "z a":
+		m.ToggleCollapse()
+	case "z M":
+		m.CollapseAll()
+	case "z R":
+		m.ExpandAll()
+	case "z 1", "z 2", "z 3":
+		m.CollapseToLevel(int(key[0] - '0'))
+	default:
+		m.setStatus(StatusWarn, "Unknown command: "+prefix+" "+key)
+	}
+	return m, nil
+}
+
+// GotoNode selects the node with exactly the given ID and centers the camera on it
+func (m *Model) GotoNode(id string) {
+	if id == "" {
+		m.setStatus(StatusWarn, "Usage: :goto <id>")
+		return
+	}
+	node := m.Nodes[id]
+	if node == nil {
+		m.setStatus(StatusWarn, fmt.Sprintf("No such node: %s", id))
+		return
+	}
+	m.expandTo(id)
+	m.Selected = id
+	m.centerOn(node)
+	m.setStatus(StatusInfo, fmt.Sprintf("Node %s", id))
+}
+
+// SelectParent selects the parent of the selected node
+func (m *Model) SelectParent() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+	m.selectStructural(m.Nodes[node.ParentID], "No parent")
+}
+
+// SelectFirstChild selects the topmost child of the selected node
+func (m *Model) SelectFirstChild() {
+	if m.Selected == "" {
+		return
+	}
+	children := m.GetChildrenOf(m.Selected)
+	if len(children) == 0 {
+		m.setStatus(StatusWarn, "No children")
+		return
+	}
+	m.selectStructural(children[0], "")
+}
+
+// SelectSibling selects the next (offset > 0) or previous (offset < 0) sibling in child order
+func (m *Model) SelectSibling(offset int) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+
+	var siblings []*Node
+	if node.ParentID == "" {
+		siblings = m.GetRootNodes()
+	} else {
+		siblings = m.GetChildrenOf(node.ParentID)
+	}
+
+	for i, sibling := range siblings {
+		if sibling.ID != node.ID {
+			continue
+		}
+		if j := i + offset; j >= 0 && j < len(siblings) {
+			m.selectStructural(siblings[j], "")
+			return
+		}
+		break
+	}
+
+	if offset > 0 {
+		m.setStatus(StatusWarn, "No next sibling")
+	} else {
+		m.setStatus(StatusWarn, "No previous sibling")
+	}
+}
+
+// selectStructural selects target and brings it on screen, or reports missing if it's nil
+func (m *Model) selectStructural(target *Node, missing string) {
+	if target == nil {
+		m.setStatus(StatusWarn, missing)
+		return
+	}
+	m.expandTo(target.ID)
+	m.Selected = target.ID
+	m.revealNode(target)
+	m.clearStatus()
+}
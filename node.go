@@ -3,24 +3,35 @@ package main
 import (
 	"fmt"
 	"strings"
+
+	"github.com/mattn/go-runewidth"
 )
 
 // Node represents a single node in the mind map
 type Node struct {
-	ID       string   `json:"id"`
-	Text     string   `json:"text"`
-	X        float64  `json:"x"`
-	Y        float64  `json:"y"`
-	Width    int      `json:"width"`
-	Height   int      `json:"height"`
-	ParentID string   `json:"parent_id"` // ID of parent node
-	Color    string   `json:"color"`     // Color for this branch
-	Links    []string `json:"links"`     // IDs of connected nodes
+	ID        string   `json:"id"`
+	Text      string   `json:"text"`
+	X         float64  `json:"x"`
+	Y         float64  `json:"y"`
+	Width     int      `json:"width"`
+	Height    int      `json:"height"`
+	ParentID  string   `json:"parent_id"`           // ID of parent node
+	Color     string   `json:"color"`               // Color for this branch
+	OwnColor  bool     `json:"own_color,omitempty"` // Color was picked by hand and wins over the coloring mode
+	Links     []string `json:"links"`               // IDs of connected nodes
+	Order     int      `json:"order,omitempty"`     // Position among its siblings, first is 0
+	Collapsed bool     `json:"collapsed,omitempty"` // Descendants are hidden
+
+	Task  bool              `json:"task,omitempty"`  // Shown with a checkbox
+	Done  bool              `json:"done,omitempty"`  // Checkbox state of a task
+	Tags  []string          `json:"tags,omitempty"`  // Tags without the leading '#'
+	Attrs map[string]string `json:"attrs,omitempty"` // Extra attributes kept from imported outlines
+	Notes string            `json:"notes,omitempty"` // Longer description shown in the notes panel
 }
 
-// NewNode creates a new node at the given position
-func NewNode(id, text string, x, y float64) *Node {
-	width, height := calculateNodeSize(text)
+// NewNode creates a new node at the given position, sized for text wrapped at wrapWidth
+func NewNode(id, text string, x, y float64, wrapWidth int) *Node {
+	width, height := calculateNodeSize(text, wrapWidth)
 	return &Node{
 		ID:     id,
 		Text:   text,
@@ -32,7 +43,7 @@ func NewNode(id, text string, x, y float64) *Node {
 	}
 }
 
-// wrapText wraps text to fit within maxWidth, breaking on word boundaries
+// wrapText wraps text to fit within maxWidth display cells, breaking on word boundaries
 func wrapText(text string, maxWidth int) []string {
 	if maxWidth < 5 {
 		maxWidth = 5 // Minimum sensible width
@@ -57,19 +68,23 @@ func wrapText(text string, maxWidth int) []string {
 
 		var currentLine string
 		for _, word := range words {
+			lineWidth := textWidth(currentLine)
+			wordWidth := textWidth(word)
+
 			// If adding this word would exceed maxWidth
-			if len(currentLine) > 0 && len(currentLine)+1+len(word) > maxWidth {
+			if lineWidth > 0 && lineWidth+1+wordWidth > maxWidth {
 				// If the word itself is longer than maxWidth, we need to break it
-				if len(word) > maxWidth {
+				if wordWidth > maxWidth {
 					// Add current line if not empty
-					if len(currentLine) > 0 {
+					if lineWidth > 0 {
 						wrappedLines = append(wrappedLines, currentLine)
 						currentLine = ""
 					}
 					// Break the long word into chunks
-					for len(word) > maxWidth {
-						wrappedLines = append(wrappedLines, word[:maxWidth])
-						word = word[maxWidth:]
+					for textWidth(word) > maxWidth {
+						chunk := truncateWidth(word, maxWidth)
+						wrappedLines = append(wrappedLines, chunk)
+						word = word[len(chunk):]
 					}
 					currentLine = word
 				} else {
@@ -77,9 +92,17 @@ func wrapText(text string, maxWidth int) []string {
 					wrappedLines = append(wrappedLines, currentLine)
 					currentLine = word
 				}
+			} else if lineWidth == 0 && wordWidth > maxWidth {
+				// A long first word is broken into chunks as well
+				for textWidth(word) > maxWidth {
+					chunk := truncateWidth(word, maxWidth)
+					wrappedLines = append(wrappedLines, chunk)
+					word = word[len(chunk):]
+				}
+				currentLine = word
 			} else {
 				// Add word to current line
-				if len(currentLine) > 0 {
+				if lineWidth > 0 {
 					currentLine += " " + word
 				} else {
 					currentLine = word
@@ -100,21 +123,49 @@ func wrapText(text string, maxWidth int) []string {
 	return wrappedLines
 }
 
-// calculateNodeSize returns the width and height needed for a node's text
-func calculateNodeSize(text string) (int, int) {
-	const maxTextWidth = 22 // Roughly 4-5 words, similar to MindNode
+// textWidth returns the number of terminal cells text occupies (CJK characters take two)
+func textWidth(text string) int {
+	return runewidth.StringWidth(text)
+}
 
-	lines := wrapText(text, maxTextWidth)
+// truncateWidth returns the longest prefix of text that fits in maxWidth cells
+func truncateWidth(text string, maxWidth int) string {
+	width := 0
+	for i, ch := range text {
+		w := runewidth.RuneWidth(ch)
+		if width+w > maxWidth {
+			return text[:i]
+		}
+		width += w
+	}
+	return text
+}
+
+// defaultWrapWidth is the widest a line of node text gets before wrapping, unless configured
+const defaultWrapWidth = 22 // Roughly 4-5 words, similar to MindNode
+
+// Limits for the configurable wrap width
+const (
+	minWrapWidth = 8
+	maxWrapWidth = 200
+)
+
+// minNodeWidth is the narrowest a node's box gets, borders and padding included
+const minNodeWidth = 10
+
+// calculateNodeSize returns the width and height needed for a node's text wrapped at wrapWidth
+func calculateNodeSize(text string, wrapWidth int) (int, int) {
+	lines := wrapText(text, wrapWidth)
 	height := len(lines) + 2 // +2 for borders
 	width := 0
 	for _, line := range lines {
-		if len(line) > width {
-			width = len(line)
+		if w := textWidth(line); w > width {
+			width = w
 		}
 	}
 	width += 4 // +4 for borders and padding
-	if width < 10 {
-		width = 10 // Minimum width
+	if width < minNodeWidth {
+		width = minNodeWidth
 	}
 	return width, height
 }
@@ -130,9 +181,35 @@ func (n *Node) GetCenter() (float64, float64) {
 	return n.X + float64(n.Width)/2, n.Y + float64(n.Height)/2
 }
 
-// UpdateSize recalculates the node's size based on its text
-func (n *Node) UpdateSize() {
-	n.Width, n.Height = calculateNodeSize(n.Text)
+// UpdateSize recalculates the node's size based on its text wrapped at wrapWidth
+func (n *Node) UpdateSize(wrapWidth int) {
+	n.Width, n.Height = calculateNodeSize(n.displayText(), wrapWidth)
+
+	// Tags get a line of their own below the text
+	if tags := n.tagLine(); tags != "" {
+		n.Height++
+		if w := min(textWidth(tags), wrapWidth) + 4; w > n.Width {
+			n.Width = w
+		}
+	}
+}
+
+// Clone returns a deep copy of the node
+func (n *Node) Clone() *Node {
+	clone := *n
+	clone.Links = make([]string, len(n.Links))
+	copy(clone.Links, n.Links)
+	if n.Tags != nil {
+		clone.Tags = make([]string, len(n.Tags))
+		copy(clone.Tags, n.Tags)
+	}
+	if n.Attrs != nil {
+		clone.Attrs = make(map[string]string, len(n.Attrs))
+		for k, v := range n.Attrs {
+			clone.Attrs[k] = v
+		}
+	}
+	return &clone
 }
 
 // String returns a string representation of the node
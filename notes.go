@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// notesPanelRows is the height of the notes panel, including its header line
+const notesPanelRows = 8
+
+// notesPanelHeight returns how many rows the notes panel takes, or 0 when it's closed.
+// On short terminals it never takes more than half the screen, and it's left out
+// when there isn't room for its header and a line of notes.
+func (m Model) notesPanelHeight() int {
+	if !m.ShowNotes {
+		return 0
+	}
+	height := min(notesPanelRows, (m.Height-1)/2)
+	if height < 2 {
+		return 0
+	}
+	return height
+}
+
+// ToggleNotes opens or closes the notes panel
+func (m *Model) ToggleNotes() {
+	m.ShowNotes = !m.ShowNotes
+	if m.ShowNotes {
+		m.setStatus(StatusInfo, "Notes panel shown ({/} scroll, Alt+N edits)")
+	} else {
+		m.setStatus(StatusInfo, "Notes panel hidden")
+	}
+}
+
+// startEditNotes enters edit mode on a node's notes, opening the panel to show them
+func (m *Model) startEditNotes(node *Node) {
+	m.startEdit(node.Notes)
+	m.EditingNotes = true
+	m.ShowNotes = true
+	m.Selected = node.ID
+}
+
+// notesWidth returns how wide a line of notes gets in the panel
+func (m Model) notesWidth() int {
+	return m.Width - 2
+}
+
+// notesLines returns the selected node's notes wrapped to the panel width, or the notes
+// being edited with their cursor
+func (m Model) notesLines() []string {
+	if m.Mode == ModeEdit && m.EditingNotes {
+		return wrapText(withCursor(m.EditBuffer, m.EditCursor), m.notesWidth())
+	}
+	node := m.GetSelectedNode()
+	if node == nil || node.Notes == "" {
+		return nil
+	}
+	return wrapText(node.Notes, m.notesWidth())
+}
+
+// clampNotesScroll keeps a scroll offset within the selected node's notes
+func (m Model) clampNotesScroll(scroll int) int {
+	last := max(0, len(m.notesLines())-(m.notesPanelHeight()-1))
+	return max(0, min(scroll, last))
+}
+
+// notesScroll returns the first notes line to show. While editing, that's as far down as
+// it takes to keep the cursor in view.
+func (m Model) notesScroll() int {
+	if m.Mode == ModeEdit && m.EditingNotes {
+		for i, line := range m.notesLines() {
+			if strings.ContainsRune(line, editCursorRune) {
+				return m.clampNotesScroll(i - (m.notesPanelHeight() - 2))
+			}
+		}
+	}
+	if m.NotesScrollID != m.Selected {
+		return 0
+	}
+	return m.clampNotesScroll(m.NotesScroll)
+}
+
+// ScrollNotes scrolls the notes panel by delta lines
+func (m *Model) ScrollNotes(delta int) {
+	if !m.ShowNotes {
+		return
+	}
+	m.NotesScroll = m.clampNotesScroll(m.notesScroll() + delta)
+	m.NotesScrollID = m.Selected
+}
+
+// drawNotesPanel renders the notes panel: a header naming the node, then its notes
+func (m Model) drawNotesPanel() [][]ColoredCell {
+	height := m.notesPanelHeight()
+	rows := newGrid(m.Width, height)
+	if height == 0 {
+		return rows
+	}
+
+	// Header: a rule with the node title and the visible line range
+	for j := range rows[0] {
+		rows[0][j] = ColoredCell{Char: '─', Color: m.Theme.Info}
+	}
+	node := m.GetSelectedNode()
+	if node == nil {
+		putText(rows[0], 1, " Notes ", m.Theme.Info)
+		return rows
+	}
+	putText(rows[0], 1, fmt.Sprintf(" Notes: %s ", singleLine(node.Text)), m.Theme.Info)
+
+	lines := m.notesLines()
+	if len(lines) == 0 {
+		putText(rows[1], 1, "No notes (Alt+N to edit)", m.Theme.Info)
+		return rows
+	}
+
+	scroll := m.notesScroll()
+	visible := lines[scroll:min(len(lines), scroll+height-1)]
+	if len(visible) < len(lines) {
+		position := fmt.Sprintf(" %d-%d/%d ", scroll+1, scroll+len(visible), len(lines))
+		putText(rows[0], m.Width-runewidth.StringWidth(position)-1, position, m.Theme.Info)
+	}
+	for i, line := range visible {
+		putText(rows[i+1], 1, line, "")
+	}
+	return rows
+}
+
+// putText writes text into a row of cells starting at column x, clipping at the row's end
+func putText(row []ColoredCell, x int, text, color string) {
+	for _, ch := range text {
+		w := runewidth.RuneWidth(ch)
+		if x < 0 || x+w > len(row) {
+			return
+		}
+		row[x] = ColoredCell{Char: ch, Color: color}
+		if w == 2 {
+			row[x+1] = ColoredCell{Char: wideContinuation, Color: color}
+		}
+		x += w
+	}
+}
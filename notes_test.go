@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEditNotesInApp(t *testing.T) {
+	m := newTestModel(t)
+	id := addTestChild(&m, m.Selected, "child")
+	m.Selected = id
+
+	m = press(m, "alt+n", "a", "alt+enter", "b")
+	if m.Mode != ModeEdit || !m.EditingNotes || !m.ShowNotes {
+		t.Fatalf("Alt+N left mode %v, editing notes %v, panel shown %v", m.Mode, m.EditingNotes, m.ShowNotes)
+	}
+	if lines := m.notesLines(); len(lines) != 2 || !strings.HasPrefix(lines[1], "b") {
+		t.Errorf("notes panel shows %q while editing", lines)
+	}
+	m = press(m, "enter")
+	node := m.Nodes[id]
+	if node.Notes != "a\nb" || node.Text != "child" {
+		t.Fatalf("notes %q, text %q after saving, want notes %q and the text untouched", node.Notes, node.Text, "a\nb")
+	}
+	if m.EditingNotes {
+		t.Error("still editing notes after Enter")
+	}
+
+	m = press(m, "alt+n", "c", "esc")
+	if node.Notes != "a\nb" {
+		t.Errorf("Esc changed the notes to %q", node.Notes)
+	}
+
+	m = press(m, "alt+n", "backspace", "backspace", "backspace", "enter")
+	if node.Notes != "" {
+		t.Errorf("notes %q after deleting them all, want them removed", node.Notes)
+	}
+	m = press(m, "u")
+	if got := m.Nodes[id].Notes; got != "a\nb" {
+		t.Errorf("undo left notes %q, want %q", got, "a\nb")
+	}
+}
+
+func TestToggleNotesKey(t *testing.T) {
+	m := newTestModel(t)
+	if m = press(m, "i"); !m.ShowNotes {
+		t.Error("i didn't show the notes panel")
+	}
+	if m = press(m, "i"); m.ShowNotes {
+		t.Error("i didn't hide the notes panel")
+	}
+}
+
+func TestSearchMatchKeys(t *testing.T) {
+	m := newTestModel(t)
+	first := addTestChild(&m, m.Selected, "apple")
+	second := addTestChild(&m, m.Selected, "apricot")
+	m = press(m, "/", "a", "p", "enter")
+	start := m.Selected
+	if start != first && start != second {
+		t.Fatalf("search selected %s, want one of the matches", start)
+	}
+	if m = press(m, "n"); m.Selected == start {
+		t.Error("n didn't move to the next match")
+	}
+	if m = press(m, "N"); m.Selected != start {
+		t.Errorf("N selected %s, want %s back", m.Selected, start)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "slices"
+
+// compareSiblings orders children by their Order, then top to bottom, then by ID
+func compareSiblings(a, b *Node) int {
+	if a.Order != b.Order {
+		return a.Order - b.Order
+	}
+	if c := compareFloat(a.Y, b.Y); c != 0 {
+		return c
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+// nextOrder returns the Order that puts a node after parentID's current children
+func (m *Model) nextOrder(parentID string) int {
+	next := 0
+	for _, node := range m.Nodes {
+		if node.ParentID == parentID {
+			next = max(next, node.Order+1)
+		}
+	}
+	return next
+}
+
+// insertOrder makes room for a node right after sibling and returns the Order it should take
+func (m *Model) insertOrder(sibling *Node) int {
+	for _, node := range m.Nodes {
+		if node.ParentID == sibling.ParentID && node.Order > sibling.Order {
+			node.Order++
+		}
+	}
+	return sibling.Order + 1
+}
+
+// renumber gives nodes consecutive Orders in the order they are listed
+func renumber(nodes []*Node) {
+	for i, node := range nodes {
+		node.Order = i
+	}
+}
+
+// normalizeOrders renumbers every group of siblings from 0, keeping their current order.
+// Files saved before nodes had an Order load with every Order at 0, so their siblings
+// are ordered top to bottom as they always were.
+func (m *Model) normalizeOrders() {
+	groups := make(map[string][]*Node)
+	for _, node := range m.Nodes {
+		groups[node.ParentID] = append(groups[node.ParentID], node)
+	}
+	for _, siblings := range groups {
+		slices.SortFunc(siblings, compareSiblings)
+		renumber(siblings)
+	}
+}
@@ -2,31 +2,241 @@ package main
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
+// formatVersion is the version of the save format written by this build. Files without a
+// version are version 0, the format from before versions were recorded.
+const formatVersion = 2
+
+// coordPrecision is how many steps per cell positions are saved with, so that leftovers of
+// animations don't change the file when nothing moved
+const coordPrecision = 100
+
+// migrations[v] upgrades a decoded file from version v to v+1
+var migrations = []func(fields map[string]json.RawMessage) error{
+	0: func(map[string]json.RawMessage) error { return nil }, // Version 1 only adds the version field
+	1: nodesToList,                                           // Version 2 lists nodes in ID order
+}
+
+// nodesToList turns the object of nodes keyed by ID that version 1 wrote into a list
+func nodesToList(fields map[string]json.RawMessage) error {
+	raw, ok := fields["nodes"]
+	if !ok || strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		return nil
+	}
+	var byID map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &byID); err != nil {
+		return fmt.Errorf("bad nodes: %w", err)
+	}
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, compareIDs)
+	list := make([]json.RawMessage, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, byID[id])
+	}
+	encoded, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	fields["nodes"] = encoded
+	return nil
+}
+
 // MindMapData represents the serializable mind map data
 type MindMapData struct {
-	Nodes  map[string]*Node `json:"nodes"`
-	Edges  []Edge           `json:"edges"`
-	Camera Camera           `json:"camera"`
+	Version int     `json:"version"`
+	Nodes   []*Node `json:"nodes"` // In ID order, so saving an unchanged map writes the same file
+	Edges   []Edge  `json:"edges"`
+	Camera  Camera  `json:"camera"`
+
+	// Editing state; older files don't have these, so they're inferred when missing
+	Selected       string `json:"selected,omitempty"`
+	NextID         *int   `json:"next_id,omitempty"`
+	NextColorIndex *int   `json:"next_color_index,omitempty"`
+	ColorMode      string `json:"color_mode,omitempty"`
+	EdgeStyle      string `json:"edge_style,omitempty"`
+	TextAlign      string `json:"text_align,omitempty"`
+	Layout         string `json:"layout,omitempty"`
+
+	// Bookmarks by letter
+	Marks map[string]Mark `json:"marks,omitempty"`
+
+	// Deleted branches, when the trash is saved with the map
+	Trash []TrashEntry `json:"trash,omitempty"`
 }
 
 // SaveToFile saves the mind map to a JSON file
 func (m *Model) SaveToFile(filename string) error {
+	jsonData, err := m.marshalMap()
+	if err != nil {
+		return err
+	}
+
+	// A failed backup still leaves the map saved
+	err = writeFileAtomic(filename, jsonData, m.Config.Backup)
+	if err != nil && !isBackupError(err) {
+		return err
+	}
+	m.Dirty = false
+
+	// Unsaved changes are saved now, so their recovery file is no longer needed
+	removeRecovery(filename)
+	removeRecovery(m.FileName())
+	return err
+}
+
+// marshalMap encodes the mind map as indented JSON
+func (m *Model) marshalMap() ([]byte, error) {
+	m.finishLayoutAnimation()
+
 	data := MindMapData{
-		Nodes:  m.Nodes,
-		Edges:  m.Edges,
-		Camera: m.Camera,
+		Version:        formatVersion,
+		Nodes:          m.savedNodes(),
+		Edges:          m.savedEdges(),
+		Camera:         m.Camera,
+		Selected:       m.Selected,
+		NextID:         &m.NextID,
+		NextColorIndex: &m.NextColorIndex,
+		ColorMode:      m.ColorMode,
+		EdgeStyle:      m.EdgeStyle,
+		TextAlign:      m.TextAlign,
+		Layout:         m.Layout,
+		Marks:          m.savedMarks(),
+	}
+	if m.Config.SaveTrash {
+		data.Trash = m.Trash
+	}
+	data.Camera.X = roundCoord(data.Camera.X)
+	data.Camera.Y = roundCoord(data.Camera.Y)
+	data.Camera.Zoom = roundCoord(data.Camera.Zoom)
+
+	if len(m.ExtraFields) == 0 {
+		return json.MarshalIndent(data, "", "  ")
 	}
 
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	// Put back fields this build doesn't know, as written by a newer version or another tool
+	encoded, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range m.ExtraFields {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
 	}
+	return json.MarshalIndent(fields, "", "  ")
+}
 
-	return os.WriteFile(filename, jsonData, 0644)
+// savedNodes returns copies of the nodes as they are saved: in ID order, with rounded positions
+func (m *Model) savedNodes() []*Node {
+	nodes := make([]*Node, 0, len(m.Nodes))
+	for _, node := range m.Nodes {
+		saved := *node
+		saved.X, saved.Y = roundCoord(node.X), roundCoord(node.Y)
+		nodes = append(nodes, &saved)
+	}
+	slices.SortFunc(nodes, func(a, b *Node) int { return compareIDs(a.ID, b.ID) })
+	return nodes
+}
+
+// savedEdges returns the edges ordered by source, then target
+func (m *Model) savedEdges() []Edge {
+	edges := slices.Clone(m.Edges)
+	if edges == nil {
+		edges = make([]Edge, 0)
+	}
+	slices.SortFunc(edges, func(a, b Edge) int {
+		if c := compareIDs(a.FromID, b.FromID); c != 0 {
+			return c
+		}
+		return compareIDs(a.ToID, b.ToID)
+	})
+	return edges
+}
+
+// savedMarks returns the marks with the positions of views rounded
+func (m *Model) savedMarks() map[string]Mark {
+	if m.Marks == nil {
+		return nil
+	}
+	marks := make(map[string]Mark, len(m.Marks))
+	for name, mark := range m.Marks {
+		mark.X, mark.Y, mark.Zoom = roundCoord(mark.X), roundCoord(mark.Y), roundCoord(mark.Zoom)
+		marks[name] = mark
+	}
+	return marks
+}
+
+// roundCoord rounds a saved position to coordPrecision
+func roundCoord(v float64) float64 {
+	return math.Round(v*coordPrecision) / coordPrecision
+}
+
+// decodeMap parses a saved map, upgrading older versions and setting aside unknown fields.
+// Files from a newer version are rejected rather than misread.
+func decodeMap(jsonData []byte) (MindMapData, map[string]json.RawMessage, error) {
+	var data MindMapData
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		return data, nil, err
+	}
+
+	version := 0
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return data, nil, fmt.Errorf("bad version: %w", err)
+		}
+	}
+	if version > formatVersion {
+		return data, nil, fmt.Errorf("file was saved by a newer version (format %d, this version reads up to %d)", version, formatVersion)
+	}
+	for v := version; v < formatVersion; v++ {
+		if err := migrations[v](fields); err != nil {
+			return data, nil, fmt.Errorf("upgrading from format %d: %w", v, err)
+		}
+	}
+
+	migrated, err := json.Marshal(fields)
+	if err != nil {
+		return data, nil, err
+	}
+	if err := json.Unmarshal(migrated, &data); err != nil {
+		return data, nil, err
+	}
+
+	known := make(map[string]bool)
+	dataType := reflect.TypeOf(data)
+	for i := 0; i < dataType.NumField(); i++ {
+		name, _, _ := strings.Cut(dataType.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+	extra := make(map[string]json.RawMessage)
+	for name, value := range fields {
+		if !known[name] {
+			extra[name] = value
+		}
+	}
+	return data, extra, nil
 }
 
 // LoadFromFile loads the mind map from a JSON file
@@ -36,29 +246,484 @@ func (m *Model) LoadFromFile(filename string) error {
 		return err
 	}
 
-	var data MindMapData
-	if err := json.Unmarshal(jsonData, &data); err != nil {
+	data, extra, err := decodeMap(jsonData)
+	if err != nil {
 		return err
 	}
 
-	m.Nodes = data.Nodes
+	m.ExtraFields = extra
+	m.Marks = data.Marks
+	m.Trash = data.Trash
+	m.ColorMode = data.ColorMode
+	m.EdgeStyle = data.EdgeStyle
+	m.TextAlign = data.TextAlign
+	m.Layout = data.Layout
+	m.Nodes = make(map[string]*Node, len(data.Nodes))
+	for _, node := range data.Nodes {
+		if node != nil {
+			m.Nodes[node.ID] = node
+		}
+	}
 	m.Edges = data.Edges
 	m.Camera = data.Camera
+	m.normalizeOrders() // Older files have no orders, so siblings keep their top-to-bottom order
+	m.clearHistory()
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+	m.resizeNodes() // The map may have been saved with a different wrap width
+	m.Dirty = false
+	var warnings []string
+	if dropped := m.dropBadEdges(); dropped > 0 {
+		warnings = append(warnings, fmt.Sprintf("dropped %d duplicate or self-linking edges", dropped))
+		m.Dirty = true
+	}
+	if dangling, _ := m.dropDanglingEdges(); len(dangling) > 0 {
+		warnings = append(warnings, fmt.Sprintf("removed %d dangling edges", len(dangling)))
+		m.Dirty = true
+	}
+	if problems := m.hierarchyProblems(); len(problems) > 0 {
+		m.repairHierarchy(problems)
+		warnings = append(warnings, describeProblems(problems))
+		m.Dirty = true
+	}
+	m.LoadWarning = strings.Join(warnings, "; ")
+	if m.Config.UntangleOnLoad && m.untangle() > 0 {
+		m.Dirty = true
+	}
 
 	// Initialize camera targets (not serialized, so set them to current values)
 	m.Camera.TargetX = m.Camera.X
 	m.Camera.TargetY = m.Camera.Y
 	m.Camera.TargetZoom = m.Camera.Zoom
 
-	// Select first node if none selected
-	if m.Selected == "" && len(m.Nodes) > 0 {
-		for id := range m.Nodes {
-			m.Selected = id
+	// Restore the saved selection, falling back to the root (or any node)
+	m.Selected = data.Selected
+	if m.Nodes[m.Selected] == nil {
+		m.Selected = ""
+		if roots := m.GetRootNodes(); len(roots) > 0 {
+			m.Selected = roots[0].ID
+		}
+	}
+
+	// NextID must be higher than any existing ID, even if the file says otherwise
+	m.NextID = m.nextFreeID()
+	if data.NextID != nil && *data.NextID > m.NextID {
+		m.NextID = *data.NextID
+	}
+
+	// Without a saved color index, continue after the branches root already has
+	if data.NextColorIndex != nil {
+		m.NextColorIndex = *data.NextColorIndex
+	} else {
+		m.NextColorIndex = len(m.GetChildrenOf("0"))
+	}
+
+	return nil
+}
+
+// ExportMarkdown writes the mind map as a nested Markdown bullet outline.
+// Cross-links (edges that aren't parent→child) are listed at the bottom.
+func (m *Model) ExportMarkdown(filename string) error {
+	var sb strings.Builder
+	visited := make(map[string]bool)
+	for _, root := range m.GetRootNodes() {
+		m.writeMarkdownNode(&sb, root, 0, visited)
+	}
+
+	var links []string
+	for _, edge := range m.Edges {
+		from, to := m.Nodes[edge.FromID], m.Nodes[edge.ToID]
+		if from == nil || to == nil || to.ParentID == from.ID {
+			continue
+		}
+		links = append(links, fmt.Sprintf("- %s → %s", singleLine(from.Text), singleLine(to.Text)))
+	}
+	if len(links) > 0 {
+		sb.WriteString("\n## Links\n\n")
+		sb.WriteString(strings.Join(links, "\n"))
+		sb.WriteString("\n")
+	}
+
+	return os.WriteFile(filename, []byte(sb.String()), 0644)
+}
+
+// writeMarkdownNode writes a node as a bullet, followed by its children one level deeper
+func (m *Model) writeMarkdownNode(sb *strings.Builder, node *Node, depth int, visited map[string]bool) {
+	if visited[node.ID] {
+		return
+	}
+	visited[node.ID] = true
+
+	indent := strings.Repeat("  ", depth)
+	lines := strings.Split(node.Text, "\n")
+	sb.WriteString(indent + "- " + lines[0] + "\n")
+	for _, line := range lines[1:] {
+		// Continuation lines are indented under the bullet text
+		sb.WriteString(indent + "  " + line + "\n")
+	}
+
+	for _, child := range m.GetChildrenOf(node.ID) {
+		m.writeMarkdownNode(sb, child, depth+1, visited)
+	}
+}
+
+// singleLine joins multi-line text into one line
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// exportFilename derives an export path from the current file, e.g. notes.json → notes.md
+func (m *Model) exportFilename(ext string) string {
+	base := strings.TrimSuffix(m.FileName(), filepath.Ext(m.FileName()))
+	return base + ext
+}
+
+// opmlDocument is the root element of an OPML file
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutline is an <outline> element. Attributes other than text are kept in Attrs.
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Attrs    []xml.Attr    `xml:",any,attr"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+// ExportOPML writes the mind map as nested OPML outline elements following parent relationships
+func (m *Model) ExportOPML(filename string) error {
+	doc := opmlDocument{
+		Version: "2.0",
+		Title:   strings.TrimSuffix(filepath.Base(m.FileName()), filepath.Ext(m.FileName())),
+	}
+	visited := make(map[string]bool)
+	for _, root := range m.GetRootNodes() {
+		if outline, ok := m.opmlOutlineFor(root, visited); ok {
+			doc.Body = append(doc.Body, outline)
+		}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	return os.WriteFile(filename, data, 0644)
+}
+
+// opmlOutlineFor converts a node and its children into an outline element
+func (m *Model) opmlOutlineFor(node *Node, visited map[string]bool) (opmlOutline, bool) {
+	if visited[node.ID] {
+		return opmlOutline{}, false
+	}
+	visited[node.ID] = true
+
+	outline := opmlOutline{Text: node.Text}
+	keys := make([]string, 0, len(node.Attrs))
+	for k := range node.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		outline.Attrs = append(outline.Attrs, xml.Attr{Name: xml.Name{Local: k}, Value: node.Attrs[k]})
+	}
+
+	for _, child := range m.GetChildrenOf(node.ID) {
+		if childOutline, ok := m.opmlOutlineFor(child, visited); ok {
+			outline.Children = append(outline.Children, childOutline)
+		}
+	}
+	return outline, true
+}
+
+// ImportOPML replaces the mind map with the outline tree of an OPML file
+func (m *Model) ImportOPML(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var doc opmlDocument
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("%s is not valid OPML: %v", filepath.Base(filename), err)
+	}
+	if len(doc.Body) == 0 {
+		return fmt.Errorf("no outline items found in %s", filename)
+	}
+
+	rootText := doc.Title
+	if rootText == "" {
+		rootText = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	m.buildFromOutline(rootText, opmlItems(doc.Body), nil)
+	return nil
+}
+
+// opmlItems converts outline elements into outline items
+func opmlItems(outlines []opmlOutline) []*outlineItem {
+	items := make([]*outlineItem, 0, len(outlines))
+	for _, outline := range outlines {
+		item := &outlineItem{Text: outline.Text, Children: opmlItems(outline.Children)}
+		for _, attr := range outline.Attrs {
+			if item.Attrs == nil {
+				item.Attrs = make(map[string]string)
+			}
+			item.Attrs[attr.Name.Local] = attr.Value
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// outlineItem is one entry of an imported outline
+type outlineItem struct {
+	Text     string
+	Attrs    map[string]string
+	Notes    string
+	Color    string // Set by hand in the source, so it's kept whatever the coloring mode
+	Children []*outlineItem
+	Node     *Node // The node made from the item, once buildFromOutline has run
+}
+
+// applyTo copies an item's attributes, notes and color onto the node made from it
+func (item *outlineItem) applyTo(node *Node) {
+	node.Attrs = item.Attrs
+	node.Notes = item.Notes
+	if item.Color != "" {
+		node.Color, node.OwnColor = item.Color, true
+	}
+}
+
+// outlineLink is a cross-link listed in an outline's "Links" section
+type outlineLink struct {
+	From, To string
+}
+
+// ImportOutline replaces the mind map with a Markdown bullet list or indented plain-text outline.
+// A single top-level item becomes the root; several top-level items are placed under a
+// root named after the file.
+func (m *Model) ImportOutline(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	items, links := parseOutline(string(content))
+	if len(items) == 0 {
+		return fmt.Errorf("no outline items found in %s", filename)
+	}
+
+	rootText := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	m.buildFromOutline(rootText, items, links)
+	return nil
+}
+
+// buildFromOutline replaces the mind map with the given outline tree.
+// A single top-level item becomes the root; otherwise rootText names a new root above them.
+func (m *Model) buildFromOutline(rootText string, items []*outlineItem, links []outlineLink) {
+	rootItem := &outlineItem{Text: rootText}
+	if len(items) == 1 {
+		rootItem = items[0]
+		items = items[0].Children
+	}
+
+	m.Nodes = map[string]*Node{"0": NewNode("0", rootItem.Text, 0, 0, m.WrapWidth)}
+	rootItem.applyTo(m.Nodes["0"])
+	rootItem.Node = m.Nodes["0"]
+	m.ExtraFields = nil
+	m.LoadWarning = ""
+	m.Marks = nil
+	m.Edges = make([]Edge, 0)
+	m.Camera = NewCamera()
+	m.Selected = "0"
+	m.NextID = 1
+	m.NextColorIndex = 0
+	m.clearHistory()
+	m.invalidateSpatialIndex()
+	m.invalidateDepths()
+	m.Dirty = true // Imported content hasn't been saved as a map yet
+
+	// Create nodes level by level so each column is placed before the one to its right
+	type pending struct {
+		parent *Node
+		items  []*outlineItem
+	}
+	queue := []pending{{parent: m.Nodes["0"], items: items}}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, item := range next.items {
+			node := m.addChild(next.parent, item.Text)
+			item.applyTo(node)
+			item.Node = node
+			if len(item.Children) > 0 {
+				queue = append(queue, pending{parent: node, items: item.Children})
+			}
+		}
+	}
+
+	// Cross-links refer to nodes by text, so only unambiguous ones can be restored
+	byText := make(map[string][]string)
+	for id, node := range m.Nodes {
+		key := singleLine(node.Text)
+		byText[key] = append(byText[key], id)
+	}
+	for _, link := range links {
+		from, to := byText[link.From], byText[link.To]
+		if len(from) == 1 && len(to) == 1 && from[0] != to[0] {
+			m.linkNodes(from[0], to[0])
+		}
+	}
+}
+
+// parseOutline parses nested bullets, headings, or tab/space indented lines into a tree.
+// Tabs count as four columns, so mixed indentation nests by visual width.
+func parseOutline(content string) ([]*outlineItem, []outlineLink) {
+	type frame struct {
+		indent int
+		item   *outlineItem
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	usesBullets := false
+	for _, line := range lines {
+		if _, ok := stripBullet(strings.TrimSpace(line)); ok {
+			usesBullets = true
 			break
 		}
 	}
 
-	// Update NextID to be higher than any existing ID
+	var roots []*outlineItem
+	var links []outlineLink
+	var stack []frame
+	inLinks := false
+
+	for _, line := range lines {
+		indent, text := measureIndent(line)
+		if text == "" {
+			continue
+		}
+
+		// Headings nest by level; a "Links" heading starts the cross-link section
+		if strings.HasPrefix(text, "#") {
+			level := len(text) - len(strings.TrimLeft(text, "#"))
+			heading := strings.TrimSpace(text[level:])
+			inLinks = strings.EqualFold(heading, "links")
+			if inLinks {
+				continue
+			}
+			text = heading
+			indent = level - 7 // Headings sit above any bullet indentation
+		} else if inLinks {
+			if item, ok := stripBullet(text); ok {
+				if from, to, found := strings.Cut(item, " → "); found {
+					links = append(links, outlineLink{From: from, To: to})
+				}
+			}
+			continue
+		} else if item, ok := stripBullet(text); ok {
+			text = item
+		} else if usesBullets && len(stack) > 0 && indent > stack[len(stack)-1].indent {
+			// Indented text under a bullet continues that bullet
+			top := stack[len(stack)-1].item
+			top.Text += "\n" + text
+			continue
+		}
+
+		item := &outlineItem{Text: text}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, item)
+		} else {
+			parent := stack[len(stack)-1].item
+			parent.Children = append(parent.Children, item)
+		}
+		stack = append(stack, frame{indent: indent, item: item})
+	}
+
+	return roots, links
+}
+
+// measureIndent returns the visual indentation width of a line and its trimmed text
+func measureIndent(line string) (int, string) {
+	width := 0
+	for i, ch := range line {
+		switch ch {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width, strings.TrimSpace(line[i:])
+		}
+	}
+	return width, ""
+}
+
+// stripBullet removes a leading "-", "*", "+" or "1." list marker
+func stripBullet(text string) (string, bool) {
+	for _, marker := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(text, marker) {
+			return strings.TrimSpace(text[len(marker):]), true
+		}
+	}
+	if dot := strings.Index(text, ". "); dot > 0 {
+		if _, err := strconv.Atoi(text[:dot]); err == nil {
+			return strings.TrimSpace(text[dot+2:]), true
+		}
+	}
+	return text, false
+}
+
+// isOutlineFile reports whether a path should be imported (an outline, canvas or FreeMind map) rather than loaded as JSON
+func isOutlineFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".txt", ".opml", ".canvas", ".mm":
+		return true
+	}
+	return false
+}
+
+// importFile imports an OPML file, an Obsidian canvas, a FreeMind map or a Markdown/plain-text outline, depending on the extension
+func (m *Model) importFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".opml":
+		return m.ImportOPML(path)
+	case ".canvas":
+		return m.ImportCanvas(path)
+	case ".mm":
+		return m.ImportFreeMind(path)
+	}
+	return m.ImportOutline(path)
+}
+
+// OpenFile loads a JSON map or imports an outline, depending on the file extension.
+// Imported outlines aren't associated with a save file so they can't be clobbered by ctrl+s.
+func (m *Model) OpenFile(path string) error {
+	if isOutlineFile(path) {
+		if err := m.importFile(path); err != nil {
+			return err
+		}
+		m.CurrentFile = ""
+		return nil
+	}
+
+	if err := m.LoadFromFile(path); err != nil {
+		return err
+	}
+	m.CurrentFile = path
+	return nil
+}
+
+// nextFreeID returns one more than the highest numeric node ID
+func (m *Model) nextFreeID() int {
 	maxID := 0
 	for id := range m.Nodes {
 		var numID int
@@ -68,7 +733,31 @@ func (m *Model) LoadFromFile(filename string) error {
 			}
 		}
 	}
-	m.NextID = maxID + 1
+	return maxID + 1
+}
 
-	return nil
+// autosaveMsg triggers a periodic autosave
+type autosaveMsg struct{}
+
+// scheduleAutosave returns a command that fires the next autosave, or nil if autosave is off
+func (m *Model) scheduleAutosave() tea.Cmd {
+	if m.Config.AutosaveSeconds <= 0 {
+		return nil
+	}
+	return tea.Tick(time.Duration(m.Config.AutosaveSeconds)*time.Second, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
+// autosave saves to the current file if there are unsaved changes
+func (m *Model) autosave() {
+	if !m.Dirty || m.CurrentFile == "" {
+		return
+	}
+	if err := m.SaveToFile(m.CurrentFile); err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Autosave: %v", err))
+		return
+	}
+	m.rememberFile()
+	m.setStatus(StatusInfo, fmt.Sprintf("Autosaved to %s", m.CurrentFile))
 }
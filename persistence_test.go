@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// saveTestMap saves m to a file in a temp directory and returns the path and contents
+func saveTestMap(t *testing.T, m *Model) (string, []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "map.json")
+	m.CurrentFile = path
+	if err := m.SaveToFile(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+// loadTestMap loads a saved map into a fresh model
+func loadTestMap(t *testing.T, path string) Model {
+	t.Helper()
+	m := newTestModel(t)
+	m.CurrentFile = path
+	if err := m.LoadFromFile(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return m
+}
+
+func TestSaveLoadSaveIsIdentical(t *testing.T) {
+	m := newTestModel(t)
+	first := addTestChild(&m, "0", "First")
+	addTestChild(&m, first, "Child of first")
+	second := addTestChild(&m, "0", "Second")
+	m.Selected = second
+
+	path, saved := saveTestMap(t, &m)
+	reloaded := loadTestMap(t, path)
+	_, resaved := saveTestMap(t, &reloaded)
+	if !bytes.Equal(saved, resaved) {
+		t.Errorf("save after load differs:\n%s\n---\n%s", saved, resaved)
+	}
+}
+
+func TestEditingStateSurvivesReload(t *testing.T) {
+	m := newTestModel(t)
+	first := addTestChild(&m, "0", "First")
+	addTestChild(&m, "0", "Second")
+	m.DeleteNode(first) // Leaves a gap below NextID that must not be reused
+	m.Selected = "0"
+	addTestChild(&m, "0", "Third")
+
+	path, _ := saveTestMap(t, &m)
+	reloaded := loadTestMap(t, path)
+	if reloaded.Selected != m.Selected || reloaded.NextID != m.NextID || reloaded.NextColorIndex != m.NextColorIndex {
+		t.Errorf("reload gave selected %q, next ID %d, next color %d; want %q, %d, %d",
+			reloaded.Selected, reloaded.NextID, reloaded.NextColorIndex, m.Selected, m.NextID, m.NextColorIndex)
+	}
+}
+
+func TestBranchColorsAdvanceAcrossSessions(t *testing.T) {
+	m := newTestModel(t)
+	colors := map[string]bool{}
+	for _, text := range []string{"One", "Two"} {
+		colors[m.Nodes[addTestChild(&m, "0", text)].Color] = true
+	}
+	removed := addTestChild(&m, "0", "Three")
+	colors[m.Nodes[removed].Color] = true
+	m.DeleteNode(removed) // Its color stays taken, as it would have without the reload
+
+	path, _ := saveTestMap(t, &m)
+	reloaded := loadTestMap(t, path)
+	color := reloaded.Nodes[addTestChild(&reloaded, "0", "Four")].Color
+	if colors[color] {
+		t.Errorf("new branch after reload reuses color %s", color)
+	}
+	if want := branchPalette[3%len(branchPalette)]; color != want {
+		t.Errorf("new branch after reload has color %s, want %s", color, want)
+	}
+}
+
+func TestOldFilesInferEditingState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.json")
+	old := `{
+  "nodes": {
+    "0": {"id": "0", "text": "Root", "x": 0, "y": 0},
+    "3": {"id": "3", "text": "A", "x": 20, "y": 0, "parent_id": "0"},
+    "12": {"id": "12", "text": "B", "x": 20, "y": 5, "parent_id": "0"}
+  },
+  "edges": [{"from": "0", "to": "3"}, {"from": "0", "to": "12"}],
+  "camera": {"x": 0, "y": 0, "zoom": 1}
+}`
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := loadTestMap(t, path)
+	if m.Selected != "0" || m.NextID != 13 || m.NextColorIndex != 2 {
+		t.Errorf("got selected %q, next ID %d, next color %d; want %q, 13, 2", m.Selected, m.NextID, m.NextColorIndex, "0")
+	}
+}
+
+// deterministicTestModel returns a map with more than ten nodes, cross-links added out of
+// order and positions with leftovers of an animation
+func deterministicTestModel(t *testing.T) Model {
+	m := newTestModel(t)
+	for i := 1; i <= 12; i++ {
+		addTestChild(&m, fmt.Sprint((i-1)/3), fmt.Sprintf("Node %d", i))
+	}
+	m.AddEdge("11", "2")
+	m.AddEdge("3", "10")
+	m.Nodes["5"].X += 0.1 + 1e-12
+	m.Nodes["7"].Y -= 1e-9
+	return m
+}
+
+func TestRepeatedSavesAreIdentical(t *testing.T) {
+	m := deterministicTestModel(t)
+	_, first := saveTestMap(t, &m)
+	for i := range 5 {
+		m.Nodes["5"].X += 1e-7 // Residue below the saved precision
+		if _, again := saveTestMap(t, &m); !bytes.Equal(first, again) {
+			t.Fatalf("save %d differs:\n%s\n---\n%s", i+2, first, again)
+		}
+	}
+}
+
+func TestSavedOrder(t *testing.T) {
+	m := deterministicTestModel(t)
+	_, data := saveTestMap(t, &m)
+	var saved MindMapData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(saved.Nodes))
+	for i, node := range saved.Nodes {
+		ids[i] = node.ID
+	}
+	if !slices.IsSortedFunc(ids, compareIDs) || ids[2] != "2" || ids[len(ids)-1] != "12" {
+		t.Errorf("nodes saved in order %v, want numeric ID order", ids)
+	}
+	if !slices.IsSortedFunc(saved.Edges, func(a, b Edge) int {
+		if c := compareIDs(a.FromID, b.FromID); c != 0 {
+			return c
+		}
+		return compareIDs(a.ToID, b.ToID)
+	}) {
+		t.Errorf("edges saved in order %v, want by source then target", saved.Edges)
+	}
+	if x := saved.Nodes[5].X; x != roundCoord(x) || x != roundCoord(m.Nodes["5"].X) {
+		t.Errorf("node 5 saved at x %v, want it rounded to %v", x, roundCoord(m.Nodes["5"].X))
+	}
+}
+
+func TestLoadNodesKeyedByID(t *testing.T) {
+	m := deterministicTestModel(t)
+	path, data := saveTestMap(t, &m)
+
+	// Rewrite the file the way version 1 did, with nodes in an object keyed by ID
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	var nodes []*Node
+	if err := json.Unmarshal(fields["nodes"], &nodes); err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]*Node, len(nodes))
+	for _, node := range nodes {
+		byID[node.ID] = node
+	}
+	fields["nodes"], _ = json.Marshal(byID)
+	fields["version"] = json.RawMessage("1")
+	old, _ := json.MarshalIndent(fields, "", "  ")
+	if err := os.WriteFile(path, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadTestMap(t, path)
+	_, resaved := saveTestMap(t, &reloaded)
+	if !bytes.Equal(data, resaved) {
+		t.Errorf("version 1 file loads differently:\n%s\n---\n%s", data, resaved)
+	}
+}
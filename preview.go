@@ -0,0 +1,48 @@
+package main
+
+// previewID is the ID of the ghost drawn for a node being created. Real nodes never have
+// an empty ID, so the ghost can't be mistaken for one of them.
+const previewID = ""
+
+// createPreview returns the ghost of the node being created: where it will go, sized for
+// the text typed so far. It is nil outside of node creation and is never added to m.Nodes.
+func (m Model) createPreview() *Node {
+	if m.Mode != ModeEdit || m.Creating.Kind == CreateNone {
+		return nil
+	}
+	ghost := NewNode(previewID, withCursor(m.EditBuffer, m.EditCursor), m.Creating.X, m.Creating.Y, m.WrapWidth)
+	ghost.X = m.Creating.nodeX(ghost.Width)
+	ghost.Color = m.Theme.Hint
+	return ghost
+}
+
+// previewParent returns the node the one being created will hang off, or nil for a floating node
+func (m Model) previewParent() *Node {
+	anchor := m.Nodes[m.Creating.AnchorID]
+	if anchor != nil && (m.Creating.Kind == CreateSibling || m.Creating.Kind == CreateParent) {
+		return m.Nodes[anchor.ParentID] // Same parent as the anchor
+	}
+	return anchor
+}
+
+// drawPreviewEdge draws a dashed line from the future parent to the ghost of the node being created
+func (m Model) drawPreviewEdge(grid [][]ColoredCell) {
+	ghost := m.createPreview()
+	if ghost == nil {
+		return
+	}
+	parent := m.previewParent()
+	if parent == nil || m.isHidden(parent.ID) {
+		return
+	}
+	x1, y1, x2, y2 := m.edgeEndpoints(grid, parent, ghost)
+	m.drawDashedLine(grid, x1, y1, x2, y2, m.Theme.Hint)
+}
+
+// drawPreviewNode draws the ghost of the node being created on top of the map, with a
+// dashed border. Nodes in its way only make room once it is created.
+func (m Model) drawPreviewNode(grid [][]ColoredCell) {
+	if ghost := m.createPreview(); ghost != nil {
+		m.drawNode(grid, ghost, false)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRecent is how many maps the recent files list keeps
+const maxRecent = 10
+
+// RecentFile is a map that was opened or saved lately
+type RecentFile struct {
+	Path   string    `json:"path"` // Absolute path of the map
+	Opened time.Time `json:"opened"`
+	Nodes  int       `json:"nodes"` // Node count when last opened or saved
+}
+
+// recentPath returns where the recent files list is kept
+func recentPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "recent.json"), nil
+}
+
+// LoadRecent reads the recent files list, newest first. A missing or unreadable list is empty.
+func LoadRecent() []RecentFile {
+	path, err := recentPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var recent []RecentFile
+	if err := json.Unmarshal(data, &recent); err != nil {
+		return nil
+	}
+	return recent
+}
+
+// saveRecent writes the recent files list
+func saveRecent(recent []RecentFile) error {
+	path, err := recentPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(recent, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, false)
+}
+
+// rememberFile moves the current map to the top of the recent files list. It is called
+// after each load and save in the UI; the list is a convenience, so failing to write it
+// isn't reported.
+func (m *Model) rememberFile() {
+	if m.CurrentFile == "" {
+		return
+	}
+	path, err := filepath.Abs(m.CurrentFile)
+	if err != nil {
+		return
+	}
+	recent := slices.DeleteFunc(LoadRecent(), func(r RecentFile) bool { return r.Path == path })
+	recent = append([]RecentFile{{Path: path, Opened: time.Now(), Nodes: len(m.Nodes)}}, recent...)
+	saveRecent(recent[:min(len(recent), maxRecent)])
+}
+
+// OpenRecent shows the recent files picker
+func (m *Model) OpenRecent() {
+	m.Recent = LoadRecent()
+	m.RecentIndex = 0
+	m.ShowRecent = true
+}
+
+// openStartupPicker shows the recent files picker at startup. Crash recovery waits until
+// the picker closes, since until then it isn't known which map will be open.
+func (m *Model) openStartupPicker() {
+	m.OpenRecent()
+	m.RecoverOnClose = true
+}
+
+// closeRecent hides the picker. Closing the startup picker without opening a map leaves
+// the new map open, so that's when recovery is offered for it.
+func (m *Model) closeRecent() {
+	m.ShowRecent = false
+	if m.RecoverOnClose {
+		m.RecoverOnClose = false
+		m.offerRecovery()
+	}
+}
+
+// recentChoices is how many entries the picker has: the recent maps, "New map" and "Open path…"
+func (m Model) recentChoices() int {
+	return len(m.Recent) + 2
+}
+
+// chooseRecent acts on the chosen picker entry. A map that no longer exists is dropped
+// from the list instead of opened.
+func (m *Model) chooseRecent() tea.Cmd {
+	switch index := m.RecentIndex; {
+	case index == len(m.Recent):
+		m.setStatus(StatusInfo, "New map")
+		m.closeRecent()
+	case index == len(m.Recent)+1:
+		// :e offers recovery for the map it opens
+		m.ShowRecent, m.RecoverOnClose = false, false
+		m.startCommand("e ")
+	case !fileExists(m.Recent[index].Path):
+		path := m.Recent[index].Path
+		m.Recent = slices.Delete(m.Recent, index, index+1)
+		saveRecent(m.Recent)
+		m.setStatus(StatusWarn, fmt.Sprintf("%s no longer exists; removed it from the list", path))
+	default:
+		// Opening the map offers recovery for it
+		m.ShowRecent, m.RecoverOnClose = false, false
+		path := m.Recent[index].Path
+		if session, ok := LoadSession(); ok && session.File == path {
+			// The last map opens where it was left
+			if err := m.resumeSession(session); err != nil {
+				m.setStatus(StatusError, fmt.Sprintf("Error loading: %v", err))
+				return nil
+			}
+			m.offerRecovery()
+			return nil
+		}
+		m.commandEdit(path, false)
+	}
+	return nil
+}
+
+// handleRecentKey handles keys in the recent files picker: j/k choose an entry, Enter
+// opens it and Esc starts a new map
+func (m Model) handleRecentKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.RecentIndex = max(m.RecentIndex-1, 0)
+	case "down", "j":
+		m.RecentIndex = min(m.RecentIndex+1, m.recentChoices()-1)
+	case "enter":
+		cmd := m.chooseRecent()
+		return m, cmd
+	case "esc", "q":
+		m.closeRecent()
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderRecentOverlay lists the recent maps, with those that no longer exist dimmed,
+// followed by "New map" and "Open path…"
+func (m Model) renderRecentOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	chosenStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	goneStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.FilteredOut))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	pathWidth := max(20, min(60, m.Width-40))
+	lines := []string{titleStyle.Render("🕘 Recent maps"), ""}
+	for i := 0; i < m.recentChoices(); i++ {
+		var desc string
+		style := descStyle
+		switch {
+		case i == len(m.Recent):
+			desc = "New map"
+			if len(m.Recent) > 0 {
+				lines = append(lines, "")
+			}
+		case i == len(m.Recent)+1:
+			desc = "Open path…"
+		default:
+			recent := m.Recent[i]
+			desc = fmt.Sprintf("%-*s  %d nodes · %s", pathWidth, shortenPath(recent.Path, pathWidth), recent.Nodes, recent.Opened.Format("Jan 2 15:04"))
+			if !fileExists(recent.Path) {
+				desc = fmt.Sprintf("%-*s  (missing)", pathWidth, shortenPath(recent.Path, pathWidth))
+				style = goneStyle
+			}
+		}
+		if i == m.RecentIndex {
+			lines = append(lines, chosenStyle.Render("▶ "+desc))
+		} else {
+			lines = append(lines, style.Render("  "+desc))
+		}
+	}
+	lines = append(lines, "", footerStyle.Render("j/k choose · Enter open · Esc new map"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
+
+// shortenPath fits a path into width cells, writing the home directory as ~ and cutting
+// from the left, where the path is least telling
+func shortenPath(path string, width int) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		path = "~" + path[len(home):]
+	}
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recoveryInterval is how often unsaved changes are written to the recovery file
+const recoveryInterval = 15 * time.Second
+
+// recoveryMsg triggers a write of the recovery file
+type recoveryMsg struct{}
+
+// recoveryPath returns the hidden sidecar holding unsaved changes for a map,
+// e.g. notes/map.json → notes/.map.json.autosave
+func recoveryPath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), "."+filepath.Base(filename)+".autosave")
+}
+
+// scheduleRecovery returns a command that fires the next recovery write
+func scheduleRecovery() tea.Cmd {
+	return tea.Tick(recoveryInterval, func(time.Time) tea.Msg {
+		return recoveryMsg{}
+	})
+}
+
+// writeRecovery saves unsaved changes to the recovery file so a crash doesn't lose them
+func (m *Model) writeRecovery() {
+	if !m.Dirty {
+		return
+	}
+	data, err := m.marshalMap()
+	if err == nil {
+		err = writeFileAtomic(recoveryPath(m.FileName()), data, false)
+	}
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Error writing recovery file: %v", err))
+	}
+}
+
+// removeRecovery deletes the recovery file for a map once its changes are saved
+func removeRecovery(filename string) {
+	os.Remove(recoveryPath(filename))
+}
+
+// offerRecovery asks whether to restore unsaved changes if the map's recovery file
+// is newer than the map itself
+func (m *Model) offerRecovery() {
+	sidecar := recoveryPath(m.FileName())
+	info, err := os.Stat(sidecar)
+	if err != nil {
+		return
+	}
+	if mapInfo, err := os.Stat(m.FileName()); err == nil && !info.ModTime().After(mapInfo.ModTime()) {
+		return // Saved after the recovery file was written
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmRecover
+	m.ConfirmTarget = sidecar
+	m.ConfirmPrompt = fmt.Sprintf("Recover unsaved changes from %s? [y/n]", info.ModTime().Format("Jan 2 15:04"))
+}
+
+// answerRecover handles the answer to the recovery prompt: y loads the unsaved changes,
+// n discards them, Esc leaves the recovery file for next time
+func (m Model) answerRecover(key string) (tea.Model, tea.Cmd) {
+	sidecar := m.ConfirmTarget
+	switch key {
+	case "y":
+		m.endConfirm()
+		currentFile := m.CurrentFile
+		if err := m.LoadFromFile(sidecar); err != nil {
+			m.setStatus(StatusError, fmt.Sprintf("Error recovering: %v", err))
+			return m, nil
+		}
+		m.CurrentFile = currentFile
+		m.Dirty = true // Recovered changes still need saving
+		m.setStatus(m.loadStatusLevel(), "Recovered unsaved changes"+m.loadWarningSuffix())
+	case "n":
+		m.endConfirm()
+		os.Remove(sidecar)
+		m.setStatus(StatusInfo, "Discarded unsaved changes")
+	case "esc":
+		m.endConfirm()
+		m.setStatus(StatusInfo, "Cancelled")
+	}
+	return m, nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 // ColoredCell holds a character and its color
@@ -14,44 +15,75 @@ type ColoredCell struct {
 	Color string
 }
 
+// wideContinuation marks the second cell covered by a double-width character
+const wideContinuation rune = 0
+
+// Below this size the map, status bar and overlays don't fit, so View shows a notice instead
+const (
+	minScreenWidth  = 40
+	minScreenHeight = 10
+)
+
 // View renders the mind map
 func (m Model) View() string {
-	if m.Width == 0 || m.Height == 0 {
+	if m.Width <= 0 || m.Height <= 0 {
 		return ""
 	}
+	if m.Width < minScreenWidth || m.Height < minScreenHeight {
+		return m.renderTooSmall()
+	}
 
 	// If help overlay is shown, render it over everything
 	if m.ShowHelp {
 		return m.renderHelpOverlay()
 	}
+	if m.ShowStats {
+		return m.renderStatsOverlay()
+	}
+	if m.ShowMarks {
+		return m.renderMarksOverlay()
+	}
+	if m.ShowCheck {
+		return m.renderCheckOverlay()
+	}
+	if m.ShowTrash {
+		return m.renderTrashOverlay()
+	}
+	if m.ShowTemplates {
+		return m.renderTemplateOverlay()
+	}
+	if m.ShowRecent {
+		return m.renderRecentOverlay()
+	}
+	if m.ShowMessages {
+		return m.renderMessagesOverlay()
+	}
 
-	// Create a 2D grid for rendering with color information
-	grid := make([][]ColoredCell, m.Height-1) // -1 for status bar
-	for i := range grid {
-		grid[i] = make([]ColoredCell, m.Width)
-		for j := range grid[i] {
-			grid[i][j] = ColoredCell{Char: ' ', Color: ""}
-		}
+	// A terminal only a row or two tall has room for the status bar alone
+	if m.canvasHeight() == 0 {
+		return m.renderStatusBar()
 	}
 
-	// Draw edges first (so they appear behind nodes)
-	m.drawEdges(grid)
+	// Create a 2D grid for rendering with color information
+	grid := newGrid(m.Width, m.canvasHeight())
+	m.drawMap(grid)
 
-	// Draw nodes
-	m.drawNodes(grid)
+	// Overlays sit on top of the map
+	if m.Mode == ModeHint {
+		m.drawHints(grid)
+	}
+	if m.ShowMinimap {
+		m.drawMinimap(grid)
+	}
+	if m.ShowLegend {
+		m.drawLegend(grid)
+	}
+	grid = append(grid, m.drawNotesPanel()...)
 
 	// Convert grid to string with colors
 	var sb strings.Builder
 	for _, row := range grid {
-		for _, cell := range row {
-			if cell.Color != "" {
-				// Apply color using lipgloss
-				style := lipgloss.NewStyle().Foreground(lipgloss.Color(cell.Color))
-				sb.WriteString(style.Render(string(cell.Char)))
-			} else {
-				sb.WriteRune(cell.Char)
-			}
-		}
+		m.writeRow(&sb, row)
 		sb.WriteRune('\n')
 	}
 
@@ -62,41 +94,217 @@ func (m Model) View() string {
 	return sb.String()
 }
 
+// newGrid allocates a blank grid of the given size in cells
+func newGrid(width, height int) [][]ColoredCell {
+	grid := make([][]ColoredCell, height)
+	for i := range grid {
+		grid[i] = make([]ColoredCell, width)
+		for j := range grid[i] {
+			grid[i][j] = ColoredCell{Char: ' ', Color: ""}
+		}
+	}
+	return grid
+}
+
+// gridSize returns a grid's width and height in cells
+func gridSize(grid [][]ColoredCell) (width, height int) {
+	if len(grid) == 0 {
+		return 0, 0
+	}
+	return len(grid[0]), len(grid)
+}
+
+// drawMap draws the map as seen through m.Camera onto a grid of any size,
+// with the camera centered on the middle of the grid
+func (m Model) drawMap(grid [][]ColoredCell) {
+	// Draw edges first (so they appear behind nodes)
+	ports := m.drawEdges(grid)
+
+	// Draw nodes, then join the edges to their borders
+	m.drawNodes(grid)
+	drawPorts(grid, ports)
+	m.drawPreviewNode(grid)
+}
+
+// writeRow writes one grid row, coalescing consecutive cells of the same color
+// into a single styled run so each run costs one escape sequence instead of one per cell
+func (m Model) writeRow(sb *strings.Builder, row []ColoredCell) {
+	var run strings.Builder
+	runColor := ""
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if runColor != "" && !m.NoColor {
+			sb.WriteString(m.colorStyle(runColor).Render(run.String()))
+		} else {
+			sb.WriteString(run.String())
+		}
+		run.Reset()
+	}
+
+	prevWide := false
+	for _, cell := range row {
+		if cell.Char == wideContinuation {
+			// Already covered by the wide character to the left, unless that was overdrawn
+			if !prevWide {
+				run.WriteRune(' ')
+			}
+			prevWide = false
+			continue
+		}
+		prevWide = runewidth.RuneWidth(cell.Char) == 2
+
+		if cell.Color != runColor {
+			flush()
+			runColor = cell.Color
+		}
+		run.WriteRune(cell.Char)
+	}
+	flush()
+}
+
+// colorStyle returns a cached foreground style for a hex color, mapped through the theme
+func (m Model) colorStyle(color string) lipgloss.Style {
+	if style, ok := m.styleCache[color]; ok {
+		return style
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(m.Theme.nodeColor(color)))
+	if m.styleCache != nil {
+		m.styleCache[color] = style
+	}
+	return style
+}
+
 // drawNodes renders all nodes onto the grid
 func (m Model) drawNodes(grid [][]ColoredCell) {
+	current := m.currentSearchMatch()
+	var visible map[string]bool
+	if m.TagFilter != "" {
+		visible = m.tagFilterVisible()
+	}
+	gridWidth, gridHeight := gridSize(grid)
 	for id, node := range m.Nodes {
-		m.drawNode(grid, node, id == m.Selected)
+		if !m.nodeOnScreen(node, gridWidth, gridHeight) || m.isHidden(id) {
+			continue
+		}
+		node = m.displayNode(node)
+		if m.Mode == ModeEdit && m.Creating.Kind == CreateNone && !m.EditingNotes && id == m.Selected {
+			// Show the text being edited, with its cursor, inside the node itself
+			editing := *node
+			editing.Text = withCursor(m.EditBuffer, m.EditCursor)
+			editing.UpdateSize(m.WrapWidth)
+			m.drawNode(grid, &editing, true)
+			continue
+		}
+		if m.Mode == ModeSearch && m.isSearchMatch(id) {
+			// Draw search matches in the highlight color; the best match gets the bold border
+			highlighted := *node
+			highlighted.Color = m.Theme.Search
+			m.drawNode(grid, &highlighted, id == current)
+			continue
+		}
+		if m.inDoneSubtree(id) {
+			// Completed tasks and everything below them are dimmed
+			done := *node
+			done.Color = m.Theme.Done
+			m.drawNode(grid, &done, m.isSelected(id))
+			continue
+		}
+		if visible != nil && !visible[id] {
+			// Nodes lacking the filter tag fade into the background
+			filtered := *node
+			filtered.Color = m.Theme.FilteredOut
+			m.drawNode(grid, &filtered, m.isSelected(id))
+			continue
+		}
+		m.drawNode(grid, node, m.isSelected(id))
+	}
+}
+
+// renderTooSmall explains that the terminal is too small, using only as much room as there is
+func (m Model) renderTooSmall() string {
+	lines := []string{
+		"Terminal too small —",
+		fmt.Sprintf("need at least %d×%d", minScreenWidth, minScreenHeight),
+		fmt.Sprintf("(now %d×%d)", m.Width, m.Height),
 	}
+	lines = lines[:min(len(lines), m.Height)]
+	for i, line := range lines {
+		lines[i] = truncateWidth(line, m.Width)
+	}
+	return lipgloss.Place(m.Width, m.Height, lipgloss.Center, lipgloss.Center, strings.Join(lines, "\n"))
+}
+
+// canvasHeight returns the number of rows the map is drawn in, leaving room for the
+// status bar and the notes panel
+func (m Model) canvasHeight() int {
+	return max(0, m.Height-1-m.notesPanelHeight())
+}
+
+// pageHeight returns how many world rows fit on the screen at the current zoom
+func (m Model) pageHeight() float64 {
+	return float64(m.canvasHeight()) / m.Camera.Zoom
+}
+
+// nodeOnScreen reports whether any part of a node falls inside a view of the given size
+func (m Model) nodeOnScreen(node *Node, viewWidth, viewHeight int) bool {
+	sx, sy := m.Camera.WorldToScreen(node.X, node.Y, viewWidth, viewHeight)
+	width := int(float64(node.Width)*m.Camera.Zoom) + 2 // +2 for the selection marker
+	height := int(float64(node.Height) * m.Camera.Zoom)
+	return sx+width >= 0 && sx-2 < viewWidth && sy+height >= 0 && sy < viewHeight
 }
 
-// drawNode renders a single node onto the grid
+// nodeScreenRect returns the grid cells a node is drawn in: its top-left corner and size.
+// Nodes too small for a box are drawn as a single point.
+func (m Model) nodeScreenRect(grid [][]ColoredCell, node *Node) (x, y, width, height int) {
+	gridWidth, gridHeight := gridSize(grid)
+	x, y = m.Camera.WorldToScreen(node.X, node.Y, gridWidth, gridHeight)
+	width = int(float64(node.Width) * m.Camera.Zoom)
+	height = int(float64(node.Height) * m.Camera.Zoom)
+	if width < 3 || height < 2 {
+		return x, y, 1, 1
+	}
+	return x, y, width, height
+}
+
+// drawNode renders a single node onto the grid. Nodes may be larger than the grid or
+// start far off it, as when zoomed in, so only the cells that fall on the grid are drawn.
 func (m Model) drawNode(grid [][]ColoredCell, node *Node, isSelected bool) {
-	// Convert world coordinates to screen coordinates
-	sx, sy := m.Camera.WorldToScreen(node.X, node.Y, m.Width, m.Height-1)
+	// Convert world coordinates to screen coordinates, applying zoom to the size
+	sx, sy, width, height := m.nodeScreenRect(grid, node)
+	gridWidth, gridHeight := gridSize(grid)
+	put := func(x, y int, ch rune, color string) {
+		if x >= 0 && x < gridWidth && y >= 0 && y < gridHeight {
+			grid[y][x] = ColoredCell{Char: ch, Color: color}
+		}
+	}
 
 	// Check if node is visible
-	if sy >= len(grid) || sy < 0 {
+	if sx-2 >= gridWidth || sy >= gridHeight || sx+width <= 0 || sy+height <= 0 { // -2 for the selection marker
 		return
 	}
 
-	// Apply zoom to size
-	width := int(float64(node.Width) * m.Camera.Zoom)
-	height := int(float64(node.Height) * m.Camera.Zoom)
-
 	// Don't render if too small
 	if width < 3 || height < 2 {
 		// Just draw a point
-		if sy >= 0 && sy < len(grid) && sx >= 0 && sx < len(grid[0]) {
-			grid[sy][sx] = ColoredCell{Char: '●', Color: node.Color}
-		}
+		put(sx, sy, '●', node.Color)
 		return
 	}
 
 	// Get border runes based on selection
 	// Selected nodes use rounded double-line borders for emphasis
 	// Unselected nodes use single-line rounded corners for clean look
+	// The source of a link being made gets a double border so it stays recognizable
+	// The ghost of a node being created has a dashed border
 	var top, bottom, left, right, topLeft, topRight, bottomLeft, bottomRight rune
-	if isSelected {
+	if m.isLinkSource(node.ID) {
+		top, bottom, left, right = '═', '═', '║', '║'
+		topLeft, topRight, bottomLeft, bottomRight = '╔', '╗', '╚', '╝'
+	} else if node.ID == previewID {
+		top, bottom, left, right = '╌', '╌', '╎', '╎'
+		topLeft, topRight, bottomLeft, bottomRight = '╭', '╮', '╰', '╯'
+	} else if isSelected {
 		top, bottom, left, right = '━', '━', '┃', '┃'
 		topLeft, topRight, bottomLeft, bottomRight = '┏', '┓', '┗', '┛'
 	} else {
@@ -104,214 +312,435 @@ func (m Model) drawNode(grid [][]ColoredCell, node *Node, isSelected bool) {
 		topLeft, topRight, bottomLeft, bottomRight = '╭', '╮', '╰', '╯'
 	}
 
+	// Columns of the box that are on the grid, without its left and right borders
+	firstX, lastX := max(sx+1, 0), min(sx+width-1, gridWidth)
+
 	// Add selection indicator
-	if isSelected && sy >= 0 && sy < len(grid) && sx-2 >= 0 && sx-2 < len(grid[0]) {
-		grid[sy][sx-2] = ColoredCell{Char: '▶', Color: node.Color}
+	if isSelected {
+		put(sx-2, sy, '▶', node.Color)
 	}
 
 	// Draw top border
-	if sy >= 0 && sy < len(grid) {
-		if sx >= 0 && sx < len(grid[0]) {
-			grid[sy][sx] = ColoredCell{Char: topLeft, Color: node.Color}
+	if sy >= 0 {
+		put(sx, sy, topLeft, node.Color)
+		for x := firstX; x < lastX; x++ {
+			put(x, sy, top, node.Color)
 		}
-		for x := sx + 1; x < sx+width-1 && x < len(grid[0]); x++ {
-			if x >= 0 {
-				grid[sy][x] = ColoredCell{Char: top, Color: node.Color}
+		put(sx+width-1, sy, topRight, node.Color)
+
+		// Nodes with notes get a marker in the top-right corner of the border
+		if node.Notes != "" && width >= 6 {
+			put(sx+width-3, sy, '≡', node.Color)
+		}
+
+		// The node's ID goes at the start of the top border when IDs are shown
+		labelX := sx + 2
+		if id := "#" + node.ID; m.ShowIDs && len(id)+2 <= width {
+			for i, ch := range id {
+				put(sx+1+i, sy, ch, m.Theme.Info)
 			}
+			labelX = sx + 1 + len(id)
 		}
-		if sx+width-1 >= 0 && sx+width-1 < len(grid[0]) {
-			grid[sy][sx+width-1] = ColoredCell{Char: topRight, Color: node.Color}
+
+		// Parents of tasks show how many are done in the top border
+		if done, total, ok := m.taskRollup(node.ID); ok {
+			label := fmt.Sprintf(" %d/%d ", done, total)
+			if labelX-sx+len(label)+2 <= width {
+				for i, ch := range label {
+					put(labelX+i, sy, ch, node.Color)
+				}
+			}
 		}
 	}
 
 	// Draw middle (text with improved padding)
 	// Use the same wrapping logic as calculateNodeSize
-	const maxTextWidth = 22
-	lines := wrapText(node.Text, maxTextWidth)
-	for i := 1; i < height-1; i++ {
+	lines := wrapText(node.displayText(), m.WrapWidth)
+	centered := m.centersText(lines)
+	tagLineIdx := -1
+	if tags := node.tagLine(); tags != "" {
+		tagLineIdx = len(lines)
+		lines = append(lines, tags)
+	}
+	maxRenderWidth := width - 4 // Account for borders and padding (2 spaces)
+	for i := max(1, -sy); i < height-1 && sy+i < gridHeight; i++ {
 		y := sy + i
-		if y < 0 || y >= len(grid) {
-			continue
-		}
-
-		// Left border
-		if sx >= 0 && sx < len(grid[0]) {
-			grid[y][sx] = ColoredCell{Char: left, Color: node.Color}
-		}
 
-		// Add left padding space
-		if sx+1 >= 0 && sx+1 < len(grid[0]) {
-			grid[y][sx+1] = ColoredCell{Char: ' ', Color: ""}
-		}
+		// Left border and padding
+		put(sx, y, left, node.Color)
+		put(sx+1, y, ' ', "")
 
-		// Text content
+		// Text content; boxes too narrow for any are drawn as borders only
 		lineIdx := i - 1
-		if lineIdx < len(lines) {
-			text := lines[lineIdx]
-			maxRenderWidth := width - 4 // Account for borders and padding (2 spaces)
-			if len(text) > maxRenderWidth {
-				text = text[:maxRenderWidth]
+		if lineIdx < len(lines) && maxRenderWidth > 0 {
+			text := truncateWidth(lines[lineIdx], maxRenderWidth)
+			color := node.Color
+			if lineIdx == tagLineIdx {
+				color = m.Theme.Tag
 			}
 
-			for j, ch := range text {
-				x := sx + j + 2 // +2 for border and left padding
-				if x >= 0 && x < len(grid[0]) {
-					grid[y][x] = ColoredCell{Char: ch, Color: node.Color}
+			x := sx + 2 // +2 for border and left padding
+			if centered {
+				x += (maxRenderWidth - textWidth(text)) / 2
+			}
+			for _, ch := range text {
+				if x >= gridWidth {
+					break
+				}
+				w := runewidth.RuneWidth(ch)
+				if w == 2 && x+1 >= gridWidth {
+					ch = ' ' // No room for the second half at the screen edge
+				}
+				put(x, y, ch, color)
+				if w == 2 && x == -1 {
+					put(x+1, y, ' ', "") // The first half is off the grid
+				} else if w == 2 {
+					put(x+1, y, wideContinuation, color)
 				}
+				x += w
 			}
 		}
 
-		// Add right padding space
-		if sx+width-2 >= 0 && sx+width-2 < len(grid[0]) {
-			grid[y][sx+width-2] = ColoredCell{Char: ' ', Color: ""}
+		// Right padding and border
+		put(sx+width-2, y, ' ', "")
+		put(sx+width-1, y, right, node.Color)
+	}
+
+	// Draw bottom border
+	if bottomY := sy + height - 1; bottomY >= 0 && bottomY < gridHeight {
+		put(sx, bottomY, bottomLeft, node.Color)
+		for x := firstX; x < lastX; x++ {
+			put(x, bottomY, bottom, node.Color)
+		}
+		put(sx+width-1, bottomY, bottomRight, node.Color)
+
+		// Collapsed nodes say how many nodes they hide in the bottom border
+		if node.Collapsed {
+			label := fmt.Sprintf(" +%d ", len(m.GetDescendantsOf(node.ID)))
+			if len(label)+4 <= width {
+				for i, ch := range label {
+					put(sx+2+i, bottomY, ch, node.Color)
+				}
+			}
 		}
+	}
+}
 
-		// Right border
-		if sx+width-1 >= 0 && sx+width-1 < len(grid[0]) {
-			grid[y][sx+width-1] = ColoredCell{Char: right, Color: node.Color}
+// drawEdges renders all edges onto the grid and returns where they meet node borders
+func (m Model) drawEdges(grid [][]ColoredCell) []edgePort {
+	// The link about to be made is drawn first so it wins shared cells
+	m.drawLinkPreview(grid)
+	m.drawPreviewEdge(grid)
+
+	// The edge chosen in edge mode is drawn first so it wins shared cells
+	var ports []edgePort
+	highlighted := -1
+	if m.Mode == ModeEdge {
+		highlighted = m.currentEdge()
+		if highlighted >= 0 {
+			edge := m.Edges[highlighted]
+			fromNode, toNode := m.Nodes[edge.FromID], m.Nodes[edge.ToID]
+			if fromNode != nil && toNode != nil {
+				ports = append(ports, m.drawEdge(grid, fromNode, toNode, m.Theme.Danger)...)
+			}
 		}
 	}
 
-	// Draw bottom border
-	if sy+height-1 >= 0 && sy+height-1 < len(grid) {
-		if sx >= 0 && sx < len(grid[0]) {
-			grid[sy+height-1][sx] = ColoredCell{Char: bottomLeft, Color: node.Color}
+	var visible map[string]bool
+	if m.TagFilter != "" {
+		visible = m.tagFilterVisible()
+	}
+
+	for i, edge := range m.Edges {
+		if i == highlighted {
+			continue
 		}
-		for x := sx + 1; x < sx+width-1 && x < len(grid[0]); x++ {
-			if x >= 0 {
-				grid[sy+height-1][x] = ColoredCell{Char: bottom, Color: node.Color}
+		fromNode := m.Nodes[edge.FromID]
+		toNode := m.Nodes[edge.ToID]
+		if fromNode != nil && toNode != nil && !m.isHidden(fromNode.ID) && !m.isHidden(toNode.ID) {
+			color := m.displayColor(toNode)
+			if visible != nil && (!visible[fromNode.ID] || !visible[toNode.ID]) {
+				color = m.Theme.FilteredOut
 			}
+			ports = append(ports, m.drawEdge(grid, fromNode, toNode, color)...)
 		}
-		if sx+width-1 >= 0 && sx+width-1 < len(grid[0]) {
-			grid[sy+height-1][sx+width-1] = ColoredCell{Char: bottomRight, Color: node.Color}
+	}
+	return ports
+}
+
+// drawEdge draws a line between two nodes, connecting at their borders, and returns the
+// border cells it leaves and enters
+func (m Model) drawEdge(grid [][]ColoredCell, from, to *Node, color string) []edgePort {
+	if m.EdgeStyle == EdgeOrthogonal {
+		return m.drawOrthogonalEdge(grid, from, to, color)
+	}
+	sx1, sy1, sx2, sy2 := m.edgeEndpoints(grid, from, to)
+
+	// The curve stays inside the box around its control points, so skip it if that's off-screen
+	gridWidth, gridHeight := gridSize(grid)
+	minX, minY, maxX, maxY := newBezier(sx1, sy1, sx2, sy2).bounds()
+	if maxX < 0 || maxY < 0 || minX >= float64(gridWidth) || minY >= float64(gridHeight) {
+		return nil
+	}
+
+	// Draw the curve in the given color (normally the "to" node's color), around other nodes
+	dirX, dirY := m.drawRoute(grid, m.routeEdge(grid, from, to, sx1, sy1, sx2, sy2), color)
+
+	// Cross-links point at their target; parent→child edges stay plain to keep the canvas calm
+	if to.ParentID != from.ID && (dirX != 0 || dirY != 0) {
+		m.drawArrowhead(grid, to, sx2, sy2, dirX, dirY, color)
+	}
+
+	fx, fy, fw, fh := m.nodeScreenRect(grid, from)
+	tx, ty, tw, th := m.nodeScreenRect(grid, to)
+	return []edgePort{borderPort(sx1, sy1, fx, fy, fw, fh), borderPort(sx2, sy2, tx, ty, tw, th)}
+}
+
+// drawOrthogonalEdge draws an edge as right-angled connector: out of the side of the source,
+// one vertical segment midway, into the side of the target. When the target is mostly
+// above or below, it leaves from the bottom or top instead and turns along a middle row.
+func (m Model) drawOrthogonalEdge(grid [][]ColoredCell, from, to *Node, color string) []edgePort {
+	fx, fy, fw, fh := m.nodeScreenRect(grid, from)
+	tx, ty, tw, th := m.nodeScreenRect(grid, to)
+	dx := (tx + tw/2) - (fx + fw/2)
+	dy := (ty + th/2) - (fy + fh/2)
+
+	sx1, sy1, sx2, sy2 := m.edgeEndpoints(grid, from, to)
+	vertical := abs(dy) > abs(dx) && (ty >= fy+fh || ty+th <= fy)
+	if vertical {
+		sx1, sx2 = fx+fw/2, tx+tw/2
+		if dy > 0 {
+			sy1, sy2 = fy+fh, ty-1
+		} else {
+			sy1, sy2 = fy-1, ty+th
 		}
 	}
+
+	gridWidth, gridHeight := gridSize(grid)
+	if max(sx1, sx2) < 0 || max(sy1, sy2) < 0 || min(sx1, sx2) >= gridWidth || min(sy1, sy2) >= gridHeight {
+		return nil
+	}
+
+	ports := []edgePort{borderPort(sx1, sy1, fx, fy, fw, fh), borderPort(sx2, sy2, tx, ty, tw, th)}
+	route := orthogonalRoute(sx1, sy1, sx2, sy2, vertical)
+	dirX, dirY := m.drawSquareRoute(grid, route, color, opposite(ports[0].dir), opposite(ports[1].dir))
+
+	if to.ParentID != from.ID && (dirX != 0 || dirY != 0) {
+		m.drawArrowhead(grid, to, sx2, sy2, dirX, dirY, color)
+	}
+	return ports
 }
 
-// drawEdges renders all edges onto the grid
-func (m Model) drawEdges(grid [][]ColoredCell) {
-	for _, edge := range m.Edges {
-		fromNode := m.Nodes[edge.FromID]
-		toNode := m.Nodes[edge.ToID]
-		if fromNode != nil && toNode != nil {
-			m.drawEdge(grid, fromNode, toNode)
+// drawSquareRoute draws a route made of horizontal and vertical steps, with corner glyphs
+// where it turns. The ends connect back towards the nodes: startBack and endBack are the
+// directions from the first and last cells into their node's border.
+func (m Model) drawSquareRoute(grid [][]ColoredCell, route []cell, color string, startBack, endBack int) (int, int) {
+	towards := func(a, b cell) int {
+		switch {
+		case b.x > a.x:
+			return lineRight
+		case b.x < a.x:
+			return lineLeft
+		case b.y > a.y:
+			return lineDown
+		default:
+			return lineUp
 		}
 	}
+
+	drawn := make(map[cell]bool, len(route))
+	for i, c := range route {
+		lines := 0
+		if i == 0 {
+			lines |= startBack
+		} else {
+			lines |= towards(c, route[i-1])
+		}
+		if i == len(route)-1 {
+			lines |= endBack
+		} else {
+			lines |= towards(c, route[i+1])
+		}
+		glyph, ok := glyphForLines[lines]
+		if !ok {
+			glyph = '─' // Both ends point the same way, as when a route doubles back
+			if lines&(lineUp|lineDown) != 0 {
+				glyph = '│'
+			}
+		}
+		plotRouteCell(grid, c.x, c.y, glyph, color, drawn)
+	}
+
+	if len(route) < 2 {
+		return 0, 0
+	}
+	last, prev := route[len(route)-1], route[len(route)-2]
+	return last.x - prev.x, last.y - prev.y
 }
 
-// drawEdge draws a line between two nodes, connecting at their borders
-func (m Model) drawEdge(grid [][]ColoredCell, from, to *Node) {
+// edgeEndpoints returns the screen cells an edge between two nodes starts and ends at
+func (m Model) edgeEndpoints(grid [][]ColoredCell, from, to *Node) (sx1, sy1, sx2, sy2 int) {
 	// Get center points to determine direction
 	fromCX, fromCY := from.GetCenter()
 	toCX, toCY := to.GetCenter()
 
-	var fx, fy, tx, ty float64
+	// Connection points are the cells just outside the boxes as drawn at this zoom,
+	// so the curve touches the border whatever the scale
+	fx, fy, fw, fh := m.nodeScreenRect(grid, from)
+	tx, ty, tw, th := m.nodeScreenRect(grid, to)
 
-	// Determine connection points based on relative positions
 	// Horizontal connections (most common)
 	if toCX > fromCX { // "to" is to the right of "from"
 		// Connect from right edge of "from" to left edge of "to"
-		fx = from.X + float64(from.Width)
-		fy = fromCY
-		tx = to.X
-		ty = toCY
+		sx1, sy1 = fx+fw, fy+fh/2
+		sx2, sy2 = tx-1, ty+th/2
 	} else if toCX < fromCX { // "to" is to the left of "from"
 		// Connect from left edge of "from" to right edge of "to"
-		fx = from.X
-		fy = fromCY
-		tx = to.X + float64(to.Width)
-		ty = toCY
+		sx1, sy1 = fx-1, fy+fh/2
+		sx2, sy2 = tx+tw, ty+th/2
 	} else { // Vertically aligned
 		if toCY > fromCY { // "to" is below "from"
 			// Connect from bottom of "from" to top of "to"
-			fx = fromCX
-			fy = from.Y + float64(from.Height)
-			tx = toCX
-			ty = to.Y
+			sx1, sy1 = fx+fw/2, fy+fh
+			sx2, sy2 = tx+tw/2, ty-1
 		} else { // "to" is above "from"
 			// Connect from top of "from" to bottom of "to"
-			fx = fromCX
-			fy = from.Y
-			tx = toCX
-			ty = to.Y + float64(to.Height)
+			sx1, sy1 = fx+fw/2, fy-1
+			sx2, sy2 = tx+tw/2, ty+th
 		}
 	}
-
-	// Convert to screen coordinates
-	sx1, sy1 := m.Camera.WorldToScreen(fx, fy, m.Width, m.Height-1)
-	sx2, sy2 := m.Camera.WorldToScreen(tx, ty, m.Width, m.Height-1)
-
-	// Draw line using Bresenham's algorithm with the "to" node's color
-	m.drawLine(grid, sx1, sy1, sx2, sy2, to.Color)
+	return sx1, sy1, sx2, sy2
 }
 
-// drawLine draws a smooth Bezier curve between two points
-func (m Model) drawLine(grid [][]ColoredCell, x1, y1, x2, y2 int, color string) {
-	// Calculate control points for cubic Bezier curve
-	// Place control points horizontally offset for smooth horizontal connections
-	dx := float64(x2 - x1)
-	dy := float64(y2 - y1)
-
-	// Adjust control point distance based on the distance between nodes
-	dist := math.Sqrt(dx*dx + dy*dy)
-	cpOffset := math.Min(dist*0.4, 30.0) // 40% of distance, max 30 units
-
-	// Control points for horizontal flow
-	cp1x := float64(x1) + cpOffset
-	cp1y := float64(y1)
-	cp2x := float64(x2) - cpOffset
-	cp2y := float64(y2)
+// isLinkSource reports whether a node is the source of the link or move being picked
+func (m Model) isLinkSource(id string) bool {
+	return (m.Mode == ModeLink || m.Mode == ModeReparent) && id == m.LinkSourceID
+}
 
-	// If connection is more vertical than horizontal, adjust control points vertically
-	if math.Abs(dy) > math.Abs(dx) {
-		cp1x = float64(x1)
-		cp1y = float64(y1) + cpOffset*math.Copysign(1, dy)
-		cp2x = float64(x2)
-		cp2y = float64(y2) - cpOffset*math.Copysign(1, dy)
+// linkCandidate returns the ID of the node a link would go to, or "?" before one is picked
+func (m Model) linkCandidate() string {
+	if m.Selected == "" || m.Selected == m.LinkSourceID {
+		return "?"
 	}
+	return m.Selected
+}
 
-	// Draw the Bezier curve using parametric equation
-	// Sample enough points for smooth rendering
-	steps := int(dist * 2) // Ensure we have enough resolution
-	if steps < 10 {
-		steps = 10
+// drawLinkPreview draws a dashed straight line from the link source to the current
+// candidate, so the link about to be made is visible before it is confirmed
+func (m Model) drawLinkPreview(grid [][]ColoredCell) {
+	if m.Mode != ModeLink && m.Mode != ModeReparent {
+		return
+	}
+	from, to := m.Nodes[m.LinkSourceID], m.Nodes[m.Selected]
+	if m.Mode == ModeReparent {
+		// The moved node will hang off the candidate parent
+		from, to = to, from
+	}
+	if from == nil || to == nil || from == to {
+		return
 	}
 
-	prevX, prevY := x1, y1
-	for i := 0; i <= steps; i++ {
-		t := float64(i) / float64(steps)
+	x1, y1, x2, y2 := m.edgeEndpoints(grid, from, to)
+	m.drawDashedLine(grid, x1, y1, x2, y2, m.Theme.Link)
+}
 
-		// Cubic Bezier formula: B(t) = (1-t)³P0 + 3(1-t)²tP1 + 3(1-t)t²P2 + t³P3
-		omt := 1 - t
-		omt2 := omt * omt
-		omt3 := omt2 * omt
-		t2 := t * t
-		t3 := t2 * t
+// drawDashedLine draws a dashed straight line between two grid cells
+func (m Model) drawDashedLine(grid [][]ColoredCell, x1, y1, x2, y2 int, color string) {
+	dash := m.getLineChar(x2-x1, y2-y1)
+	switch dash {
+	case '─':
+		dash = '╌'
+	case '│':
+		dash = '╎'
+	}
+
+	// Bresenham, leaving every other cell blank for the dashes
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	stepX, stepY := 1, 1
+	if x1 > x2 {
+		stepX = -1
+	}
+	if y1 > y2 {
+		stepY = -1
+	}
+	err := dx + dy
+	for i := 0; ; i++ {
+		if i%2 == 0 && y1 >= 0 && y1 < len(grid) && x1 >= 0 && x1 < len(grid[0]) {
+			grid[y1][x1] = ColoredCell{Char: dash, Color: color}
+		}
+		if x1 == x2 && y1 == y2 {
+			break
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x1 += stepX
+		}
+		if e2 <= dx {
+			err += dx
+			y1 += stepY
+		}
+	}
+}
 
-		x := omt3*float64(x1) + 3*omt2*t*cp1x + 3*omt*t2*cp2x + t3*float64(x2)
-		y := omt3*float64(y1) + 3*omt2*t*cp1y + 3*omt*t2*cp2y + t3*float64(y2)
+// drawArrowhead draws an arrow just outside the target node's border, pointing the way
+// the edge's last segment travels
+func (m Model) drawArrowhead(grid [][]ColoredCell, to *Node, ex, ey, dirX, dirY int, color string) {
+	left, top, width, height := m.nodeScreenRect(grid, to)
+	right, bottom := left+width, top+height
+
+	var x, y int
+	var ch rune
+	if abs(dirX) >= abs(dirY) {
+		y = ey
+		if dirX > 0 {
+			x, ch = left-1, '▶'
+		} else {
+			x, ch = right, '◀'
+		}
+	} else {
+		x = ex
+		if dirY > 0 {
+			y, ch = top-1, '▼'
+		} else {
+			y, ch = bottom, '▲'
+		}
+	}
 
-		curX, curY := int(math.Round(x)), int(math.Round(y))
+	if y >= 0 && y < len(grid) && x >= 0 && x < len(grid[0]) {
+		grid[y][x] = ColoredCell{Char: ch, Color: color}
+	}
+}
 
-		// Draw line segment from previous point to current point
-		m.drawLineSegment(grid, prevX, prevY, curX, curY, color)
+// drawRoute draws an edge along the cells of its route and returns the direction of its
+// last step, or (0, 0) if nothing was drawn
+func (m Model) drawRoute(grid [][]ColoredCell, route []cell, color string) (int, int) {
+	if len(route) == 0 {
+		return 0, 0
+	}
+	if len(route) == 1 {
+		m.drawLineSegment(grid, route[0].x, route[0].y, route[0].x, route[0].y, color, nil)
+		return 0, 0
+	}
 
-		prevX, prevY = curX, curY
+	// Where segments of the same route meet, the first glyph stays; merging is for crossings
+	drawn := make(map[cell]bool, len(route))
+	dirX, dirY := 0, 0
+	for i := 1; i < len(route); i++ {
+		prev, cur := route[i-1], route[i]
+		m.drawLineSegment(grid, prev.x, prev.y, cur.x, cur.y, color, drawn)
+		dirX, dirY = cur.x-prev.x, cur.y-prev.y
 	}
+	return dirX, dirY
 }
 
 // drawLineSegment draws a small line segment and picks the best character for direction
-func (m Model) drawLineSegment(grid [][]ColoredCell, x1, y1, x2, y2 int, color string) {
+func (m Model) drawLineSegment(grid [][]ColoredCell, x1, y1, x2, y2 int, color string, drawn map[cell]bool) {
 	dx := x2 - x1
 	dy := y2 - y1
 
 	// Plot start point
-	if y1 >= 0 && y1 < len(grid) && x1 >= 0 && x1 < len(grid[0]) {
-		if grid[y1][x1].Char == ' ' {
-			lineChar := m.getLineChar(dx, dy)
-			grid[y1][x1] = ColoredCell{Char: lineChar, Color: color}
-		}
-	}
+	plotRouteCell(grid, x1, y1, m.getLineChar(dx, dy), color, drawn)
 
 	// If points are the same, we're done
 	if x1 == x2 && y1 == y2 {
@@ -349,12 +778,7 @@ func (m Model) drawLineSegment(grid [][]ColoredCell, x1, y1, x2, y2 int, color s
 		}
 
 		// Plot point if within bounds
-		if y1 >= 0 && y1 < len(grid) && x1 >= 0 && x1 < len(grid[0]) {
-			if grid[y1][x1].Char == ' ' {
-				lineChar := m.getLineChar(dx, dy)
-				grid[y1][x1] = ColoredCell{Char: lineChar, Color: color}
-			}
-		}
+		plotRouteCell(grid, x1, y1, m.getLineChar(dx, dy), color, drawn)
 	}
 }
 
@@ -388,91 +812,195 @@ func (m Model) getLineChar(dx, dy int) rune {
 // renderStatusBar creates the status bar at the bottom
 func (m Model) renderStatusBar() string {
 	var modeStr string
+	editing := strings.ReplaceAll(withCursor(m.EditBuffer, m.EditCursor), "\n", "↵")
 	switch m.Mode {
 	case ModeNormal:
 		modeStr = "NORMAL"
+		if m.Count > 0 {
+			modeStr += fmt.Sprintf(" %d", m.Count)
+		}
 	case ModeEdit:
-		modeStr = fmt.Sprintf("EDIT: %s_", m.EditBuffer)
+		modeStr = "EDIT: " + editing
+		if m.EditingNotes {
+			modeStr = "NOTES: " + editing
+		}
 	case ModeLink:
-		modeStr = fmt.Sprintf("LINK: %s → ?", m.LinkSourceID)
+		modeStr = fmt.Sprintf("LINK: %s → %s", m.LinkSourceID, m.linkCandidate())
+	case ModeReparent:
+		modeStr = fmt.Sprintf("REPARENT: %s → %s", m.LinkSourceID, m.linkCandidate())
+	case ModeSearch:
+		modeStr = fmt.Sprintf("/%s_", m.SearchQuery)
+	case ModeEdge:
+		modeStr = "EDGES"
+	case ModeConfirm:
+		modeStr = "CONFIRM"
+	case ModeHint:
+		modeStr = "HINT: " + m.HintInput + "_"
+	case ModeMove:
+		modeStr = m.moveModeStatus()
+	case ModeVisual:
+		modeStr = fmt.Sprintf("VISUAL %d", len(m.SelectedSet))
+	case ModeCommand:
+		modeStr = fmt.Sprintf(":%s_", m.CommandBuffer)
+	}
+
+	// Without color the mode badge needs brackets to stand out
+	if m.NoColor {
+		modeStr = "[" + modeStr + "]"
 	}
-
 	left := fmt.Sprintf(" %s ", modeStr)
 
 	// Context-sensitive key hints based on mode
 	var keyHints string
 	switch m.Mode {
 	case ModeNormal:
-		keyHints = " [i]child [Enter]sibling [e]dit [d]elete | hjkl:move +/-:zoom | [?]help "
+		keyHints = normalKeyHints
 	case ModeEdit:
-		keyHints = " [Enter]save [Esc]cancel "
-	case ModeLink:
-		keyHints = " Select target → [Enter]confirm [Esc]cancel "
+		keyHints = " [Enter]save [Alt+Enter]newline [Esc]cancel "
+	case ModeLink, ModeReparent:
+		keyHints = " ←↑↓→/Tab:target [Enter]confirm [Esc]cancel "
+	case ModeCommand:
+		keyHints = " [Enter]run [Esc]cancel "
+		if m.QuitAfterSave {
+			keyHints = " Save as: [Enter]save and quit [Esc]stay "
+		}
+	case ModeSearch:
+		keyHints = " [Tab]next [Enter]jump [Esc]cancel "
+	case ModeEdge:
+		keyHints = " [Tab]next [x]delete [Esc]done "
+	case ModeHint:
+		keyHints = " Type a label to jump [Esc]cancel "
+	case ModeMove:
+		keyHints = " hjkl:move HJKL:faster [t]subtree on/off [Enter]done "
+	case ModeVisual:
+		keyHints = " ←↑↓→:extend [Space]toggle [x]delete [C]olor [t]ag [m]ove [Esc]done "
 	}
 
 	middle := m.StatusMsg
+	if m.Mode == ModeConfirm {
+		middle = m.ConfirmPrompt
+	} else if m.Mode == ModeSearch {
+		middle = m.searchStatus()
+	} else if m.Mode == ModeEdit {
+		middle = m.editStatus()
+	}
+	errorStatus := m.StatusLevel == StatusError && middle == m.StatusMsg
 
 	// Compact info on the right
-	right := fmt.Sprintf(" %d nodes | %.1fx ",
-		len(m.Nodes), m.Camera.Zoom)
+	filename := m.CurrentFile
+	if filename == "" {
+		filename = "[No Name]"
+	}
+	if m.Dirty {
+		filename += " [+]"
+	}
+	info := func(summary string) string {
+		right := fmt.Sprintf(" %s | %d nodes | %.1fx ", filename, len(m.Nodes), m.Camera.Zoom)
+		if summary != "" {
+			right = fmt.Sprintf(" %s | %s | %d nodes | %.1fx ", filename, summary, len(m.Nodes), m.Camera.Zoom)
+		}
+		if m.TagFilter != "" {
+			right = fmt.Sprintf(" #%s |%s", m.TagFilter, right)
+		}
+		return right
+	}
+	right := info(m.selectionSummary())
 
-	// Calculate spacing
+	// Fit the bar to exactly the terminal width so it never wraps: drop the key hints
+	// first, then the selected node's summary, then shorten the status message, then
+	// the right-hand info, then the mode
 	totalWidth := m.Width
-	leftWidth := lipgloss.Width(left)
-	keyHintsWidth := lipgloss.Width(keyHints)
-	middleWidth := lipgloss.Width(middle)
-	rightWidth := lipgloss.Width(right)
-
-	// Adjust to fit
-	usedWidth := leftWidth + keyHintsWidth + middleWidth + rightWidth
-	spacing := ""
-	if usedWidth < totalWidth {
-		spacing = strings.Repeat(" ", totalWidth-usedWidth)
+	middle = " " + middle
+	width := lipgloss.Width
+	if width(left)+width(keyHints)+width(middle)+width(right) > totalWidth {
+		keyHints = ""
+	}
+	if width(left)+width(middle)+width(right) > totalWidth {
+		right = info("")
+	}
+	if m.Mode == ModeEdit && width(left)+width(middle)+width(right) > totalWidth {
+		// A long edit scrolls inside the badge so the counter stays in view
+		room := totalWidth - width(middle) - width(right) - (width(left) - textWidth(editing))
+		modeStr = strings.Replace(modeStr, editing, scrollToCursor(editing, max(room, 8)), 1)
+		left = fmt.Sprintf(" %s ", modeStr)
+	}
+	if width(left)+width(middle)+width(right) > totalWidth {
+		middle = truncateWidth(middle, max(0, totalWidth-width(left)-width(right)))
+	}
+	if width(left)+width(right) > totalWidth {
+		right = fmt.Sprintf(" %.1fx ", m.Camera.Zoom)
+		if m.Dirty {
+			right = " [+]" + right
+		}
+		right = truncateWidth(right, max(0, totalWidth-width(left)))
+	}
+	if width(left) > totalWidth {
+		if totalWidth < 3 {
+			return strings.Repeat(" ", max(0, totalWidth)) // No room for even a letter of the mode
+		}
+		modeStr = truncateWidth(modeStr, totalWidth-2)
+		left = fmt.Sprintf(" %s ", modeStr)
 	}
+	spacing := strings.Repeat(" ", max(0, totalWidth-width(left)-width(keyHints)-width(middle)-width(right)))
 
 	// Style the status bar with improved visual hierarchy
+	theme := m.Theme
 	statusStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#E0E0E0")).
-		Background(lipgloss.Color("#2A2A2A"))
+		Foreground(lipgloss.Color(theme.Text)).
+		Background(lipgloss.Color(theme.Background))
 
 	modeStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#000000")).
-		Background(lipgloss.Color("#00D787")).
+		Foreground(lipgloss.Color(theme.BadgeText)).
+		Background(lipgloss.Color(theme.Accent)).
 		Bold(true).
 		Padding(0, 1)
 
 	if m.Mode == ModeEdit {
-		modeStyle = modeStyle.
-			Background(lipgloss.Color("#FFB86C")).
-			Foreground(lipgloss.Color("#000000"))
-	} else if m.Mode == ModeLink {
-		modeStyle = modeStyle.
-			Background(lipgloss.Color("#FF79C6")).
-			Foreground(lipgloss.Color("#000000"))
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Edit))
+	} else if m.Mode == ModeLink || m.Mode == ModeReparent || m.Mode == ModeMove {
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Link))
+	} else if m.Mode == ModeEdge {
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Danger))
+	} else if m.Mode == ModeSearch || m.Mode == ModeHint || m.Mode == ModeVisual {
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Search))
+	} else if m.Mode == ModeCommand {
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Command))
+	} else if m.Mode == ModeConfirm {
+		modeStyle = modeStyle.Background(lipgloss.Color(theme.Danger))
 	}
 
 	// Key hints style - subtle but visible
 	keyHintsStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#888888")).
-		Background(lipgloss.Color("#2A2A2A"))
+		Foreground(lipgloss.Color(theme.Hint)).
+		Background(lipgloss.Color(theme.Background))
 
 	// Status message style - highlighted when present
 	middleStyle := statusStyle
-	if m.StatusMsg != "" {
+	if middle != "" {
 		middleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFB86C")).
-			Background(lipgloss.Color("#2A2A2A"))
+			Foreground(lipgloss.Color(theme.Message)).
+			Background(lipgloss.Color(theme.Background))
+	}
+	if errorStatus {
+		middleStyle = middleStyle.Foreground(lipgloss.Color(theme.Danger))
+	}
+	if m.Mode == ModeEdit {
+		// The edit counter stays quiet until the text passes the soft limit
+		middleStyle = keyHintsStyle
+		if m.overSoftLimit() {
+			middleStyle = middleStyle.Foreground(lipgloss.Color(theme.Danger))
+		}
 	}
 
 	// Info style
 	infoStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
-		Background(lipgloss.Color("#2A2A2A"))
+		Foreground(lipgloss.Color(theme.Info)).
+		Background(lipgloss.Color(theme.Background))
 
 	// Enhanced visual separation
 	leftPart := modeStyle.Render(modeStr)
 	keyHintsPart := keyHintsStyle.Render(keyHints)
-	middlePart := middleStyle.Render(" " + middle)
+	middlePart := middleStyle.Render(middle)
 	rightPart := infoStyle.Render(right)
 
 	return leftPart + keyHintsPart + statusStyle.Render(spacing) + middlePart + rightPart
@@ -492,145 +1020,3 @@ func distance(x1, y1, x2, y2 float64) float64 {
 	dy := y2 - y1
 	return math.Sqrt(dx*dx + dy*dy)
 }
-
-// renderHelpOverlay creates a centered help panel with keybindings
-func (m Model) renderHelpOverlay() string {
-	// Define keybinding categories
-	type KeyBinding struct {
-		Key  string
-		Desc string
-	}
-
-	categories := []struct {
-		Title string
-		Keys  []KeyBinding
-	}{
-		{
-			Title: "Navigation",
-			Keys: []KeyBinding{
-				{"h/j/k/l", "Move camera left/down/up/right"},
-				{"H/J/K/L", "Move camera faster"},
-				{"+/-", "Zoom in/out"},
-				{"0", "Reset view to root node"},
-			},
-		},
-		{
-			Title: "Editing",
-			Keys: []KeyBinding{
-				{"i", "Create child node (to the right)"},
-				{"Enter", "Create sibling node (below)"},
-				{"e", "Edit selected node text"},
-				{"d", "Delete selected node"},
-				{"Esc", "Cancel editing"},
-			},
-		},
-		{
-			Title: "Linking",
-			Keys: []KeyBinding{
-				{"l", "Start linking mode"},
-				{"h/j/k/l", "Navigate to target node"},
-				{"Enter", "Confirm link"},
-				{"Esc", "Cancel linking"},
-			},
-		},
-		{
-			Title: "General",
-			Keys: []KeyBinding{
-				{"?", "Toggle this help"},
-				{"Ctrl+S", "Save mindmap"},
-				{"q", "Quit application"},
-			},
-		},
-	}
-
-	// Calculate dimensions for centered overlay
-	maxWidth := 70
-	if maxWidth > m.Width-4 {
-		maxWidth = m.Width - 4
-	}
-
-	// Build help content
-	var lines []string
-
-	// Title
-	titleStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#00D787")).
-		Align(lipgloss.Center)
-
-	lines = append(lines, titleStyle.Render("⌨  Keybindings"))
-	lines = append(lines, "")
-
-	// Category and key styles
-	categoryStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#FFB86C"))
-
-	keyStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FF79C6")).
-		Bold(true)
-
-	descStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#E0E0E0"))
-
-	// Render each category
-	for i, cat := range categories {
-		if i > 0 {
-			lines = append(lines, "")
-		}
-		lines = append(lines, categoryStyle.Render(cat.Title))
-
-		for _, kb := range cat.Keys {
-			line := fmt.Sprintf("  %-15s %s",
-				keyStyle.Render(kb.Key),
-				descStyle.Render(kb.Desc))
-			lines = append(lines, line)
-		}
-	}
-
-	lines = append(lines, "")
-	footerStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#666666")).
-		Align(lipgloss.Center)
-	lines = append(lines, footerStyle.Render("Press ? or Esc to close"))
-
-	// Join all lines
-	content := strings.Join(lines, "\n")
-
-	// Create bordered box for the help content
-	helpBox := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#00D787")).
-		Padding(1, 2).
-		Render(content)
-
-	// Center the box on screen
-	height := strings.Count(helpBox, "\n") + 1
-	verticalPadding := (m.Height - height) / 2
-	horizontalPadding := (m.Width - maxWidth - 4) / 2 // -4 for borders
-
-	if verticalPadding < 0 {
-		verticalPadding = 0
-	}
-	if horizontalPadding < 0 {
-		horizontalPadding = 0
-	}
-
-	// Create semi-transparent background
-	bgStyle := lipgloss.NewStyle().
-		Background(lipgloss.Color("#1A1A1A")).
-		Width(m.Width).
-		Height(m.Height)
-
-	// Position help box
-	positioned := lipgloss.Place(
-		m.Width,
-		m.Height,
-		lipgloss.Center,
-		lipgloss.Center,
-		helpBox,
-		lipgloss.WithWhitespaceChars(" "),
-	)
-
-	return bgStyle.Render(positioned)
-}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// syntheticMap returns a tidy map of n nodes, five children to a parent, in a
+// 200×60 terminal showing its middle at full size
+func syntheticMap(tb testing.TB, n int) Model {
+	m := newTestModel(tb)
+	m.Width, m.Height = 200, 60
+	for i := 1; i < n; i++ {
+		parent := m.Nodes[fmt.Sprint((i-1)/5)]
+		m.addChild(parent, fmt.Sprintf("Node %d with some text", i))
+	}
+	m.AutoLayout()
+	m.finishLayoutAnimation()
+	minX, minY, maxX, maxY, _ := m.nodeBounds()
+	m.Camera = NewCamera()
+	m.Camera.X, m.Camera.Y = (minX+maxX)/2, (minY+maxY)/2
+	return m
+}
+
+// withTrueColor renders in true color for the rest of the test, as a capable terminal would
+func withTrueColor(tb testing.TB) {
+	withColorProfile(tb, termenv.TrueColor)
+}
+
+// writeRowPerCell is how rows were written before runs were coalesced: a new style
+// and escape sequence for every colored cell
+func (m Model) writeRowPerCell(sb *strings.Builder, row []ColoredCell) {
+	for _, cell := range row {
+		if cell.Char == wideContinuation {
+			continue
+		}
+		if cell.Color != "" {
+			sb.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(m.Theme.nodeColor(cell.Color))).Render(string(cell.Char)))
+		} else {
+			sb.WriteRune(cell.Char)
+		}
+	}
+}
+
+func TestWriteRowMatchesPerCell(t *testing.T) {
+	withTrueColor(t)
+	m := syntheticMap(t, 60)
+	grid := newGrid(m.Width, m.canvasHeight())
+	m.drawMap(grid)
+
+	for y, row := range grid {
+		var runs, cells strings.Builder
+		m.writeRow(&runs, row)
+		m.writeRowPerCell(&cells, row)
+		if ansi.Strip(runs.String()) != ansi.Strip(cells.String()) {
+			t.Fatalf("row %d differs:\n%q\n%q", y, ansi.Strip(runs.String()), ansi.Strip(cells.String()))
+		}
+		if len(runs.String()) > len(cells.String()) {
+			t.Errorf("row %d is longer coalesced (%d bytes) than per cell (%d bytes)", y, len(runs.String()), len(cells.String()))
+		}
+	}
+}
+
+// BenchmarkFrame times building a whole frame of a 500-node map, with the grid written
+// out in coalesced runs (View) and one cell at a time as before
+func BenchmarkFrame(b *testing.B) {
+	withTrueColor(b)
+	m := syntheticMap(b, 500)
+
+	b.Run("runs", func(b *testing.B) {
+		for b.Loop() {
+			_ = m.View()
+		}
+	})
+	b.Run("per-cell", func(b *testing.B) {
+		for b.Loop() {
+			grid := newGrid(m.Width, m.canvasHeight())
+			m.drawMap(grid)
+			var sb strings.Builder
+			for _, row := range grid {
+				m.writeRowPerCell(&sb, row)
+				sb.WriteRune('\n')
+			}
+			sb.WriteString(m.renderStatusBar())
+			_ = sb.String()
+		}
+	})
+}
+
+// drawMapUnculled draws every node and edge whether or not it is on screen, the way
+// frames were drawn before culling
+func (m Model) drawMapUnculled(grid [][]ColoredCell) {
+	for _, edge := range m.Edges {
+		from, to := m.Nodes[edge.FromID], m.Nodes[edge.ToID]
+		sx1, sy1, sx2, sy2 := m.edgeEndpoints(grid, from, to)
+		m.drawRoute(grid, newBezier(sx1, sy1, sx2, sy2).cells(), to.Color)
+	}
+	for id, node := range m.Nodes {
+		m.drawNode(grid, node, id == m.Selected)
+	}
+}
+
+func TestDrawMapSkipsOffscreenNodes(t *testing.T) {
+	m := syntheticMap(t, 200)
+	culled := newGrid(m.Width, m.canvasHeight())
+	m.drawMap(culled)
+	unculled := newGrid(m.Width, m.canvasHeight())
+	m.drawMapUnculled(unculled)
+
+	// Edges are routed differently, but every node box on screen must be drawn the same
+	for _, node := range m.Nodes {
+		if !m.nodeOnScreen(node, m.Width, m.canvasHeight()) {
+			continue
+		}
+		x, y, _, _ := m.nodeScreenRect(culled, node)
+		if x >= 0 && y >= 0 && x < m.Width && y < m.canvasHeight() && culled[y][x] != unculled[y][x] {
+			t.Errorf("node %s: corner is %q culled, %q unculled", node.ID, culled[y][x].Char, unculled[y][x].Char)
+		}
+	}
+}
+
+// BenchmarkDrawMap times drawing a 1000-node map, zoomed in on its middle, with
+// off-screen nodes and edges skipped and with everything drawn
+func BenchmarkDrawMap(b *testing.B) {
+	m := syntheticMap(b, 1000)
+
+	b.Run("culled", func(b *testing.B) {
+		for b.Loop() {
+			m.drawMap(newGrid(m.Width, m.canvasHeight()))
+		}
+	})
+	b.Run("unculled", func(b *testing.B) {
+		for b.Loop() {
+			m.drawMapUnculled(newGrid(m.Width, m.canvasHeight()))
+		}
+	})
+}
+
+// withColorProfile renders with the given color profile for the rest of the test
+func withColorProfile(tb testing.TB, profile termenv.Profile) {
+	saved := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(profile)
+	tb.Cleanup(func() { lipgloss.SetColorProfile(saved) })
+}
+
+// colorTestModel returns a small map in every state the screen can show colors in
+func colorTestModel(t *testing.T) Model {
+	m := newTestModel(t)
+	first := addTestChild(&m, "0", "First")
+	addTestChild(&m, first, "Done #tag")
+	addTestChild(&m, "0", "Second")
+	m.Nodes[first].Notes = "Some notes"
+	m.Nodes[first].Task = true
+	m.AddEdge("3", first)
+	m.Selected = first
+	return m
+}
+
+func TestViewWithoutColorHasNoEscapes(t *testing.T) {
+	withColorProfile(t, termenv.Ascii)
+	tests := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"normal", func(m *Model) {}},
+		{"error status", func(m *Model) { m.setStatus(StatusError, "Something failed") }},
+		{"edit", func(m *Model) { m.startEdit("Editing") }},
+		{"visual", func(m *Model) { *m = press(*m, "v", "j") }},
+		{"search", func(m *Model) { *m = press(*m, "/", "F") }},
+		{"link", func(m *Model) { *m = press(*m, "ctrl+k") }},
+		{"minimap and legend", func(m *Model) { m.ShowMinimap, m.ShowLegend = true, true }},
+		{"notes panel", func(m *Model) { m.ShowNotes = true }},
+		{"tag filter", func(m *Model) { m.TagFilter = "tag" }},
+		{"help", func(m *Model) { m.ShowHelp = true }},
+		{"messages", func(m *Model) { m.ShowMessages = true }},
+		{"light theme", func(m *Model) { m.SetTheme(lightTheme) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := colorTestModel(t)
+			m.NoColor = true
+			tt.setup(&m)
+			if view := m.View(); strings.Contains(view, "\x1b[") {
+				t.Errorf("view contains escape sequences:\n%q", view)
+			}
+		})
+	}
+}
+
+func TestViewWithoutColorShowsSelection(t *testing.T) {
+	withColorProfile(t, termenv.Ascii)
+	m := colorTestModel(t)
+	m.NoColor = true
+	m.Camera.X, m.Camera.Y = m.Nodes["0"].GetCenter()
+	m.Camera.TargetX, m.Camera.TargetY = m.Camera.X, m.Camera.Y
+
+	view := m.View()
+	if strings.Count(view, "━") == 0 || !strings.Contains(view, "[NORMAL]") {
+		t.Errorf("selection or mode badge isn't marked without color:\n%s", view)
+	}
+}
+
+func TestViewDowngradesTrueColor(t *testing.T) {
+	for _, profile := range []termenv.Profile{termenv.ANSI256, termenv.ANSI} {
+		withColorProfile(t, profile)
+		m := colorTestModel(t)
+		m.SetTheme(m.Theme) // Styles are cached per profile
+		view := m.View()
+		if !strings.Contains(view, "\x1b[") {
+			t.Errorf("profile %v: view has no color at all", profile)
+		}
+		if strings.Contains(view, "38;2;") {
+			t.Errorf("profile %v: view uses true color", profile)
+		}
+	}
+}
+
+func TestViewAtEverySize(t *testing.T) {
+	overlays := []struct {
+		name  string
+		setup func(m *Model)
+	}{
+		{"map", func(m *Model) {}},
+		{"minimap, legend and notes", func(m *Model) { m.ShowMinimap, m.ShowLegend, m.ShowNotes = true, true, true }},
+		{"help", func(m *Model) { m.ShowHelp = true }},
+		{"stats", func(m *Model) { m.ShowStats = true }},
+		{"messages", func(m *Model) { m.ShowMessages = true }},
+		{"edit", func(m *Model) { m.startEdit("Editing a node") }},
+		{"zoomed in", func(m *Model) { m.Camera.Zoom = 4 }},
+	}
+	for _, overlay := range overlays {
+		t.Run(overlay.name, func(t *testing.T) {
+			for width := 0; width <= 60; width += 3 {
+				for height := 0; height <= 16; height++ {
+					m := colorTestModel(t)
+					m.NoColor = true
+					overlay.setup(&m)
+					m.Width, m.Height = width, height
+
+					view := m.View() // Mustn't panic
+					lines := strings.Split(view, "\n")
+					if view != "" && len(lines) > max(height, 1) {
+						t.Errorf("%d×%d: view is %d lines tall", width, height, len(lines))
+					}
+					for _, line := range lines {
+						if w := lipgloss.Width(line); w > width {
+							t.Errorf("%d×%d: line %q is %d wide", width, height, line, w)
+							break
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDrawNodeClipsToGrid(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int // Node size in world units
+		zoom          float64
+		sx, sy        int // Where the node's top-left corner lands on the grid
+		want          []string
+	}{
+		{
+			name: "fits", width: 6, height: 3, zoom: 1, sx: 1, sy: 0,
+			want: []string{" ╭────╮ ", " │ Hi │ ", " ╰────╯ "},
+		},
+		{
+			name: "no room for text", width: 4, height: 3, zoom: 1, sx: 0, sy: 0,
+			want: []string{"╭──╮    ", "│  │    ", "╰──╯    "},
+		},
+		{
+			name: "narrower than its padding", width: 3, height: 3, zoom: 1, sx: 0, sy: 0,
+			want: []string{"╭─╮     ", "│ │     ", "╰─╯     "},
+		},
+		{
+			name: "squashed to its borders", width: 10, height: 4, zoom: 0.6, sx: 0, sy: 0,
+			want: []string{"╭────╮  ", "╰────╯  ", "        "},
+		},
+		{
+			name: "too small for a box", width: 6, height: 3, zoom: 0.3, sx: 2, sy: 1,
+			want: []string{"        ", "  ●     ", "        "},
+		},
+		{
+			name: "giant, bottom-right corner on the grid", width: 6, height: 3, zoom: 4, sx: -20, sy: -10,
+			want: []string{"   │    ", "───╯    ", "        "},
+		},
+		{
+			name: "giant, bottom-left corner on the grid", width: 6, height: 3, zoom: 4, sx: 5, sy: -9,
+			want: []string{"     │  ", "     │  ", "     ╰──"},
+		},
+		{
+			name: "giant, top-left corner on the grid", width: 6, height: 3, zoom: 4, sx: 2, sy: 1,
+			want: []string{"        ", "  ╭─────", "  │ Hi  "},
+		},
+		{
+			name: "giant, covering the whole grid", width: 6, height: 3, zoom: 4, sx: -8, sy: -4,
+			want: []string{"        ", "        ", "        "},
+		},
+		{
+			name: "giant, far off to the upper left", width: 6, height: 3, zoom: 4, sx: -1000, sy: -1000,
+			want: []string{"        ", "        ", "        "},
+		},
+		{
+			name: "giant, far off to the lower right", width: 6, height: 3, zoom: 4, sx: 1000, sy: 1000,
+			want: []string{"        ", "        ", "        "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			gridWidth, gridHeight := len([]rune(tt.want[0])), len(tt.want)
+			m.Camera.Zoom = tt.zoom
+			m.TextAlign = TextAlignLeft
+			node := NewNode("1", "Hi", 0, 0, m.WrapWidth)
+			node.Width, node.Height = tt.width, tt.height
+			node.X = (float64(tt.sx) - float64(gridWidth)/2) / tt.zoom
+			node.Y = (float64(tt.sy) - float64(gridHeight)/2) / tt.zoom
+
+			grid := newGrid(gridWidth, gridHeight)
+			m.drawNode(grid, node, false)
+			if got := gridLines(grid); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDrawNodeOnEmptyGrids(t *testing.T) {
+	m := newTestModel(t)
+	node := NewNode("1", "Hi", 0, 0, m.WrapWidth)
+	for _, zoom := range []float64{0.1, 1, 4} {
+		m.Camera.Zoom = zoom
+		for _, grid := range [][][]ColoredCell{nil, newGrid(0, 0), newGrid(0, 3), newGrid(1, 1)} {
+			m.drawNode(grid, node, true) // Mustn't panic
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RepeatKind is a change the '.' key can make again
+type RepeatKind int
+
+const (
+	RepeatNone RepeatKind = iota
+	RepeatCreate
+	RepeatEdit
+	RepeatDelete
+	RepeatSplice
+	RepeatDuplicate
+	RepeatRecolor
+	RepeatToggleDone
+	RepeatTags
+	RepeatSort
+	RepeatMoveSibling
+)
+
+// LastAction is the last repeatable change and what it takes to make it again.
+// Only the fields its kind uses are set.
+type LastAction struct {
+	Kind    RepeatKind
+	Create  CreateKind // RepeatCreate: child, sibling or parent
+	Text    string     // RepeatCreate and RepeatEdit: the node's text
+	Subtree bool       // RepeatDuplicate: copy the whole subtree
+	Color   string     // RepeatRecolor
+	Tags    []string   // RepeatTags
+	Order   string     // RepeatSort: "a", "r" or "c"
+	Dir     int        // RepeatMoveSibling: -1 for up, 1 for down
+}
+
+// remember records a change for '.' to repeat. Replaying it records it again, which keeps it.
+func (m *Model) remember(action LastAction) {
+	m.LastAction = action
+}
+
+// describe says what the action does, for the status bar
+func (a LastAction) describe() string {
+	switch a.Kind {
+	case RepeatCreate:
+		kind := "child"
+		switch a.Create {
+		case CreateSibling:
+			kind = "sibling"
+		case CreateParent:
+			kind = "parent"
+		}
+		return fmt.Sprintf("create %s %q", kind, truncateWidth(singleLine(a.Text), 20))
+	case RepeatEdit:
+		return fmt.Sprintf("set text %q", truncateWidth(singleLine(a.Text), 20))
+	case RepeatDelete:
+		return "delete"
+	case RepeatSplice:
+		return "splice"
+	case RepeatDuplicate:
+		if a.Subtree {
+			return "duplicate subtree"
+		}
+		return "duplicate"
+	case RepeatRecolor:
+		return "color " + a.Color
+	case RepeatToggleDone:
+		return "toggle done"
+	case RepeatTags:
+		return "tag " + strings.Join(a.Tags, " ")
+	case RepeatSort:
+		return "sort children " + childOrders[a.Order]
+	case RepeatMoveSibling:
+		if a.Dir < 0 {
+			return "move up"
+		}
+		return "move down"
+	}
+	return ""
+}
+
+// RepeatLastAction makes the last repeatable change again on the current selection
+func (m *Model) RepeatLastAction() {
+	action := m.LastAction
+	if action.Kind == RepeatNone {
+		m.setStatus(StatusWarn, "Nothing to repeat")
+		return
+	}
+	node := m.GetSelectedNode()
+	if node == nil && action.Kind != RepeatCreate {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+
+	switch action.Kind {
+	case RepeatCreate:
+		if action.Create == CreateParent && (node == nil || node.ID == "0") {
+			m.setStatus(StatusWarn, "Cannot insert above the root node")
+			return
+		}
+		m.applyCreate(m.planCreate(action.Create), action.Text)
+	case RepeatEdit:
+		m.setText(node, action.Text)
+	case RepeatDelete:
+		m.requestDelete(node)
+		if m.Mode == ModeConfirm {
+			return // The prompt says what is about to happen
+		}
+	case RepeatSplice:
+		m.SpliceNode(node.ID)
+	case RepeatDuplicate:
+		m.DuplicateNode(node.ID, action.Subtree)
+	case RepeatRecolor:
+		m.recolor([]string{node.ID}, action.Color)
+	case RepeatToggleDone:
+		m.ToggleDone()
+	case RepeatTags:
+		m.ToggleTags(action.Tags)
+	case RepeatSort:
+		m.SortChildren(action.Order)
+	case RepeatMoveSibling:
+		m.MoveSibling(action.Dir)
+	}
+	m.amendStatus(fmt.Sprintf("Repeated %s: %s", action.describe(), m.StatusMsg))
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// substituteUsage explains the :s command
+const substituteUsage = "Usage: :s/old/new/[rib] (r: regex, i: ignore case, b: selected branch only)"
+
+// substituteDelimiters are the characters that can separate the parts of :s
+const substituteDelimiters = `/#|,:;!@%+=~`
+
+// isSubstitute reports whether a command line is a :s command, which is parsed whole
+// since its text may hold spaces
+func isSubstitute(line string) bool {
+	return len(line) >= 2 && line[0] == 's' && strings.ContainsRune(substituteDelimiters, rune(line[1]))
+}
+
+// Replacement is a search and replace waiting for confirmation
+type Replacement struct {
+	Pattern *regexp.Regexp
+	With    string
+	Regex   bool     // With may refer to groups as $1; otherwise it is inserted as is
+	IDs     []string // Nodes whose text changes
+}
+
+// apply returns text with every match replaced
+func (r Replacement) apply(text string) string {
+	if r.Regex {
+		return r.Pattern.ReplaceAllString(text, r.With)
+	}
+	return r.Pattern.ReplaceAllLiteralString(text, r.With)
+}
+
+// parseSubstitute splits "s/old/new/flags" into its parts. Any of substituteDelimiters
+// can stand in for '/', and a backslash before it makes it part of old or new. The last delimiter may
+// be left off, as in "s/old/new".
+func parseSubstitute(line string) (old, with, flags string, err error) {
+	if len(line) < 2 || line[0] != 's' {
+		return "", "", "", errors.New(substituteUsage)
+	}
+	delim := rune(line[1])
+	if !strings.ContainsRune(substituteDelimiters, delim) {
+		return "", "", "", errors.New(substituteUsage)
+	}
+
+	var parts []string
+	var part strings.Builder
+	escaped := false
+	for _, r := range line[2:] {
+		switch {
+		case escaped && r != delim:
+			part.WriteRune('\\') // Kept for the regex, as in \d
+			part.WriteRune(r)
+			escaped = false
+		case escaped:
+			part.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == delim:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	if escaped {
+		part.WriteRune('\\')
+	}
+	parts = append(parts, part.String())
+
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+		return "", "", "", errors.New(substituteUsage)
+	}
+	if len(parts) == 3 {
+		flags = parts[2]
+	}
+	return parts[0], parts[1], flags, nil
+}
+
+// commandSubstitute handles ":s/old/new/[flags]", replacing text in every node (or, with
+// the b flag, in the selected branch). It asks first, saying how many nodes would change.
+func (m *Model) commandSubstitute(line string) {
+	old, with, flags, err := parseSubstitute(line)
+	if err != nil {
+		m.setStatus(StatusWarn, err.Error())
+		return
+	}
+
+	r := Replacement{With: with, Regex: strings.Contains(flags, "r")}
+	expr := old
+	if !r.Regex {
+		expr = regexp.QuoteMeta(old)
+	}
+	var nodes []*Node
+	for _, flag := range flags {
+		switch flag {
+		case 'r':
+		case 'i':
+			expr = "(?i)" + expr
+		case 'b':
+			node := m.GetSelectedNode()
+			if node == nil {
+				m.setStatus(StatusWarn, "No node selected")
+				return
+			}
+			nodes = append([]*Node{node}, m.GetDescendantsOf(node.ID)...)
+		default:
+			m.setStatus(StatusWarn, fmt.Sprintf("Unknown flag %q. %s", flag, substituteUsage))
+			return
+		}
+	}
+	if r.Pattern, err = regexp.Compile(expr); err != nil {
+		m.setStatus(StatusWarn, fmt.Sprintf("Bad pattern: %v", err))
+		return
+	}
+
+	if nodes == nil {
+		for _, node := range m.Nodes {
+			nodes = append(nodes, node)
+		}
+	}
+	sortNodes(nodes)
+	for _, node := range nodes {
+		if r.apply(node.Text) != node.Text {
+			r.IDs = append(r.IDs, node.ID)
+		}
+	}
+	if len(r.IDs) == 0 {
+		m.setStatus(StatusWarn, fmt.Sprintf("No nodes match %q", old))
+		return
+	}
+
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmReplace
+	m.PendingReplace = r
+	m.ConfirmPrompt = fmt.Sprintf("Replace in %d nodes? [y/N]", len(r.IDs))
+}
+
+// applyReplacement makes a confirmed replacement as one undo step
+func (m *Model) applyReplacement(r Replacement) {
+	m.pushUndo(fmt.Sprintf("replace in %d nodes", len(r.IDs)))
+	for _, id := range r.IDs {
+		if node := m.Nodes[id]; node != nil {
+			node.Text = r.apply(node.Text)
+			node.UpdateSize(m.WrapWidth)
+		}
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Replaced text in %d nodes", len(r.IDs)))
+}
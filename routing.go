@@ -0,0 +1,222 @@
+package main
+
+import "math"
+
+// EdgeOrthogonal is the edge style drawn with right-angled connectors instead of curves
+const EdgeOrthogonal = "orthogonal"
+
+// ToggleEdgeStyle switches between curved and right-angled edges
+func (m *Model) ToggleEdgeStyle() {
+	if m.EdgeStyle == EdgeOrthogonal {
+		m.EdgeStyle = ""
+		m.setStatus(StatusInfo, "Curved edges")
+	} else {
+		m.EdgeStyle = EdgeOrthogonal
+		m.setStatus(StatusInfo, "Right-angled edges")
+	}
+	m.Dirty = true // The style is saved with the map
+}
+
+// screenRect is a rectangle of grid cells
+type screenRect struct {
+	x, y, w, h int
+}
+
+// contains reports whether a cell lies inside the rectangle
+func (r screenRect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h
+}
+
+// overlaps reports whether two rectangles share any cell
+func (r screenRect) overlaps(o screenRect) bool {
+	return r.x < o.x+o.w && o.x < r.x+r.w && r.y < o.y+o.h && o.y < r.y+r.h
+}
+
+// cell is a grid position along an edge's route
+type cell struct {
+	x, y int
+}
+
+// bezier is a cubic Bezier curve in screen space
+type bezier struct {
+	x1, y1, cp1x, cp1y, cp2x, cp2y, x2, y2 float64
+	bulge                                  float64 // How far bend pulled the control points aside
+}
+
+// newBezier returns the curve drawn between two points: it leaves and arrives
+// horizontally, or vertically when the points are further apart that way
+func newBezier(x1, y1, x2, y2 int) bezier {
+	dx := float64(x2 - x1)
+	dy := float64(y2 - y1)
+
+	// Adjust control point distance based on the distance between nodes
+	dist := math.Sqrt(dx*dx + dy*dy)
+	cpOffset := math.Min(dist*0.4, 30.0) // 40% of distance, max 30 units
+
+	b := bezier{x1: float64(x1), y1: float64(y1), x2: float64(x2), y2: float64(y2)}
+	if b.vertical() {
+		b.cp1x, b.cp1y = b.x1, b.y1+cpOffset*math.Copysign(1, dy)
+		b.cp2x, b.cp2y = b.x2, b.y2-cpOffset*math.Copysign(1, dy)
+	} else {
+		b.cp1x, b.cp1y = b.x1+cpOffset, b.y1
+		b.cp2x, b.cp2y = b.x2-cpOffset, b.y2
+	}
+	return b
+}
+
+// vertical reports whether the curve runs more up and down than sideways
+func (b bezier) vertical() bool {
+	return math.Abs(b.y2-b.y1) > math.Abs(b.x2-b.x1)
+}
+
+// bounds returns a box the curve stays inside: the box around its control points
+func (b bezier) bounds() (minX, minY, maxX, maxY float64) {
+	minX = math.Min(math.Min(b.x1, b.x2), math.Min(b.cp1x, b.cp2x))
+	maxX = math.Max(math.Max(b.x1, b.x2), math.Max(b.cp1x, b.cp2x))
+	minY = math.Min(math.Min(b.y1, b.y2), math.Min(b.cp1y, b.cp2y))
+	maxY = math.Max(math.Max(b.y1, b.y2), math.Max(b.cp1y, b.cp2y))
+	return minX, minY, maxX, maxY
+}
+
+// cells samples the curve at the points its segments are drawn between
+func (b bezier) cells() []cell {
+	// Bent curves travel further than the distance between their ends
+	dist := math.Hypot(b.x2-b.x1, b.y2-b.y1) + b.bulge
+	steps := max(int(dist*2), 10)
+
+	cells := make([]cell, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+
+		// Cubic Bezier formula: B(t) = (1-t)³P0 + 3(1-t)²tP1 + 3(1-t)t²P2 + t³P3
+		omt := 1 - t
+		a, c1, c2, d := omt*omt*omt, 3*omt*omt*t, 3*omt*t*t, t*t*t
+		x := a*b.x1 + c1*b.cp1x + c2*b.cp2x + d*b.x2
+		y := a*b.y1 + c1*b.cp1y + c2*b.cp2y + d*b.y2
+		next := cell{int(math.Round(x)), int(math.Round(y))}
+		if len(cells) == 0 || cells[len(cells)-1] != next {
+			cells = append(cells, next)
+		}
+	}
+	return cells
+}
+
+// bend returns the curve with both control points moved sideways so its middle
+// passes through the given line: a row for horizontal curves, a column for vertical ones
+func (b bezier) bend(through float64) bezier {
+	// At t=0.5 the curve is an eighth of each end plus three eighths of each control point
+	if b.vertical() {
+		cp := (through - (b.x1+b.x2)/8) / 0.75
+		b.bulge = math.Abs(cp - (b.x1+b.x2)/2)
+		b.cp1x, b.cp2x = cp, cp
+	} else {
+		cp := (through - (b.y1+b.y2)/8) / 0.75
+		b.bulge = math.Abs(cp - (b.y1+b.y2)/2)
+		b.cp1y, b.cp2y = cp, cp
+	}
+	return b
+}
+
+// routeEdge returns the cells an edge between two nodes passes through, from (x1, y1) to
+// (x2, y2). The usual curve is bent above or below nodes in its way; if that doesn't clear
+// them, the edge takes a three-segment right-angled route instead.
+func (m Model) routeEdge(grid [][]ColoredCell, from, to *Node, x1, y1, x2, y2 int) []cell {
+	curve := newBezier(x1, y1, x2, y2)
+	route := curve.cells()
+	obstacles := m.edgeObstacles(grid, from, to, curve)
+	if !routeBlocked(route, obstacles) {
+		return route
+	}
+
+	// Bend to just past the obstacles on the nearer side first
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, r := range obstacles {
+		if curve.vertical() {
+			low, high = math.Min(low, float64(r.x-1)), math.Max(high, float64(r.x+r.w))
+		} else {
+			low, high = math.Min(low, float64(r.y-1)), math.Max(high, float64(r.y+r.h))
+		}
+	}
+	middle := (curve.y1 + curve.y2) / 2
+	if curve.vertical() {
+		middle = (curve.x1 + curve.x2) / 2
+	}
+	sides := []float64{low, high}
+	if high-middle < middle-low {
+		sides = []float64{high, low}
+	}
+	for _, side := range sides {
+		bent := curve.bend(side)
+		route := bent.cells()
+		if !routeBlocked(route, m.edgeObstacles(grid, from, to, bent)) {
+			return route
+		}
+	}
+
+	return orthogonalRoute(x1, y1, x2, y2, curve.vertical())
+}
+
+// orthogonalRoute returns a right-angled route that turns halfway between the ends
+func orthogonalRoute(x1, y1, x2, y2 int, vertical bool) []cell {
+	corners := []cell{{x1, y1}, {(x1 + x2) / 2, y1}, {(x1 + x2) / 2, y2}, {x2, y2}}
+	if vertical {
+		corners = []cell{{x1, y1}, {x1, (y1 + y2) / 2}, {x2, (y1 + y2) / 2}, {x2, y2}}
+	}
+
+	route := []cell{corners[0]}
+	for _, corner := range corners[1:] {
+		last := route[len(route)-1]
+		for last != corner {
+			switch {
+			case last.x < corner.x:
+				last.x++
+			case last.x > corner.x:
+				last.x--
+			case last.y < corner.y:
+				last.y++
+			default:
+				last.y--
+			}
+			route = append(route, last)
+		}
+	}
+	return route
+}
+
+// edgeObstacles returns the on-screen boxes of nodes, other than the edge's own ends, that
+// could be in a curve's way. Only nodes near the curve are looked at, and only on screen,
+// since cells off the grid aren't drawn.
+func (m Model) edgeObstacles(grid [][]ColoredCell, from, to *Node, curve bezier) []screenRect {
+	gridWidth, gridHeight := gridSize(grid)
+	minX, minY, maxX, maxY := curve.bounds()
+	minX, minY = math.Max(minX, 0), math.Max(minY, 0)
+	maxX, maxY = math.Min(maxX, float64(gridWidth-1)), math.Min(maxY, float64(gridHeight-1))
+	if minX > maxX || minY > maxY {
+		return nil
+	}
+
+	worldMinX, worldMinY := m.Camera.ScreenToWorld(int(minX), int(minY), gridWidth, gridHeight)
+	worldMaxX, worldMaxY := m.Camera.ScreenToWorld(int(maxX)+1, int(maxY)+1, gridWidth, gridHeight)
+
+	var obstacles []screenRect
+	for _, node := range m.nodesInRect(worldMinX, worldMinY, worldMaxX, worldMaxY) {
+		if node == from || node == to || node.ID == from.ID || node.ID == to.ID {
+			continue
+		}
+		x, y, w, h := m.nodeScreenRect(grid, node)
+		obstacles = append(obstacles, screenRect{x, y, w, h})
+	}
+	return obstacles
+}
+
+// routeBlocked reports whether a route passes through any of the boxes
+func routeBlocked(route []cell, obstacles []screenRect) bool {
+	for _, r := range obstacles {
+		for _, c := range route {
+			if r.contains(c.x, c.y) {
+				return true
+			}
+		}
+	}
+	return false
+}
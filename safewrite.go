@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BackupError reports that the previous version couldn't be backed up.
+// The save itself went through.
+type BackupError struct {
+	Err error
+}
+
+func (e *BackupError) Error() string {
+	return fmt.Sprintf("backup failed but save succeeded: %v", e.Err)
+}
+
+func (e *BackupError) Unwrap() error {
+	return e.Err
+}
+
+// isBackupError reports whether err only concerns the backup, so the save succeeded
+func isBackupError(err error) bool {
+	var backupErr *BackupError
+	return errors.As(err, &backupErr)
+}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// the data goes to a temp file in the same directory, is synced, then renamed over path.
+// With backup set, the previous contents are first copied to path + ".bak". An existing
+// file keeps its permissions, and the backup gets the same ones.
+func writeFileAtomic(path string, data []byte, backup bool) error {
+	mode := existingMode(path)
+	var backupErr error
+	if backup {
+		backupErr = backupFile(path, mode)
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("save failed: %w", err)
+	}
+	tmpName := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpName)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return fmt.Errorf("save failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("save failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("save failed: %w", err)
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("save failed: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("save failed: %w", err)
+	}
+
+	// Make the rename itself durable; not all platforms support syncing a directory
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	if backupErr != nil {
+		return &BackupError{Err: backupErr}
+	}
+	return nil
+}
+
+// existingMode returns the permissions of the file at path, or 0644 for a new file
+func existingMode(path string) os.FileMode {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0644
+	}
+	return info.Mode().Perm()
+}
+
+// backupFile copies path to path + ".bak" with the given permissions. A missing
+// original isn't an error.
+func backupFile(path string, mode os.FileMode) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".bak", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	// An existing backup keeps its old permissions unless they're changed before writing
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dirEntries returns the names of the files in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomicReplacesTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		backup    bool
+		wantFiles []string
+	}{
+		{"without backup", false, []string{"map.json"}},
+		{"with backup", true, []string{"map.json", "map.json.bak"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "map.json")
+			if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := writeFileAtomic(path, []byte("new"), tt.backup); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != "new" {
+				t.Errorf("target holds %q, want %q", data, "new")
+			}
+			if tt.backup {
+				if data, _ := os.ReadFile(path + ".bak"); string(data) != "old" {
+					t.Errorf("backup holds %q, want %q", data, "old")
+				}
+			}
+			// No temp file may be left behind
+			if got := dirEntries(t, dir); strings.Join(got, " ") != strings.Join(tt.wantFiles, " ") {
+				t.Errorf("directory holds %v, want %v", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicNewFileNeedsNoBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	if err := writeFileAtomic(path, []byte("new"), true); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("backup of a file that didn't exist was made: %v", err)
+	}
+}
+
+func TestWriteFileAtomicUnwritableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	err := writeFileAtomic(path, []byte("new"), false)
+	if err == nil || isBackupError(err) || !strings.HasPrefix(err.Error(), "save failed") {
+		t.Fatalf("got error %v, want a save failure", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Errorf("target holds %q after a failed save, want it untouched", data)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "map.json")
+	err := writeFileAtomic(path, []byte("new"), true)
+	if err == nil || isBackupError(err) || !strings.HasPrefix(err.Error(), "save failed") {
+		t.Errorf("got error %v, want a save failure", err)
+	}
+}
+
+func TestWriteFileAtomicBackupFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A directory where the backup should go makes the backup fail
+	if err := os.Mkdir(path+".bak", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writeFileAtomic(path, []byte("new"), true)
+	if !isBackupError(err) || !strings.HasPrefix(err.Error(), "backup failed but save succeeded") {
+		t.Fatalf("got error %v, want a backup failure", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("target holds %q, want the save to have gone through", data)
+	}
+}
+
+func TestSaveAsReportsBackupFailure(t *testing.T) {
+	m := newTestModel(t)
+	m.Config.Backup = true
+	path := filepath.Join(t.TempDir(), "map.json")
+	m.CurrentFile = path
+	m.saveAs("", false)
+	if err := os.Mkdir(path+".bak", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Dirty = true
+	m.saveAs("", false)
+	if m.Dirty || m.StatusLevel != StatusWarn || !strings.Contains(m.StatusMsg, "backup failed but save succeeded") {
+		t.Errorf("got dirty %v and status %q (level %v), want saved with a backup warning", m.Dirty, m.StatusMsg, m.StatusLevel)
+	}
+}
+
+func TestWriteFileAtomicKeepsPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing os.FileMode // 0 for a new file
+		want     os.FileMode
+	}{
+		{"new file", 0, 0644},
+		{"private file", 0600, 0600},
+		{"shared file", 0664, 0664},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "map.json")
+			if tt.existing != 0 {
+				if err := os.WriteFile(path, []byte("old"), tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				// The umask may have masked some bits off
+				if err := os.Chmod(path, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+				// A stale backup with looser permissions gets the target's
+				if err := os.WriteFile(path+".bak", []byte("older"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writeFileAtomic(path, []byte("new"), true); err != nil {
+				t.Fatal(err)
+			}
+
+			files := []string{path}
+			if tt.existing != 0 {
+				files = append(files, path+".bak")
+			}
+			for _, file := range files {
+				info, err := os.Stat(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != tt.want {
+					t.Errorf("%s has mode %v, want %v", filepath.Base(file), got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// updateSearchMatches recomputes the nodes matching SearchQuery, ordered top to bottom.
+// Matching is a case-insensitive substring test on the node text.
+func (m *Model) updateSearchMatches() {
+	m.SearchMatches = nil
+	m.SearchIndex = 0
+	if m.SearchQuery == "" {
+		return
+	}
+
+	query := strings.ToLower(m.SearchQuery)
+	matches := make([]*Node, 0)
+	for _, node := range m.Nodes {
+		if strings.Contains(strings.ToLower(node.Text), query) {
+			matches = append(matches, node)
+		}
+	}
+	sortNodes(matches)
+
+	for _, node := range matches {
+		m.SearchMatches = append(m.SearchMatches, node.ID)
+	}
+}
+
+// currentSearchMatch returns the ID of the highlighted match, or "" if there is none
+func (m *Model) currentSearchMatch() string {
+	if len(m.SearchMatches) == 0 {
+		return ""
+	}
+	return m.SearchMatches[m.SearchIndex]
+}
+
+// isSearchMatch reports whether a node matches the active search
+func (m *Model) isSearchMatch(id string) bool {
+	for _, matchID := range m.SearchMatches {
+		if matchID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// jumpToSearchMatch selects and centers the match offset steps away from the current one
+func (m *Model) jumpToSearchMatch(offset int) {
+	if m.SearchQuery == "" {
+		m.setStatus(StatusWarn, "No active search")
+		return
+	}
+
+	// Nodes may have changed since the search was run
+	current := m.currentSearchMatch()
+	m.updateSearchMatches()
+	if len(m.SearchMatches) == 0 {
+		m.setStatus(StatusWarn, fmt.Sprintf("No matches for %q", m.SearchQuery))
+		return
+	}
+	for i, id := range m.SearchMatches {
+		if id == current {
+			m.SearchIndex = i
+		}
+	}
+
+	n := len(m.SearchMatches)
+	m.SearchIndex = ((m.SearchIndex+offset)%n + n) % n
+	m.Selected = m.currentSearchMatch()
+	m.expandTo(m.Selected)
+	m.centerOn(m.Nodes[m.Selected])
+	m.setPrompt(m.searchStatus())
+}
+
+// searchStatus describes the search position, e.g. "3/7 matches"
+func (m *Model) searchStatus() string {
+	if len(m.SearchMatches) == 0 {
+		if m.SearchQuery == "" {
+			return ""
+		}
+		return "No matches"
+	}
+	return fmt.Sprintf("%d/%d matches", m.SearchIndex+1, len(m.SearchMatches))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session is what's remembered between runs: the last map and where you were in it
+type Session struct {
+	File     string  `json:"file"` // Absolute path of the map
+	CameraX  float64 `json:"camera_x"`
+	CameraY  float64 `json:"camera_y"`
+	Zoom     float64 `json:"zoom"`
+	Selected string  `json:"selected"`
+}
+
+// sessionPath returns where the session is kept
+func sessionPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "session.json"), nil
+}
+
+// LoadSession reads the last session. ok is false if there is none, it can't be read,
+// or its map has since been deleted.
+func LoadSession() (session Session, ok bool) {
+	path, err := sessionPath()
+	if err != nil {
+		return Session{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Session{}, false
+	}
+	if err := json.Unmarshal(data, &session); err != nil || session.File == "" {
+		return Session{}, false
+	}
+	if _, err := os.Stat(session.File); err != nil {
+		return Session{}, false
+	}
+	return session, true
+}
+
+// saveSession records the current map, camera and selection for the next run.
+// Maps without a file name aren't remembered.
+func (m *Model) saveSession() error {
+	if m.CurrentFile == "" {
+		return nil
+	}
+	file, err := filepath.Abs(m.CurrentFile)
+	if err != nil {
+		return err
+	}
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(Session{
+		File:     file,
+		CameraX:  m.Camera.TargetX,
+		CameraY:  m.Camera.TargetY,
+		Zoom:     m.Camera.TargetZoom,
+		Selected: m.Selected,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, false)
+}
+
+// resumeSession opens the session's map and puts the camera and selection back
+func (m *Model) resumeSession(session Session) error {
+	if err := m.OpenFile(session.File); err != nil {
+		return err
+	}
+	m.Camera.X, m.Camera.Y = session.CameraX, session.CameraY
+	m.Camera.TargetX, m.Camera.TargetY = session.CameraX, session.CameraY
+	if session.Zoom > 0 {
+		m.Camera.Zoom = math.Max(minZoom, math.Min(maxZoom, session.Zoom))
+		m.Camera.TargetZoom = m.Camera.Zoom
+	}
+	if m.Nodes[session.Selected] != nil {
+		m.Selected = session.Selected
+	}
+	m.rememberFile()
+	m.setStatus(m.loadStatusLevel(), fmt.Sprintf("Resumed %s", session.File)+m.loadWarningSuffix())
+	return nil
+}
+
+// startWithoutFile decides what to open when no map was named on the command line: the
+// last session (resumed, or offered when resume_session is off), otherwise the recent
+// files picker. Crash recovery is offered once it's settled which map is open.
+func (m *Model) startWithoutFile(resume bool) {
+	session, ok := LoadSession()
+	switch {
+	case ok && resume:
+		// A map that no longer loads is left untouched by resumeSession, so that's a fresh start
+		if err := m.resumeSession(session); err != nil {
+			m.setStatus(StatusWarn, fmt.Sprintf("Couldn't resume %s: %v", session.File, err))
+		}
+		m.offerRecovery()
+	case ok:
+		m.offerResume(session)
+	case len(LoadRecent()) > 0:
+		m.openStartupPicker()
+	default:
+		m.offerRecovery()
+	}
+}
+
+// offerResume asks whether to pick up where the last session left off
+func (m *Model) offerResume(session Session) {
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmResume
+	m.ConfirmTarget = session.File
+	m.ConfirmPrompt = fmt.Sprintf("Resume %s? [y/N]", session.File)
+}
+
+// answerResume handles the answer to the resume prompt; anything but y shows the recent
+// files picker, or starts fresh when there are no recent files
+func (m Model) answerResume(key string) (tea.Model, tea.Cmd) {
+	m.endConfirm()
+	m.clearStatus()
+	if key != "y" && len(LoadRecent()) > 0 {
+		m.openStartupPicker()
+		return m, nil
+	}
+	if key == "y" {
+		// The session is read again in case another instance has quit since
+		if session, ok := LoadSession(); ok {
+			if err := m.resumeSession(session); err != nil {
+				m.setStatus(StatusError, fmt.Sprintf("Error resuming: %v", err))
+			}
+		}
+	}
+	m.offerRecovery()
+	return m, nil
+}
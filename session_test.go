@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sessionTestModel saves a map, records it as the last session and a recent file, and
+// returns a fresh model to start up with, along with the map's path
+func sessionTestModel(t *testing.T) (Model, string) {
+	t.Helper()
+	t.Chdir(t.TempDir()) // Keeps the default map and its recovery file out of the repo
+	m := newTestModel(t)
+	path, _ := saveTestMap(t, &m)
+	if err := m.saveSession(); err != nil {
+		t.Fatal(err)
+	}
+	m.rememberFile()
+	return NewModel(), path
+}
+
+func TestStartupOffersSessionBeforeRecentFiles(t *testing.T) {
+	m, _ := sessionTestModel(t)
+	m.startWithoutFile(false)
+	if m.Mode != ModeConfirm || m.ConfirmAction != ConfirmResume || m.ShowRecent {
+		t.Fatalf("got mode %v, confirm action %v and picker %v, want the resume question", m.Mode, m.ConfirmAction, m.ShowRecent)
+	}
+
+	m = press(m, "n")
+	if !m.ShowRecent {
+		t.Error("declining the session didn't show the recent files")
+	}
+}
+
+func TestStartupResumeFailureIsReported(t *testing.T) {
+	m, path := sessionTestModel(t)
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.startWithoutFile(true)
+	if m.StatusLevel != StatusWarn || !strings.Contains(m.StatusMsg, "Couldn't resume") {
+		t.Errorf("got status %q (level %v), want a warning that the session couldn't be resumed", m.StatusMsg, m.StatusLevel)
+	}
+}
+
+func TestStartupRecoveryWaitsForPicker(t *testing.T) {
+	m, _ := sessionTestModel(t)
+	session, _ := sessionPath()
+	if err := os.Remove(session); err != nil {
+		t.Fatal(err)
+	}
+	// Unsaved changes to a new map left behind by a crash
+	if err := os.WriteFile(filepath.Join(".", recoveryPath(defaultFilename)), []byte(`{"nodes":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m.startWithoutFile(false)
+	if !m.ShowRecent || m.Mode == ModeConfirm {
+		t.Fatalf("got picker %v and mode %v, want the picker without a recovery question", m.ShowRecent, m.Mode)
+	}
+	m = press(m, "esc")
+	if m.Mode != ModeConfirm || m.ConfirmAction != ConfirmRecover {
+		t.Errorf("closing the picker left mode %v, want the recovery question", m.Mode)
+	}
+}
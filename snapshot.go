@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// renderSnapshot draws the whole map at zoom 1 onto a grid just big enough to hold it.
+// The selection, search highlights and other transient state are left out.
+func (m Model) renderSnapshot() [][]ColoredCell {
+	minX, minY, maxX, maxY, ok := m.nodeBounds()
+	if !ok {
+		return nil
+	}
+
+	// A margin of two columns leaves room for arrowheads at the edges
+	const marginX, marginY = 2, 1
+	width := int(math.Ceil(maxX-minX)) + 2*marginX
+	height := int(math.Ceil(maxY-minY)) + 2*marginY
+
+	view := m
+	view.Mode = ModeNormal
+	view.Selected = ""
+	view.Camera = NewCamera()
+	view.Camera.X = minX - marginX + float64(width)/2
+	view.Camera.Y = minY - marginY + float64(height)/2
+
+	grid := newGrid(width, height)
+	view.drawMap(grid)
+	return grid
+}
+
+// ExportSnapshot writes a picture of the whole map as plain text to path and, when
+// ansiPath isn't empty, with ANSI colors to ansiPath
+func (m Model) ExportSnapshot(path, ansiPath string) error {
+	grid := m.renderSnapshot()
+	if grid == nil {
+		return fmt.Errorf("the map is empty")
+	}
+
+	plain := m
+	plain.NoColor = true
+	if err := os.WriteFile(path, []byte(plain.gridText(grid)), 0644); err != nil {
+		return err
+	}
+	if ansiPath != "" {
+		return os.WriteFile(ansiPath, []byte(m.gridText(grid)), 0644)
+	}
+	return nil
+}
+
+// gridText converts a grid to lines of text, colored unless NoColor is set,
+// with trailing blanks trimmed
+func (m Model) gridText(grid [][]ColoredCell) string {
+	var sb strings.Builder
+	for _, row := range grid {
+		end := len(row)
+		for end > 0 && row[end-1].Char == ' ' {
+			end--
+		}
+		m.writeRow(&sb, row[:end])
+		sb.WriteRune('\n')
+	}
+	return sb.String()
+}
+
+// snapshot exports a picture of the map next to the current file, as .txt and .ans
+func (m *Model) snapshot() {
+	base := strings.TrimSuffix(m.FileName(), filepath.Ext(m.FileName()))
+	path, ansiPath := base+".txt", base+".ans"
+	if err := m.ExportSnapshot(path, ansiPath); err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Snapshot failed: %v", err))
+		return
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Snapshot written to %s and %s", path, ansiPath))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// childOrders names the ways SortChildren can order children
+var childOrders = map[string]string{
+	"a": "alphabetically",
+	"r": "in reverse alphabetical order",
+	"c": "by creation order",
+}
+
+// SortChildren reorders the selected node's children top to bottom: "a" alphabetically,
+// "r" in reverse, "c" in creation (numeric ID) order. Each child's subtree moves with it,
+// and the children take over the same slots so nothing outside the branch has to move.
+func (m *Model) SortChildren(order string) {
+	parent := m.GetSelectedNode()
+	if parent == nil {
+		return
+	}
+	name, ok := childOrders[order]
+	if !ok {
+		m.setPrompt("Sort by [a]lphabetical, [r]everse or [c]reation order")
+		return
+	}
+	children := m.GetChildrenOf(parent.ID)
+	if len(children) < 2 {
+		m.setStatus(StatusWarn, "Nothing to sort")
+		return
+	}
+
+	sorted := slices.Clone(children)
+	slices.SortStableFunc(sorted, func(a, b *Node) int {
+		switch order {
+		case "a":
+			return compareText(a, b)
+		case "r":
+			return compareText(b, a)
+		default:
+			return compareIDs(a.ID, b.ID)
+		}
+	})
+	if slices.Equal(sorted, children) {
+		m.setStatus(StatusInfo, fmt.Sprintf("Children already sorted %s", name))
+		return
+	}
+
+	// Measure the current slots: each subtree's extent and the gaps between them
+	tops := make(map[string]float64, len(children))
+	heights := make(map[string]float64, len(children))
+	for _, child := range children {
+		top, bottom := m.subtreeBounds(child.ID)
+		tops[child.ID], heights[child.ID] = top, bottom-top
+	}
+	slices.SortStableFunc(children, func(a, b *Node) int {
+		return compareFloat(tops[a.ID], tops[b.ID])
+	})
+	gaps := make([]float64, len(children)-1)
+	for i := range gaps {
+		gaps[i] = tops[children[i+1].ID] - (tops[children[i].ID] + heights[children[i].ID])
+	}
+
+	m.pushUndo(fmt.Sprintf("sort children of %s", parent.ID))
+	renumber(sorted)
+	y := tops[children[0].ID]
+	for i, child := range sorted {
+		m.moveSubtree(child.ID, 0, y-tops[child.ID])
+		y += heights[child.ID]
+		if i < len(gaps) {
+			y += gaps[i]
+		}
+	}
+	m.invalidateSpatialIndex()
+	m.remember(LastAction{Kind: RepeatSort, Order: order})
+	m.setStatus(StatusInfo, fmt.Sprintf("Sorted %d children %s", len(sorted), name))
+}
+
+// compareText orders nodes by their text, ignoring case, then by ID
+func compareText(a, b *Node) int {
+	if c := strings.Compare(strings.ToLower(singleLine(a.Text)), strings.ToLower(singleLine(b.Text))); c != 0 {
+		return c
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+// compareIDs orders numeric IDs by value, before any that aren't numbers
+func compareIDs(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// compareFloat orders two numbers for sorting
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompareIDs(t *testing.T) {
+	ids := []string{"b", "10", "a", "2", "0"}
+	slices.SortFunc(ids, compareIDs)
+	if want := []string{"0", "2", "10", "a", "b"}; !slices.Equal(ids, want) {
+		t.Errorf("sorted IDs %v, want %v", ids, want)
+	}
+}
+
+func TestSortNodesBreaksTiesByID(t *testing.T) {
+	nodes := []*Node{rectNode("10", 0, 5, 1, 1), rectNode("x", 0, 5, 1, 1), rectNode("9", 0, 5, 1, 1), rectNode("1", 0, 0, 1, 1)}
+	sortNodes(nodes)
+	var got []string
+	for _, node := range nodes {
+		got = append(got, node.ID)
+	}
+	if want := []string{"1", "9", "10", "x"}; !slices.Equal(got, want) {
+		t.Errorf("sorted nodes %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Limits for the configurable spacing, in cells
+const (
+	minSpacing = 1
+	maxSpacing = 40
+)
+
+// validSpacing reports whether a gap is within the supported spacing
+func validSpacing(gap int) bool {
+	return gap >= minSpacing && gap <= maxSpacing
+}
+
+// useConfigSpacing takes the spacing from the config, reporting false (and keeping the
+// defaults) when either gap is out of range
+func (m *Model) useConfigSpacing(cfg Config) bool {
+	if !validSpacing(cfg.HSpacing) || !validSpacing(cfg.VSpacing) {
+		return false
+	}
+	m.HSpacing, m.VSpacing = float64(cfg.HSpacing), float64(cfg.VSpacing)
+	return true
+}
+
+// setSpacing changes one of the gaps new nodes are placed with. Nodes already on the map
+// stay where they are until the next re-layout.
+func (m *Model) setSpacing(gap *float64, name, value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil || !validSpacing(n) {
+		m.setStatus(StatusWarn, fmt.Sprintf("%s must be a whole number between %d and %d", name, minSpacing, maxSpacing))
+		return
+	}
+	*gap = float64(n)
+	m.setStatus(StatusInfo, fmt.Sprintf("%s: %d (new nodes use it; R re-lays out the map)", name, n))
+}
+
+// commandHSpace handles ":set hspace=<cells>", the gap between a parent and its children
+func (m *Model) commandHSpace(value string) {
+	m.setSpacing(&m.HSpacing, "Horizontal spacing", value)
+}
+
+// commandVSpace handles ":set vspace=<cells>", the gap between stacked siblings
+func (m *Model) commandVSpace(value string) {
+	m.setSpacing(&m.VSpacing, "Vertical spacing", value)
+}
@@ -0,0 +1,77 @@
+package main
+
+import "math"
+
+// spatialCellSize is the width and height of one spatial index bucket, in world units
+const spatialCellSize = 16.0
+
+// spatialIndex buckets node IDs by the grid cells their bounding boxes overlap, so
+// position lookups only look at nearby nodes. It is rebuilt lazily after nodes change.
+type spatialIndex struct {
+	cells map[[2]int][]string
+	valid bool
+}
+
+// spatialCell returns the bucket coordinate containing a world coordinate
+func spatialCell(v float64) int {
+	return int(math.Floor(v / spatialCellSize))
+}
+
+// invalidateSpatialIndex marks the index stale after nodes were added, removed, moved or resized
+func (m *Model) invalidateSpatialIndex() {
+	if m.spatial != nil {
+		m.spatial.valid = false
+	}
+}
+
+// nodeIndex returns the spatial index, rebuilding it if nodes changed since it was built.
+// Nodes hidden in collapsed branches are left out, so they can't be found or clicked.
+func (m *Model) nodeIndex() *spatialIndex {
+	if m.spatial == nil {
+		m.spatial = &spatialIndex{}
+	}
+	index := m.spatial
+	if index.valid {
+		return index
+	}
+
+	index.cells = make(map[[2]int][]string)
+	for id, node := range m.Nodes {
+		if m.isHidden(id) {
+			continue
+		}
+		minX, minY := spatialCell(node.X), spatialCell(node.Y)
+		maxX := spatialCell(node.X + float64(node.Width))
+		maxY := spatialCell(node.Y + float64(node.Height))
+		for cx := minX; cx <= maxX; cx++ {
+			for cy := minY; cy <= maxY; cy++ {
+				key := [2]int{cx, cy}
+				index.cells[key] = append(index.cells[key], id)
+			}
+		}
+	}
+	index.valid = true
+	return index
+}
+
+// nodesInRect returns the nodes whose bounding boxes may overlap a world rectangle.
+// Callers still check exact bounds; the index only narrows down the candidates.
+func (m *Model) nodesInRect(minX, minY, maxX, maxY float64) []*Node {
+	index := m.nodeIndex()
+	seen := make(map[string]bool)
+	var nodes []*Node
+	for cx := spatialCell(minX); cx <= spatialCell(maxX); cx++ {
+		for cy := spatialCell(minY); cy <= spatialCell(maxY); cy++ {
+			for _, id := range index.cells[[2]int{cx, cy}] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				if node := m.Nodes[id]; node != nil {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+	}
+	return nodes
+}
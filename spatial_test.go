@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+// nodeAtByScan finds the node at a screen position by checking every node, as
+// GetNodeAt did before the spatial index
+func (m *Model) nodeAtByScan(screenX, screenY int) *Node {
+	wx, wy := m.Camera.ScreenToWorld(screenX, screenY, m.Width, m.canvasHeight())
+	for _, node := range m.Nodes {
+		if wx >= node.X && wx < node.X+float64(node.Width) &&
+			wy >= node.Y && wy < node.Y+float64(node.Height) {
+			return node
+		}
+	}
+	return nil
+}
+
+func TestGetNodeAtMatchesScan(t *testing.T) {
+	m := syntheticMap(t, 300)
+	for y := 0; y < m.canvasHeight(); y++ {
+		for x := 0; x < m.Width; x++ {
+			if got, want := m.GetNodeAt(x, y), m.nodeAtByScan(x, y); got != want {
+				t.Fatalf("GetNodeAt(%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestNodeIndexFollowsMoves(t *testing.T) {
+	m := syntheticMap(t, 20)
+	node := m.Nodes["7"]
+	m.nodeIndex()
+	m.moveSubtree(node.ID, 500, 500)
+	m.invalidateSpatialIndex()
+
+	found := false
+	for _, candidate := range m.nodesInRect(node.X, node.Y, node.X, node.Y) {
+		found = found || candidate == node
+	}
+	if !found {
+		t.Errorf("node %s not found at its new position", node.ID)
+	}
+}
+
+// BenchmarkGetNodeAt times looking up the node under every cell of the screen on a
+// 1000-node map, with the spatial index and with a scan of every node
+func BenchmarkGetNodeAt(b *testing.B) {
+	m := syntheticMap(b, 1000)
+	lookup := func(b *testing.B, find func(x, y int) *Node) {
+		for b.Loop() {
+			for y := 0; y < m.canvasHeight(); y += 4 {
+				for x := 0; x < m.Width; x += 4 {
+					find(x, y)
+				}
+			}
+		}
+	}
+
+	b.Run("index", func(b *testing.B) { lookup(b, m.GetNodeAt) })
+	b.Run("scan", func(b *testing.B) { lookup(b, m.nodeAtByScan) })
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// branchStats sums up a node and everything below it
+type branchStats struct {
+	Descendants int // Nodes below, not counting the node itself
+	Depth       int // Levels below the node (0 for a leaf)
+	Words       int // Words in the text of the node and its descendants
+	LinksOut    int // Cross-links leaving the branch's nodes
+	LinksIn     int // Cross-links arriving at the branch's nodes
+}
+
+// add folds a child branch into s
+func (s *branchStats) add(child branchStats) {
+	s.Descendants += child.Descendants + 1
+	s.Depth = max(s.Depth, child.Depth+1)
+	s.Words += child.Words
+	s.LinksOut += child.LinksOut
+	s.LinksIn += child.LinksIn
+}
+
+// ToggleStats shows or hides the branch statistics overlay
+func (m *Model) ToggleStats() {
+	m.ShowStats = !m.ShowStats
+}
+
+// branchStatistics returns the stats of every branch, keyed by node ID, and of the whole map.
+// It walks the tree once from the roots; nodes caught in a parent cycle are walked after
+// that from wherever the loop is entered, and each node is counted only once.
+func (m *Model) branchStatistics() (map[string]branchStats, branchStats) {
+	children := make(map[string][]string)
+	for id, node := range m.Nodes {
+		if m.Nodes[node.ParentID] != nil {
+			children[node.ParentID] = append(children[node.ParentID], id)
+		}
+	}
+
+	// Each node's own share: its words and the cross-links at either end
+	stats := make(map[string]branchStats, len(m.Nodes))
+	for id, node := range m.Nodes {
+		stats[id] = branchStats{Words: len(strings.Fields(node.Text))}
+	}
+	for _, edge := range m.Edges {
+		if m.Nodes[edge.FromID] == nil || m.Nodes[edge.ToID] == nil || m.isChildOf(edge.ToID, edge.FromID) {
+			continue
+		}
+		from, to := stats[edge.FromID], stats[edge.ToID]
+		from.LinksOut++
+		to.LinksIn++
+		stats[edge.FromID], stats[edge.ToID] = from, to
+	}
+
+	visited := make(map[string]bool, len(m.Nodes))
+	var walk func(id string) branchStats
+	walk = func(id string) branchStats {
+		visited[id] = true
+		s := stats[id]
+		for _, childID := range children[id] {
+			if !visited[childID] {
+				s.add(walk(childID))
+			}
+		}
+		stats[id] = s
+		return s
+	}
+
+	var total branchStats
+	ids := make([]string, 0, len(m.Nodes))
+	for id, node := range m.Nodes {
+		if m.Nodes[node.ParentID] == nil {
+			ids = append(ids, id) // Roots first
+		}
+	}
+	for id := range m.Nodes {
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		if !visited[id] {
+			total.add(walk(id))
+		}
+	}
+	total.Depth = max(total.Depth-1, 0) // Levels below the roots, like a branch's depth
+	return stats, total
+}
+
+// renderStatsOverlay shows the statistics of the selected branch and of the whole map
+func (m Model) renderStatsOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	headingStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Edit))
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	valueStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	row := func(label string, value int) string {
+		return "  " + labelStyle.Render(fmt.Sprintf("%-12s", label)) + valueStyle.Render(fmt.Sprintf("%d", value))
+	}
+
+	stats, total := m.branchStatistics()
+	lines := []string{titleStyle.Render("📊 Statistics"), ""}
+	if node := m.GetSelectedNode(); node != nil {
+		branch := stats[node.ID]
+		lines = append(lines,
+			headingStyle.Render("Branch: "+truncateWidth(singleLine(node.Text), 30)),
+			row("Descendants", branch.Descendants),
+			row("Depth", branch.Depth),
+			row("Words", branch.Words),
+			row("Links out", branch.LinksOut),
+			row("Links in", branch.LinksIn),
+			"",
+		)
+	}
+	lines = append(lines,
+		headingStyle.Render("Whole map"),
+		row("Nodes", len(m.Nodes)),
+		row("Trees", len(m.GetRootNodes())),
+		row("Depth", total.Depth),
+		row("Words", total.Words),
+		row("Cross-links", total.LinksOut),
+	)
+	if longest := m.longestNode(); longest != nil {
+		lines = append(lines, row("Longest", utf8.RuneCountInString(longest.Text))+
+			valueStyle.Render(fmt.Sprintf(" chars: #%s %s", longest.ID, truncateWidth(singleLine(longest.Text), 24))))
+	}
+	lines = append(lines,
+		"",
+		footerStyle.Render("Press any key to close"),
+	)
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
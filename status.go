@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long status messages stay in the status bar
+const (
+	statusTimeout      = 4 * time.Second
+	errorStatusTimeout = 10 * time.Second
+)
+
+// StatusLevel is how serious a status message is, as decided where it is set
+type StatusLevel int
+
+const (
+	StatusInfo  StatusLevel = iota // Prompts, progress and results
+	StatusWarn                     // Refused commands, bad input and partial successes
+	StatusError                    // Failed saves, loads and other operations
+)
+
+// statusLevelNames are the levels as :messages lists them
+var statusLevelNames = map[StatusLevel]string{
+	StatusInfo:  "info",
+	StatusWarn:  "warn",
+	StatusError: "error",
+}
+
+// maxMessages is how many status messages the history keeps
+const maxMessages = 50
+
+// StatusEntry is a status message in the history :messages shows
+type StatusEntry struct {
+	Time  time.Time
+	Level StatusLevel
+	Text  string
+}
+
+// setStatus shows a message in the status bar and adds it to the history, dropping the
+// oldest once there are maxMessages
+func (m *Model) setStatus(level StatusLevel, msg string) {
+	m.StatusMsg, m.StatusLevel = msg, level
+	m.Messages = append(m.Messages, StatusEntry{Time: time.Now(), Level: level, Text: msg})
+	if len(m.Messages) > maxMessages {
+		m.Messages = slices.Delete(m.Messages, 0, len(m.Messages)-maxMessages)
+	}
+}
+
+// setPrompt shows a prompt, or an echo like a list of completions, in the status bar
+// without adding it to the history, which is kept for what actually happened
+func (m *Model) setPrompt(msg string) {
+	m.StatusMsg, m.StatusLevel = msg, StatusInfo
+}
+
+// amendStatus rewords the current status message and its history entry, keeping its level
+func (m *Model) amendStatus(msg string) {
+	m.StatusMsg = msg
+	if n := len(m.Messages); n > 0 {
+		m.Messages[n-1].Text = msg
+	}
+}
+
+// clearStatus empties the status bar; the history keeps the message
+func (m *Model) clearStatus() {
+	m.StatusMsg, m.StatusLevel = "", StatusInfo
+}
+
+// statusExpiredMsg clears the status message it was scheduled for
+type statusExpiredMsg struct {
+	seq int
+}
+
+// expireStatus starts the timer for the current status message. Any earlier timer
+// is superseded, so a new message always gets its full time; warnings and errors
+// stay up longer.
+func (m *Model) expireStatus() tea.Cmd {
+	m.statusSeq++
+	timeout := statusTimeout
+	if m.StatusLevel >= StatusWarn {
+		timeout = errorStatusTimeout
+	}
+	return statusTimer(m.statusSeq, timeout)
+}
+
+// statusTimer fires a statusExpiredMsg for seq after timeout
+func statusTimer(seq int, timeout time.Duration) tea.Cmd {
+	return tea.Tick(timeout, func(time.Time) tea.Msg {
+		return statusExpiredMsg{seq: seq}
+	})
+}
+
+// handleStatusExpired clears the status message unless a newer one replaced it.
+// Prompts shown while a mode or key sequence is in progress stay until it ends.
+func (m *Model) handleStatusExpired(msg statusExpiredMsg) tea.Cmd {
+	if msg.seq != m.statusSeq {
+		return nil
+	}
+	if m.Mode != ModeNormal || m.PendingKey != "" {
+		return statusTimer(msg.seq, statusTimeout)
+	}
+	m.clearStatus()
+	return nil
+}
+
+// selectionSummary describes the selected node for the status bar: its depth below the
+// root, how many children it has and how many cross-links go to or from it, like
+// "d2 c4 ↔1". It's only worked out again when the selection or the tree changes.
+func (m *Model) selectionSummary() string {
+	if m.depths == nil {
+		m.depths = &depthCache{}
+	}
+	cache := m.depths
+	if cache.hasSummary && cache.summaryID == m.Selected {
+		return cache.summary
+	}
+
+	summary := ""
+	if node := m.GetSelectedNode(); node != nil {
+		links := 0
+		for _, edge := range m.Edges {
+			if edge.FromID == node.ID && !m.isChildOf(edge.ToID, node.ID) ||
+				edge.ToID == node.ID && edge.FromID != node.ParentID {
+				links++
+			}
+		}
+		summary = fmt.Sprintf("d%d c%d", m.nodeDepth(node.ID), len(m.GetChildrenOf(node.ID)))
+		if links > 0 {
+			summary += fmt.Sprintf(" ↔%d", links)
+		}
+	}
+	cache.summary, cache.summaryID, cache.hasSummary = summary, m.Selected, true
+	return summary
+}
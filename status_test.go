@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestPromptsAreNotLogged(t *testing.T) {
+	for _, keys := range [][]string{
+		{"tab"},
+		{"enter"},
+		{"g"},
+		{"z"},
+		{"S"},
+		{"A"},
+		{"ctrl+k"},
+		{"P"},
+		{"v", "t"},
+		{"v", "m"},
+		{":", "w", "tab"},
+	} {
+		m := newTestModel(t)
+		addTestChild(&m, m.Selected, "child")
+		m.Messages = nil
+		m = press(m, keys...)
+		if m.StatusMsg == "" {
+			t.Errorf("%v: no prompt shown", keys)
+		}
+		if len(m.Messages) != 0 {
+			t.Errorf("%v: prompt logged as %q", keys, m.Messages[0].Text)
+		}
+	}
+}
+
+func TestOutcomesAreLogged(t *testing.T) {
+	m := newTestModel(t)
+	m.Messages = nil
+	m = press(m, "tab", "a", "enter")
+	if len(m.Messages) != 1 {
+		t.Fatalf("logged %d messages after creating a node, want 1", len(m.Messages))
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// subtreeMap returns a separate map holding only a node and its descendants, for exporting
+// one branch. Edges leaving the branch are dropped, positions are moved so the branch
+// starts at the origin, and the node becomes the root, with ID "0".
+func (m *Model) subtreeMap(id string) (*Model, error) {
+	top := m.Nodes[id]
+	if top == nil {
+		return nil, fmt.Errorf("no node with ID %s", id)
+	}
+	m.finishLayoutAnimation()
+
+	// The branch's node becomes the root; nothing else inside it can be "0"
+	rename := func(nodeID string) string {
+		if nodeID == id {
+			return "0"
+		}
+		return nodeID
+	}
+
+	members := append([]*Node{top}, m.GetDescendantsOf(id)...)
+	inside := make(map[string]bool, len(members))
+	minX, minY := math.Inf(1), math.Inf(1)
+	for _, node := range members {
+		inside[node.ID] = true
+		minX, minY = math.Min(minX, node.X), math.Min(minY, node.Y)
+	}
+
+	sub := *m
+	sub.Nodes = make(map[string]*Node, len(members))
+	for _, node := range members {
+		clone := node.Clone()
+		clone.ID = rename(node.ID)
+		clone.ParentID = rename(node.ParentID)
+		clone.X -= minX
+		clone.Y -= minY
+		clone.Links = clone.Links[:0]
+		for _, link := range node.Links {
+			if inside[link] {
+				clone.Links = append(clone.Links, rename(link))
+			}
+		}
+		sub.Nodes[clone.ID] = clone
+	}
+	root := sub.Nodes["0"]
+	root.ParentID = ""
+	root.Order = 0
+
+	sub.Edges = make([]Edge, 0)
+	for _, edge := range m.Edges {
+		if inside[edge.FromID] && inside[edge.ToID] {
+			sub.Edges = append(sub.Edges, Edge{FromID: rename(edge.FromID), ToID: rename(edge.ToID)})
+		}
+	}
+
+	// Only marks on nodes in the branch still make sense
+	sub.Marks = nil
+	for name, mark := range m.Marks {
+		if inside[mark.NodeID] {
+			if sub.Marks == nil {
+				sub.Marks = make(map[string]Mark)
+			}
+			sub.Marks[name] = Mark{NodeID: rename(mark.NodeID)}
+		}
+	}
+
+	sub.Selected = "0"
+	sub.Camera = NewCamera()
+	sub.Camera.X, sub.Camera.Y = root.GetCenter()
+	sub.Camera.TargetX, sub.Camera.TargetY = sub.Camera.X, sub.Camera.Y
+	sub.ExtraFields = nil
+	sub.LayoutTargets = nil
+	sub.spatial = nil
+	sub.depths = nil
+	sub.NextID = sub.nextFreeID()
+	sub.NextColorIndex = len(sub.GetChildrenOf("0"))
+	return &sub, nil
+}
+
+// ExportSubtree writes a node and its descendants on their own, in the format the path's
+// extension picks, as ExportTo does for the whole map
+func (m *Model) ExportSubtree(id, path string) error {
+	sub, err := m.subtreeMap(id)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return sub.ExportJSON(path)
+	}
+	return sub.ExportTo(path)
+}
+
+// ExportJSON writes a copy of the map to path. Unlike saving, the map stays tied to its own file.
+func (m *Model) ExportJSON(path string) error {
+	data, err := m.marshalMap()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, false)
+}
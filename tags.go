@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// normalizeTag strips a leading '#' so "#idea" and "idea" are the same tag
+func normalizeTag(tag string) string {
+	return strings.TrimPrefix(strings.TrimSpace(tag), "#")
+}
+
+// HasTag reports whether the node carries tag
+func (n *Node) HasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagLine returns the tags shown under a node's text, e.g. "#urgent #idea"
+func (n *Node) tagLine() string {
+	if len(n.Tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(n.Tags, " #")
+}
+
+// ToggleTags adds each tag the selected node lacks and removes each one it has.
+// With a visual selection, every selected node is tagged as one undo step.
+func (m *Model) ToggleTags(tags []string) {
+	if len(m.SelectedSet) > 0 {
+		ids := m.selectedIDs()
+		m.batch(fmt.Sprintf("tag %d nodes", len(ids)), func() {
+			for _, id := range ids {
+				m.toggleNodeTags(m.Nodes[id], tags)
+			}
+		})
+		m.remember(LastAction{Kind: RepeatTags, Tags: tags})
+		m.setStatus(StatusInfo, fmt.Sprintf("Toggled %s on %d nodes", strings.Join(tags, " "), len(ids)))
+		return
+	}
+
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+	m.pushUndo(fmt.Sprintf("tag node %s", node.ID))
+	m.toggleNodeTags(node, tags)
+	m.remember(LastAction{Kind: RepeatTags, Tags: tags})
+}
+
+// toggleNodeTags adds each tag a node lacks and removes each one it has
+func (m *Model) toggleNodeTags(node *Node, tags []string) {
+	var added, removed []string
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if node.HasTag(tag) {
+			kept := node.Tags[:0]
+			for _, t := range node.Tags {
+				if t != tag {
+					kept = append(kept, t)
+				}
+			}
+			node.Tags = kept
+			removed = append(removed, "#"+tag)
+		} else {
+			node.Tags = append(node.Tags, tag)
+			added = append(added, "#"+tag)
+		}
+	}
+	if len(node.Tags) == 0 {
+		node.Tags = nil
+	}
+	node.UpdateSize(m.WrapWidth)
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, " "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(removed, " "))
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Node %s: %s", node.ID, strings.Join(parts, ", ")))
+}
+
+// SetTagFilter dims every node that lacks tag, except ancestors of nodes that have it
+func (m *Model) SetTagFilter(tag string) {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		m.ClearTagFilter()
+		return
+	}
+
+	m.TagFilter = tag
+	count := 0
+	for _, node := range m.Nodes {
+		if node.HasTag(tag) {
+			count++
+		}
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Showing %d nodes tagged #%s (Esc to show all)", count, tag))
+}
+
+// ClearTagFilter shows all nodes again
+func (m *Model) ClearTagFilter() {
+	m.TagFilter = ""
+	m.setStatus(StatusInfo, "Tag filter cleared")
+}
+
+// tagFilterVisible returns the IDs of nodes the tag filter leaves visible:
+// nodes with the tag plus their ancestors, so the tree stays connected
+func (m *Model) tagFilterVisible() map[string]bool {
+	visible := make(map[string]bool)
+	for id, node := range m.Nodes {
+		if !node.HasTag(m.TagFilter) {
+			continue
+		}
+		for n := node; n != nil && !visible[n.ID]; n = m.Nodes[n.ParentID] {
+			visible[n.ID] = true
+		}
+		visible[id] = true
+	}
+	return visible
+}
+
+// allTags returns every tag used in the map, sorted
+func (m *Model) allTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, node := range m.Nodes {
+		for _, tag := range node.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// completeTag completes the last word of a ":tag" or ":filter" command line from the
+// tags already in the map. Several matches complete to their common prefix.
+func (m *Model) completeTag(line string) string {
+	if !strings.HasPrefix(line, "tag ") && !strings.HasPrefix(line, "filter ") {
+		return line
+	}
+
+	start := strings.LastIndex(line, " ") + 1
+	prefix := normalizeTag(line[start:])
+
+	var matches []string
+	for _, tag := range m.allTags() {
+		if strings.HasPrefix(tag, prefix) {
+			matches = append(matches, tag)
+		}
+	}
+	if len(matches) == 0 {
+		m.setStatus(StatusWarn, "No matching tags")
+		return line
+	}
+
+	common := commonPrefix(matches)
+	if len(matches) > 1 {
+		m.setPrompt("#" + strings.Join(matches, " #"))
+	} else {
+		common += " "
+	}
+	return line[:start] + common
+}
@@ -0,0 +1,87 @@
+package main
+
+import "fmt"
+
+// displayText returns the node's text as drawn, with a checkbox in front for tasks
+func (n *Node) displayText() string {
+	switch {
+	case !n.Task:
+		return n.Text
+	case n.Done:
+		return "[x] " + n.Text
+	default:
+		return "[ ] " + n.Text
+	}
+}
+
+// ToggleDone checks or unchecks the selected task. A plain node becomes an unchecked task.
+func (m *Model) ToggleDone() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("toggle task %s", node.ID))
+	m.remember(LastAction{Kind: RepeatToggleDone})
+	if !node.Task {
+		node.Task = true
+		node.Done = false
+		m.setStatus(StatusInfo, fmt.Sprintf("Node %s is now a task", node.ID))
+	} else {
+		node.Done = !node.Done
+		if node.Done {
+			m.setStatus(StatusInfo, fmt.Sprintf("Task %s done", node.ID))
+		} else {
+			m.setStatus(StatusInfo, fmt.Sprintf("Task %s not done", node.ID))
+		}
+	}
+	node.UpdateSize(m.WrapWidth)
+}
+
+// ToggleTask turns the selected node into a task, or back into a plain node
+func (m *Model) ToggleTask() {
+	node := m.GetSelectedNode()
+	if node == nil {
+		return
+	}
+
+	m.pushUndo(fmt.Sprintf("toggle task %s", node.ID))
+	node.Task = !node.Task
+	node.Done = false
+	node.UpdateSize(m.WrapWidth)
+	if node.Task {
+		m.setStatus(StatusInfo, fmt.Sprintf("Node %s is now a task", node.ID))
+	} else {
+		m.setStatus(StatusInfo, fmt.Sprintf("Node %s is no longer a task", node.ID))
+	}
+}
+
+// taskRollup counts the done children of a node whose children are all tasks.
+// ok is false if the node has no children or any child isn't a task.
+func (m *Model) taskRollup(id string) (done, total int, ok bool) {
+	children := m.GetChildrenOf(id)
+	if len(children) == 0 {
+		return 0, 0, false
+	}
+	for _, child := range children {
+		if !child.Task {
+			return 0, 0, false
+		}
+		if child.Done {
+			done++
+		}
+	}
+	return done, len(children), true
+}
+
+// inDoneSubtree reports whether the node or any of its ancestors is a completed task
+func (m *Model) inDoneSubtree(id string) bool {
+	visited := make(map[string]bool)
+	for node := m.Nodes[id]; node != nil && !visited[node.ID]; node = m.Nodes[node.ParentID] {
+		if node.Task && node.Done {
+			return true
+		}
+		visited[node.ID] = true
+	}
+	return false
+}
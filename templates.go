@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Template is a named outline that can be inserted under the selected node
+type Template struct {
+	Name  string         `json:"name"`
+	Nodes []TemplateNode `json:"nodes"`
+}
+
+// TemplateNode is a node of a template with the nodes below it
+type TemplateNode struct {
+	Text     string         `json:"text"`
+	Children []TemplateNode `json:"children,omitempty"`
+}
+
+// countTemplateNodes returns how many nodes a template outline has
+func countTemplateNodes(nodes []TemplateNode) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countTemplateNodes(node.Children)
+	}
+	return count
+}
+
+// noTemplates is shown when there are no templates to pick from
+const noTemplates = `No templates yet: add them under "templates" in config.json, or save a branch with :template save <name>`
+
+// OpenTemplates shows the template picker
+func (m *Model) OpenTemplates() {
+	if len(m.Config.Templates) == 0 {
+		m.setStatus(StatusWarn, noTemplates)
+		return
+	}
+	m.ShowTemplates = true
+	m.TemplateIndex = min(m.TemplateIndex, len(m.Config.Templates)-1)
+}
+
+// InsertTemplate creates a template's nodes under the selected node (or floating, with
+// nothing selected) as one undo step. They are placed and colored like new children.
+func (m *Model) InsertTemplate(t Template) {
+	if countTemplateNodes(t.Nodes) == 0 {
+		m.setStatus(StatusWarn, fmt.Sprintf("Template %q has no nodes", t.Name))
+		return
+	}
+	parent := m.GetSelectedNode()
+
+	var first *Node
+	m.batch(fmt.Sprintf("insert template %s", t.Name), func() {
+		// Create nodes level by level so each column is placed before the one to its right
+		type pending struct {
+			parent *Node
+			nodes  []TemplateNode
+		}
+		queue := []pending{{parent: parent, nodes: t.Nodes}}
+		for len(queue) > 0 {
+			next := queue[0]
+			queue = queue[1:]
+			for _, item := range next.nodes {
+				node := m.addChild(next.parent, item.Text)
+				if first == nil {
+					first = node
+				}
+				if len(item.Children) > 0 {
+					queue = append(queue, pending{parent: node, nodes: item.Children})
+				}
+			}
+		}
+	})
+
+	m.expandTo(first.ID)
+	m.Selected = first.ID
+	m.revealNode(first)
+	m.setStatus(StatusInfo, fmt.Sprintf("Inserted template %q (%d nodes)", t.Name, countTemplateNodes(t.Nodes)))
+}
+
+// templateFrom turns a node and its descendants into a template outline
+func (m *Model) templateFrom(node *Node, visited map[string]bool) TemplateNode {
+	visited[node.ID] = true
+	item := TemplateNode{Text: node.Text}
+	for _, child := range m.GetChildrenOf(node.ID) {
+		if !visited[child.ID] {
+			item.Children = append(item.Children, m.templateFrom(child, visited))
+		}
+	}
+	return item
+}
+
+// SaveTemplate saves the selected branch as a template in the config file, replacing
+// one with the same name
+func (m *Model) SaveTemplate(name string) {
+	node := m.GetSelectedNode()
+	if node == nil {
+		m.setStatus(StatusWarn, "No node selected")
+		return
+	}
+	t := Template{Name: name, Nodes: []TemplateNode{m.templateFrom(node, make(map[string]bool))}}
+	path, err := saveTemplateConfig(t)
+	if err != nil {
+		m.setStatus(StatusError, fmt.Sprintf("Couldn't save template: %v", err))
+		return
+	}
+	m.Config.Templates = withTemplate(m.Config.Templates, t)
+	m.setStatus(StatusInfo, fmt.Sprintf("Saved template %q (%d nodes) to %s", name, countTemplateNodes(t.Nodes), path))
+}
+
+// withTemplate returns templates with t replacing the one of the same name, or added at the end
+func withTemplate(templates []Template, t Template) []Template {
+	i := slices.IndexFunc(templates, func(other Template) bool { return other.Name == t.Name })
+	if i < 0 {
+		return append(templates, t)
+	}
+	templates[i] = t
+	return templates
+}
+
+// saveTemplateConfig adds a template to config.json, leaving its other settings as they
+// are, and returns the file's path
+func saveTemplateConfig(t Template) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "config.json")
+
+	fields := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	var templates []Template
+	if raw, ok := fields["templates"]; ok {
+		if err := json.Unmarshal(raw, &templates); err != nil {
+			return "", fmt.Errorf("%s: templates: %v", path, err)
+		}
+	}
+
+	encoded, err := json.Marshal(withTemplate(templates, t))
+	if err != nil {
+		return "", err
+	}
+	fields["templates"] = encoded
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return path, writeFileAtomic(path, data, false)
+}
+
+// commandTemplate handles ":template [name]", inserting a template by name (or opening
+// the picker), and ":template save <name>", saving the selected branch as one
+func (m *Model) commandTemplate(args []string) {
+	if len(args) == 0 {
+		m.OpenTemplates()
+		return
+	}
+	if args[0] == "save" {
+		if len(args) < 2 {
+			m.setStatus(StatusWarn, "Usage: :template save <name>")
+			return
+		}
+		m.SaveTemplate(strings.Join(args[1:], " "))
+		return
+	}
+
+	name := strings.Join(args, " ")
+	i := slices.IndexFunc(m.Config.Templates, func(t Template) bool { return t.Name == name })
+	if i < 0 {
+		m.setStatus(StatusWarn, fmt.Sprintf("No template named %q", name))
+		return
+	}
+	m.InsertTemplate(m.Config.Templates[i])
+}
+
+// handleTemplateKey handles keys in the template picker: j/k choose a template,
+// Enter inserts it and Esc closes the picker
+func (m Model) handleTemplateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.TemplateIndex = max(m.TemplateIndex-1, 0)
+	case "down", "j":
+		m.TemplateIndex = min(m.TemplateIndex+1, len(m.Config.Templates)-1)
+	case "enter":
+		m.ShowTemplates = false
+		m.InsertTemplate(m.Config.Templates[m.TemplateIndex])
+	case "esc", "q":
+		m.ShowTemplates = false
+	}
+	return m, nil
+}
+
+// renderTemplateOverlay lists the templates with the chosen one highlighted
+func (m Model) renderTemplateOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	chosenStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	lines := []string{titleStyle.Render("📋 Templates"), ""}
+	for i, t := range m.Config.Templates {
+		desc := fmt.Sprintf("%s (%d nodes)", truncateWidth(t.Name, 30), countTemplateNodes(t.Nodes))
+		if i == m.TemplateIndex {
+			lines = append(lines, chosenStyle.Render("▶ "+desc))
+		} else {
+			lines = append(lines, descStyle.Render("  "+desc))
+		}
+	}
+	lines = append(lines, "", footerStyle.Render("j/k choose · Enter insert under the selected node · Esc close"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
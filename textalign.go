@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Text alignment inside node boxes. Auto centers single-line text that is narrower than
+// the smallest box and puts everything else on the left.
+const (
+	TextAlignAuto   = "auto"
+	TextAlignLeft   = "left"
+	TextAlignCenter = "center"
+)
+
+// textAligns lists the alignments :set align takes
+var textAligns = []string{TextAlignAuto, TextAlignLeft, TextAlignCenter}
+
+// SetTextAlign changes how text is placed inside nodes for this map
+func (m *Model) SetTextAlign(align string) {
+	if !slices.Contains(textAligns, align) {
+		m.setStatus(StatusWarn, fmt.Sprintf("Unknown alignment %q (use auto, left or center)", align))
+		return
+	}
+	if align == TextAlignAuto {
+		align = "" // The default isn't written to the file
+	}
+	if align != m.TextAlign {
+		m.TextAlign = align
+		m.Dirty = true // The alignment is saved with the map
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Text alignment: %s", m.textAlignName()))
+}
+
+// textAlignName returns the current alignment's name, with the default spelled out
+func (m *Model) textAlignName() string {
+	if m.TextAlign == "" {
+		return TextAlignAuto
+	}
+	return m.TextAlign
+}
+
+// centersText reports whether a node's lines are centered in its box, given its text
+// wrapped into lines (without the tag line)
+func (m Model) centersText(lines []string) bool {
+	switch m.TextAlign {
+	case TextAlignCenter:
+		return true
+	case TextAlignLeft:
+		return false
+	}
+	return len(lines) == 1 && textWidth(lines[0]) < minNodeWidth-4
+}
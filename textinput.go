@@ -0,0 +1,189 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editCursorRune is drawn at the cursor position while editing text
+const editCursorRune = '▏'
+
+// applyTextKey applies an editing key to text with the cursor at a rune offset.
+// It returns the updated text and cursor, and false if msg isn't an editing key.
+// When multiline is set, alt+enter inserts a newline and up/down move between lines.
+func applyTextKey(text string, cursor int, msg tea.KeyMsg, multiline bool) (string, int, bool) {
+	runes := []rune(text)
+	if cursor < 0 {
+		cursor = 0
+	}
+	if cursor > len(runes) {
+		cursor = len(runes)
+	}
+
+	switch msg.String() {
+	case "left", "ctrl+b":
+		if cursor > 0 {
+			cursor--
+		}
+	case "right", "ctrl+f":
+		if cursor < len(runes) {
+			cursor++
+		}
+	case "home", "ctrl+a":
+		cursor = lineStart(runes, cursor)
+	case "end":
+		cursor = lineEnd(runes, cursor)
+	case "up":
+		if !multiline {
+			return text, cursor, false
+		}
+		cursor = moveLine(runes, cursor, -1)
+	case "down":
+		if !multiline {
+			return text, cursor, false
+		}
+		cursor = moveLine(runes, cursor, 1)
+
+	case "backspace":
+		if cursor > 0 {
+			runes = append(runes[:cursor-1], runes[cursor:]...)
+			cursor--
+		}
+	case "delete":
+		if cursor < len(runes) {
+			runes = append(runes[:cursor], runes[cursor+1:]...)
+		}
+	case "ctrl+w", "alt+backspace":
+		// Delete the word before the cursor, along with any spaces after it
+		start := cursor
+		for start > 0 && unicode.IsSpace(runes[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(runes[start-1]) {
+			start--
+		}
+		runes = append(runes[:start], runes[cursor:]...)
+		cursor = start
+	case "ctrl+u":
+		start := lineStart(runes, cursor)
+		runes = append(runes[:start], runes[cursor:]...)
+		cursor = start
+
+	case "alt+enter", "ctrl+j":
+		if !multiline {
+			return text, cursor, false
+		}
+		runes = insertRunes(runes, cursor, []rune{'\n'})
+		cursor++
+
+	default:
+		switch msg.Type {
+		case tea.KeyRunes:
+			insert := msg.Runes
+			if !multiline {
+				insert = []rune(strings.ReplaceAll(string(insert), "\n", " "))
+			}
+			runes = insertRunes(runes, cursor, insert)
+			cursor += len(insert)
+		case tea.KeySpace:
+			runes = insertRunes(runes, cursor, []rune{' '})
+			cursor++
+		default:
+			return text, cursor, false
+		}
+	}
+
+	return string(runes), cursor, true
+}
+
+// insertRunes inserts ins into runes at position pos
+func insertRunes(runes []rune, pos int, ins []rune) []rune {
+	result := make([]rune, 0, len(runes)+len(ins))
+	result = append(result, runes[:pos]...)
+	result = append(result, ins...)
+	return append(result, runes[pos:]...)
+}
+
+// lineStart returns the offset of the start of the line containing pos
+func lineStart(runes []rune, pos int) int {
+	for pos > 0 && runes[pos-1] != '\n' {
+		pos--
+	}
+	return pos
+}
+
+// lineEnd returns the offset of the end of the line containing pos
+func lineEnd(runes []rune, pos int) int {
+	for pos < len(runes) && runes[pos] != '\n' {
+		pos++
+	}
+	return pos
+}
+
+// moveLine moves the cursor to the same column on the previous (dir < 0) or next line
+func moveLine(runes []rune, pos, dir int) int {
+	start := lineStart(runes, pos)
+	col := pos - start
+
+	var target int
+	if dir < 0 {
+		if start == 0 {
+			return 0
+		}
+		target = lineStart(runes, start-1)
+	} else {
+		end := lineEnd(runes, pos)
+		if end == len(runes) {
+			return len(runes)
+		}
+		target = end + 1
+	}
+
+	if limit := lineEnd(runes, target); target+col > limit {
+		return limit
+	}
+	return target + col
+}
+
+// withCursor returns text with the cursor glyph inserted at a rune offset
+func withCursor(text string, cursor int) string {
+	runes := []rune(text)
+	if cursor > len(runes) {
+		cursor = len(runes)
+	}
+	return string(insertRunes(runes, cursor, []rune{editCursorRune}))
+}
+
+// scrollToCursor shortens text holding the cursor glyph to width cells, cutting from the
+// right and, when the cursor is further along than that, from the left as well. The cut
+// ends are marked with …
+func scrollToCursor(text string, width int) string {
+	if textWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	cursor := max(slices.Index(runes, editCursorRune), 0)
+	start := 0
+	for ; start < cursor; start++ {
+		need := textWidth(string(runes[start : cursor+1]))
+		if start > 0 {
+			need++ // Left …
+		}
+		if cursor+1 < len(runes) {
+			need++ // Right …
+		}
+		if need <= width {
+			break
+		}
+	}
+	if start > 0 {
+		text = "…" + string(runes[start:])
+	}
+	if textWidth(text) > width {
+		text = truncateWidth(text, width-1) + "…"
+	}
+	return text
+}
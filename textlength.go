@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultSoftLimit is how many characters node text gets before the edit counter warns
+const defaultSoftLimit = 80
+
+// editedText returns the text being edited as the node will show it, task checkbox included
+func (m Model) editedText() string {
+	if node := m.GetSelectedNode(); node != nil && m.Creating.Kind == CreateNone && !m.EditingNotes {
+		edited := *node
+		edited.Text = m.EditBuffer
+		return edited.displayText()
+	}
+	return m.EditBuffer
+}
+
+// overSoftLimit reports whether the node text being edited is longer than the configured soft
+// limit. Notes have no limit.
+func (m Model) overSoftLimit() bool {
+	return m.Config.SoftLimit > 0 && !m.EditingNotes && utf8.RuneCountInString(m.EditBuffer) > m.Config.SoftLimit
+}
+
+// editStatus describes the text being edited for the status bar: its words, characters and
+// the lines it wraps into at the current wrap width, counted the way the node is drawn
+func (m Model) editStatus() string {
+	chars := fmt.Sprint(utf8.RuneCountInString(m.EditBuffer))
+	if m.overSoftLimit() {
+		chars += fmt.Sprintf("/%d", m.Config.SoftLimit)
+	}
+	return fmt.Sprintf("%s · %s chars · %s", plural(len(strings.Fields(m.EditBuffer)), "word"), chars,
+		plural(len(wrapText(m.editedText(), m.editWrapWidth())), "line"))
+}
+
+// editWrapWidth returns the width the text being edited wraps at: the notes panel's for notes
+func (m Model) editWrapWidth() int {
+	if m.EditingNotes {
+		return m.notesWidth()
+	}
+	return m.WrapWidth
+}
+
+// longestNode returns the node with the most characters of text, or nil for an empty map.
+// Ties go to the lowest ID, so the same map always names the same node.
+func (m *Model) longestNode() *Node {
+	var longest *Node
+	length := 0
+	for _, node := range m.Nodes {
+		n := utf8.RuneCountInString(node.Text)
+		if longest == nil || n > length || (n == length && compareIDs(node.ID, longest.ID) < 0) {
+			longest, length = node, n
+		}
+	}
+	return longest
+}
+
+// plural returns a count with its noun, adding an s unless the count is one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// branchPalette holds the colors assigned to root's children. Nodes store these
+// values; other themes swap them for their own palette when drawing.
+var branchPalette = []string{
+	"#FF6B6B", // Red
+	"#4ECDC4", // Cyan
+	"#45B7D1", // Blue
+	"#FFA07A", // Light Salmon
+	"#98D8C8", // Mint
+	"#F7DC6F", // Yellow
+	"#BB8FCE", // Purple
+	"#85C1E2", // Sky Blue
+}
+
+// Theme holds every color the UI draws with. Colors are hex strings; lipgloss
+// downsamples them to the nearest color on 256- and 16-color terminals.
+type Theme struct {
+	Name    string
+	Palette []string // Replaces branchPalette entry by entry (nil keeps the stored colors)
+
+	// Status bar and overlays
+	Text       string // Status bar and help text
+	Background string // Status bar background
+	Overlay    string // Help overlay background
+	BadgeText  string // Text on the mode badge
+	Hint       string // Key hints
+	Message    string // Status messages
+	Info       string // File info and footers
+	Accent     string // Normal mode badge, help title and border
+
+	// Modes and highlights
+	Edit    string // Edit mode badge, help categories
+	Link    string // Link/move mode badges, help keys
+	Command string // Command mode badge
+	Danger  string // Confirm mode badge and the edge chosen in edge mode
+	Search  string // Search mode badge and matching nodes
+
+	// Node decorations
+	Tag         string // Tag line under a node's text
+	FilteredOut string // Nodes hidden by the tag filter
+	Done        string // Completed tasks and their subtrees
+}
+
+// darkTheme is the default theme for dark terminals
+var darkTheme = Theme{
+	Name:        "dark",
+	Text:        "#E0E0E0",
+	Background:  "#2A2A2A",
+	Overlay:     "#1A1A1A",
+	BadgeText:   "#000000",
+	Hint:        "#888888",
+	Message:     "#FFB86C",
+	Info:        "#666666",
+	Accent:      "#00D787",
+	Edit:        "#FFB86C",
+	Link:        "#FF79C6",
+	Command:     "#8BE9FD",
+	Danger:      "#FF5555",
+	Search:      "#F1FA8C",
+	Tag:         "#6C7086",
+	FilteredOut: "#3A3A3A",
+	Done:        "#6C7086",
+}
+
+// lightTheme uses darker, more saturated colors that stay readable on light backgrounds
+var lightTheme = Theme{
+	Name: "light",
+	Palette: []string{
+		"#C62828", // Red
+		"#00897B", // Cyan
+		"#1565C0", // Blue
+		"#D84315", // Salmon
+		"#2E7D32", // Mint
+		"#9E7C00", // Yellow
+		"#7B1FA2", // Purple
+		"#0277BD", // Sky Blue
+	},
+	Text:        "#1A1A1A",
+	Background:  "#D7D7D7",
+	Overlay:     "#F2F2F2",
+	BadgeText:   "#FFFFFF",
+	Hint:        "#555555",
+	Message:     "#A84300",
+	Info:        "#6C6C6C",
+	Accent:      "#00875F",
+	Edit:        "#C75000",
+	Link:        "#AD1457",
+	Command:     "#00838F",
+	Danger:      "#C62828",
+	Search:      "#8D6E00",
+	Tag:         "#8A8A8A",
+	FilteredOut: "#CCCCCC",
+	Done:        "#A0A0A0",
+}
+
+// themes lists the built-in themes by name
+var themes = map[string]Theme{
+	darkTheme.Name:  darkTheme,
+	lightTheme.Name: lightTheme,
+}
+
+// ThemeByName returns a built-in theme. "auto" (or an empty name) picks dark or light
+// from the terminal's background color.
+func ThemeByName(name string) (Theme, bool) {
+	name = strings.ToLower(name)
+	if name == "" || name == "auto" {
+		if lipgloss.HasDarkBackground() {
+			return darkTheme, true
+		}
+		return lightTheme, true
+	}
+	theme, ok := themes[name]
+	return theme, ok
+}
+
+// nodeColor maps a stored node color to the one drawn in this theme
+func (t Theme) nodeColor(color string) string {
+	if t.Palette == nil {
+		return color
+	}
+	for i, c := range branchPalette {
+		if c == color && i < len(t.Palette) {
+			return t.Palette[i]
+		}
+	}
+	return color
+}
+
+// SetTheme switches to a built-in theme
+func (m *Model) SetTheme(theme Theme) {
+	m.Theme = theme
+	for color := range m.styleCache {
+		delete(m.styleCache, color)
+	}
+}
+
+// ToggleTheme switches between the dark and light themes
+func (m *Model) ToggleTheme() {
+	if m.Theme.Name == darkTheme.Name {
+		m.SetTheme(lightTheme)
+	} else {
+		m.SetTheme(darkTheme)
+	}
+	m.setStatus(StatusInfo, "Theme: "+m.Theme.Name)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxTrash is how many deletions the trash keeps; older ones are dropped
+const maxTrash = 50
+
+// TrashEntry is a deleted node with its descendants and the edges that were cut with them
+type TrashEntry struct {
+	Nodes   []*Node   `json:"nodes"` // The deleted node first, then its descendants
+	Edges   []Edge    `json:"edges"`
+	Deleted time.Time `json:"deleted"`
+}
+
+// trashNodes puts copies of nodes that are about to be deleted in the trash, the
+// topmost first, along with every edge touching them
+func (m *Model) trashNodes(ids []string) {
+	inside := make(map[string]bool, len(ids))
+	entry := TrashEntry{Deleted: time.Now()}
+	for _, id := range ids {
+		if node := m.Nodes[id]; node != nil {
+			inside[id] = true
+			entry.Nodes = append(entry.Nodes, node.Clone())
+		}
+	}
+	if len(entry.Nodes) == 0 {
+		return
+	}
+	for _, edge := range m.Edges {
+		if inside[edge.FromID] || inside[edge.ToID] {
+			entry.Edges = append(entry.Edges, edge)
+		}
+	}
+
+	m.Trash = append(m.Trash, entry)
+	if len(m.Trash) > maxTrash {
+		m.Trash = m.Trash[len(m.Trash)-maxTrash:]
+	}
+}
+
+// RestoreTrash puts a trashed branch back where it was: under its old parent, in its old
+// place among the siblings, if the parent still exists, or floating otherwise. Edges come
+// back where both ends exist. An entry whose nodes are still in the map (say the deletion
+// was undone) is refused rather than restored a second time.
+func (m *Model) RestoreTrash(index int) {
+	if index < 0 || index >= len(m.Trash) {
+		return
+	}
+	entry := m.Trash[index]
+	top := entry.Nodes[0]
+	ids := make(map[string]bool, len(entry.Nodes))
+	for _, node := range entry.Nodes {
+		if m.Nodes[node.ID] != nil {
+			m.setStatus(StatusWarn, fmt.Sprintf("Can't restore node %s: node %s is already in the map", top.ID, node.ID))
+			return
+		}
+		ids[node.ID] = true
+	}
+	m.pushUndo(fmt.Sprintf("restore node %s", top.ID))
+
+	floatingOrder := m.nextOrder("")
+	for _, original := range entry.Nodes {
+		node := original.Clone()
+		node.Links = make([]string, 0)
+		m.Nodes[node.ID] = node
+	}
+	restored := m.Nodes[top.ID]
+	if m.Nodes[restored.ParentID] == nil {
+		restored.ParentID = ""
+		restored.Order = floatingOrder
+	}
+
+	for _, edge := range entry.Edges {
+		if m.Nodes[edge.FromID] != nil && m.Nodes[edge.ToID] != nil && !m.hasEdge(edge.FromID, edge.ToID) {
+			m.linkNodes(edge.FromID, edge.ToID)
+		}
+	}
+
+	// Links to these nodes cut by other deletions come back too, once both ends exist again
+	for i, other := range m.Trash {
+		if i == index {
+			continue
+		}
+		for _, edge := range other.Edges {
+			touches := ids[edge.FromID] || ids[edge.ToID]
+			if touches && m.Nodes[edge.FromID] != nil && m.Nodes[edge.ToID] != nil && !m.hasEdge(edge.FromID, edge.ToID) {
+				m.linkNodes(edge.FromID, edge.ToID)
+			}
+		}
+	}
+
+	m.Trash = slices.Delete(m.Trash, index, index+1)
+	m.expandTo(restored.ID)
+	m.Selected = restored.ID
+	m.centerOn(restored)
+	what := fmt.Sprintf("node %s", restored.ID)
+	if len(entry.Nodes) > 1 {
+		what += fmt.Sprintf(" and %d descendants", len(entry.Nodes)-1)
+	}
+	where := "as a floating node"
+	if restored.ParentID != "" {
+		where = "under " + restored.ParentID
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Restored %s %s", what, where))
+}
+
+// hasEdge reports whether there is an edge from one node to another
+func (m *Model) hasEdge(fromID, toID string) bool {
+	return slices.Contains(m.Edges, Edge{FromID: fromID, ToID: toID})
+}
+
+// ClearTrash empties the trash
+func (m *Model) ClearTrash() {
+	count := len(m.Trash)
+	if count == 0 {
+		m.setStatus(StatusInfo, "The trash is empty")
+		return
+	}
+	m.Trash = nil
+	m.TrashIndex = 0
+	m.ShowTrash = false
+	if m.Config.SaveTrash {
+		m.Dirty = true
+	}
+	m.setStatus(StatusInfo, fmt.Sprintf("Emptied the trash (%d entries)", count))
+}
+
+// commandTrash handles ":trash [clear]", listing the trash or emptying it
+func (m *Model) commandTrash(arg string) {
+	switch arg {
+	case "":
+		if len(m.Trash) == 0 {
+			m.setStatus(StatusInfo, "The trash is empty")
+			return
+		}
+		m.ShowTrash = true
+		m.TrashIndex = 0
+	case "clear":
+		m.ClearTrash()
+	default:
+		m.setStatus(StatusWarn, "Usage: :trash [clear]")
+	}
+}
+
+// handleTrashKey handles keys while the trash is listed: up/down choose an entry,
+// Enter restores it and Esc closes the list
+func (m Model) handleTrashKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.TrashIndex = max(m.TrashIndex-1, 0)
+	case "down", "j":
+		m.TrashIndex = min(m.TrashIndex+1, len(m.Trash)-1)
+	case "enter":
+		m.ShowTrash = false
+		m.RestoreTrash(len(m.Trash) - 1 - m.TrashIndex) // Listed newest first
+	case "esc", "q":
+		m.ShowTrash = false
+	}
+	return m, nil
+}
+
+// renderTrashOverlay lists the trash, newest first, with the chosen entry highlighted
+func (m Model) renderTrashOverlay() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(m.Theme.Accent))
+
+	chosenStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Link)).
+		Bold(true)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Text))
+
+	footerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.Theme.Info))
+
+	// Show a window of entries around the chosen one when they don't all fit
+	rows := max(1, m.Height-12)
+	first := min(max(0, m.TrashIndex-rows/2), max(0, len(m.Trash)-rows))
+
+	lines := []string{titleStyle.Render("🗑 Trash"), ""}
+	for i := first; i < min(first+rows, len(m.Trash)); i++ {
+		entry := m.Trash[len(m.Trash)-1-i]
+		desc := fmt.Sprintf("%s  %s", entry.Deleted.Format("Jan 2 15:04"), truncateWidth(singleLine(entry.Nodes[0].Text), 30))
+		if len(entry.Nodes) > 1 {
+			desc += fmt.Sprintf(" (+%d)", len(entry.Nodes)-1)
+		}
+		if i == m.TrashIndex {
+			lines = append(lines, chosenStyle.Render("▶ "+desc))
+		} else {
+			lines = append(lines, descStyle.Render("  "+desc))
+		}
+	}
+	lines = append(lines, "", footerStyle.Render("↑/↓ choose · Enter restore · Esc close · :trash clear empties it"))
+
+	return m.placeOverlay(strings.Join(lines, "\n"))
+}
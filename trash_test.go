@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestRestoreTrashAfterUndo(t *testing.T) {
+	m := newTestModel(t)
+	branch := addTestChild(&m, "0", "Branch")
+	addTestChild(&m, branch, "Leaf one")
+	addTestChild(&m, branch, "Leaf two")
+	before := len(m.Nodes)
+
+	m.DeleteNode(branch)
+	if len(m.Trash) != 1 {
+		t.Fatalf("trash has %d entries after delete, want 1", len(m.Trash))
+	}
+	m.Undo()
+	if len(m.Nodes) != before {
+		t.Fatalf("undo left %d nodes, want %d", len(m.Nodes), before)
+	}
+	if len(m.Trash) != 0 {
+		t.Fatalf("trash has %d entries after undo, want 0", len(m.Trash))
+	}
+
+	m.RestoreTrash(0)
+	if len(m.Nodes) != before {
+		t.Errorf("restore after undo left %d nodes, want %d", len(m.Nodes), before)
+	}
+
+	m.Redo()
+	if len(m.Trash) != 1 || len(m.Nodes) != 1 {
+		t.Fatalf("redo left %d nodes and %d trash entries, want 1 and 1", len(m.Nodes), len(m.Trash))
+	}
+	m.RestoreTrash(0)
+	if len(m.Nodes) != before || m.Nodes[branch] == nil || m.Nodes[branch].ParentID != "0" {
+		t.Errorf("restore after redo left %d nodes, want %d with %s under the root", len(m.Nodes), before, branch)
+	}
+	if len(m.Trash) != 0 {
+		t.Errorf("trash has %d entries after restore, want 0", len(m.Trash))
+	}
+}
+
+func TestRestoreTrashRefusesNodesStillInMap(t *testing.T) {
+	m := newTestModel(t)
+	branch := addTestChild(&m, "0", "Branch")
+	addTestChild(&m, branch, "Leaf")
+	m.DeleteNode(branch)
+
+	// Put the deleted nodes back behind the trash's back
+	for _, node := range m.Trash[0].Nodes {
+		m.Nodes[node.ID] = node.Clone()
+	}
+	before := len(m.Nodes)
+
+	m.RestoreTrash(0)
+	if len(m.Nodes) != before {
+		t.Errorf("restore duplicated the branch: %d nodes, want %d", len(m.Nodes), before)
+	}
+	if len(m.Trash) != 1 {
+		t.Errorf("refused entry was dropped from the trash")
+	}
+	if m.StatusLevel != StatusWarn {
+		t.Errorf("status level = %v, want a warning", m.StatusLevel)
+	}
+}
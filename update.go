@@ -2,12 +2,19 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Count prefixes and fast panning
+const (
+	maxCount      = 999 // Largest count a prefix can build up to
+	fastPanFactor = 5.0 // How much further H/J/K/L pan than h/j/k/l
+)
+
 // tickMsg is sent on each animation frame
 type tickMsg time.Time
 
@@ -20,166 +27,574 @@ func doTick() tea.Cmd {
 
 // Update handles messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var model tea.Model = m
+	var cmd tea.Cmd
+	prevStatus := m.StatusMsg
+	prevSelected := m.Selected
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
-		return m, nil
+		model = m
 
 	case tea.KeyMsg:
-		return m.handleKeyPress(msg)
+		model, cmd = m.handleKeyPress(msg)
+
+	case tea.MouseMsg:
+		model, cmd = m.handleMouse(msg)
+
+	case editorFinishedMsg:
+		m.finishEditor(msg)
+		model = m
+
+	case imageShownMsg:
+		if msg.Err != nil {
+			m.setStatus(StatusError, fmt.Sprintf("Couldn't show the image: %v", msg.Err))
+		}
+		model = m
+
+	case autosaveMsg:
+		m.autosave()
+		model, cmd = m, m.scheduleAutosave()
+
+	case recoveryMsg:
+		m.writeRecovery()
+		model, cmd = m, scheduleRecovery()
+
+	case statusExpiredMsg:
+		cmd = m.handleStatusExpired(msg)
+		model = m
 
 	case tickMsg:
-		// Update camera smoothly towards target
-		// smoothness: 0.2 = smooth, 0.5 = fast, adjust to preference
-		m.Camera.Update(0.25)
-		return m, doTick()
+		return m.handleTick()
 	}
 
+	// In follow mode the camera keeps up with every change of selection
+	m = model.(Model)
+	if m.Follow && m.Selected != prevSelected {
+		m.followSelection()
+	}
+
+	// Start the animation loop if this message set a new camera or layout target
+	if !m.Ticking && m.isAnimating() {
+		m.Ticking = true
+		cmd = tea.Batch(cmd, doTick())
+	}
+
+	// A new status message clears itself after a few seconds
+	if m.StatusMsg != prevStatus && m.StatusMsg != "" {
+		cmd = tea.Batch(cmd, m.expireStatus())
+	}
+	return m, cmd
+}
+
+// handleTick advances animations by one frame, scheduling another frame only while something moves
+func (m Model) handleTick() (tea.Model, tea.Cmd) {
+	// Update camera smoothly towards target
+	// smoothness: 0.2 = smooth, 0.5 = fast, adjust to preference
+	cameraMoving := m.Camera.Update(0.25)
+	layoutMoving := m.stepLayoutAnimation(0.3)
+
+	if cameraMoving || layoutMoving {
+		return m, doTick()
+	}
+	m.Ticking = false
 	return m, nil
 }
 
+// isAnimating reports whether the camera or any node still has to reach its target
+func (m *Model) isAnimating() bool {
+	return m.Camera.IsMoving() || len(m.LayoutTargets) > 0
+}
+
 // handleKeyPress processes keyboard input based on current mode
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Handle help overlay toggle (works in any mode)
-	if msg.String() == "?" {
-		m.ShowHelp = !m.ShowHelp
-		return m, nil
+	// The help overlay takes all keys while it is open
+	if m.ShowHelp {
+		return m.handleHelpKey(msg)
 	}
-
-	// Close help overlay with ESC if it's open
-	if m.ShowHelp && msg.String() == "esc" {
-		m.ShowHelp = false
+	if m.ShowStats {
+		m.ToggleStats()
 		return m, nil
 	}
-
-	// Don't process other keys if help is shown
-	if m.ShowHelp {
+	if m.ShowMarks {
+		m.ToggleMarks()
 		return m, nil
 	}
+	if m.ShowCheck {
+		return m.handleCheckKey()
+	}
+	if m.ShowTrash {
+		return m.handleTrashKey(msg)
+	}
+	if m.ShowTemplates {
+		return m.handleTemplateKey(msg)
+	}
+	if m.ShowRecent {
+		return m.handleRecentKey(msg)
+	}
+	if m.ShowMessages {
+		return m.handleMessagesKey(msg)
+	}
 
 	switch m.Mode {
 	case ModeNormal:
 		return m.handleNormalMode(msg)
 	case ModeEdit:
 		return m.handleEditMode(msg)
-	case ModeLink:
+	case ModeLink, ModeReparent:
 		return m.handleLinkMode(msg)
+	case ModeConfirm:
+		return m.handleConfirmMode(msg)
+	case ModeCommand:
+		return m.handleCommandMode(msg)
+	case ModeSearch:
+		return m.handleSearchMode(msg)
+	case ModeEdge:
+		return m.handleEdgeMode(msg)
+	case ModeHint:
+		return m.handleHintMode(msg)
+	case ModeVisual:
+		return m.handleVisualMode(msg)
+	case ModeMove:
+		return m.handleMoveMode(msg)
+	}
+	return m, nil
+}
+
+// handleMouse handles clicks, drags, and the scroll wheel
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.ShowHelp || m.ShowStats || m.ShowMarks || m.ShowCheck || m.ShowTrash || m.ShowTemplates || m.ShowRecent || m.ShowMessages || (m.Mode != ModeNormal && m.Mode != ModeLink && m.Mode != ModeReparent) {
+		return m, nil
 	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.Camera.ZoomIn()
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.Camera.ZoomOut()
+
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		if msg.Y >= m.canvasHeight() {
+			return m, nil // Click on the notes panel or status bar
+		}
+		node := m.GetNodeAt(msg.X, msg.Y)
+		if node == nil {
+			// Clicking empty space starts panning
+			m.Dragging = true
+			m.DragX, m.DragY = msg.X, msg.Y
+			return m, nil
+		}
+		m.clearStatus()
+		if m.Mode == ModeNormal {
+			m.Selected = node.ID
+		} else {
+			// Clicking a node in link mode picks it as the target
+			m.finishLink(node.ID)
+		}
+
+	case msg.Action == tea.MouseActionMotion && m.Dragging:
+		// Move the camera so the world point under the cursor follows it
+		x1, y1 := m.Camera.ScreenToWorld(m.DragX, m.DragY, m.Width, m.canvasHeight())
+		x2, y2 := m.Camera.ScreenToWorld(msg.X, msg.Y, m.Width, m.canvasHeight())
+		m.Camera.X += x1 - x2
+		m.Camera.Y += y1 - y2
+		m.Camera.TargetX = m.Camera.X
+		m.Camera.TargetY = m.Camera.Y
+		m.DragX, m.DragY = msg.X, msg.Y
+
+	case msg.Action == tea.MouseActionRelease:
+		m.Dragging = false
+	}
+
 	return m, nil
 }
 
 // handleNormalMode handles input in normal navigation mode
 func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	panSpeed := 5.0 / m.Camera.Zoom // Pan faster when zoomed out (increased from 2.0)
+	// Second key of a two-key command
+	if m.PendingKey != "" {
+		prefix := m.PendingKey
+		m.PendingKey = ""
+		if msg.String() == "esc" {
+			m.clearStatus()
+			return m, nil
+		}
+		return m.handlePrefixKey(prefix, msg.String())
+	}
 
-	switch msg.String() {
+	// Digits build a count for the next pan or zoom; any other key uses it up
+	key := msg.String()
+	if keyActions[key] == ActionCount || key == "0" && m.Count > 0 {
+		m.Count = min(m.Count*10+int(key[0]-'0'), maxCount)
+		return m, nil
+	}
+	count := max(m.Count, 1)
+	if m.Count > 0 {
+		m.Count = 0
+		if key == "esc" {
+			return m, nil
+		}
+	}
+
+	// Pan faster when zoomed out, and when a pan or zoom key is held down
+	accel := m.accelerate(keyActions[key], time.Now())
+	panSpeed := 5.0 / m.Camera.Zoom * float64(count) * accel
+
+	switch keyActions[key] {
 	// Quit
-	case "ctrl+c", "q":
+	case ActionForceQuit:
+		return m, tea.Quit
+	case ActionQuit:
+		return m.quit()
+	case ActionQuitDiscard:
+		removeRecovery(m.FileName()) // Changes were discarded on purpose
 		return m, tea.Quit
 
 	// Arrow keys: spatial node selection
-	case "up":
+	case ActionSelectUp:
 		m.selectNodeInDirection(0, -1)
-	case "down":
+	case ActionSelectDown:
 		m.selectNodeInDirection(0, 1)
-	case "left":
+	case ActionSelectLeft:
 		m.selectNodeInDirection(-1, 0)
-	case "right":
+	case ActionSelectRight:
 		m.selectNodeInDirection(1, 0)
 
+	// Reorder among siblings
+	case ActionMoveSiblingUp:
+		m.MoveSibling(-1)
+	case ActionMoveSiblingDown:
+		m.MoveSibling(1)
+
 	// WASD/vim keys: pan camera
-	case "w", "k":
+	case ActionPanUp:
 		m.Camera.Pan(0, -panSpeed)
-		m.StatusMsg = ""
-	case "s", "j":
+		m.clearStatus()
+	case ActionPanDown:
 		m.Camera.Pan(0, panSpeed)
-		m.StatusMsg = ""
-	case "a", "h":
+		m.clearStatus()
+	case ActionPanLeft:
 		m.Camera.Pan(-panSpeed, 0)
-		m.StatusMsg = ""
-	case "d", "l":
+		m.clearStatus()
+	case ActionPanRight:
 		m.Camera.Pan(panSpeed, 0)
-		m.StatusMsg = ""
+		m.clearStatus()
+
+	// Shifted vim keys pan five times as far
+	case ActionFastPanUp:
+		m.Camera.Pan(0, -panSpeed*fastPanFactor)
+		m.clearStatus()
+	case ActionFastPanDown:
+		m.Camera.Pan(0, panSpeed*fastPanFactor)
+		m.clearStatus()
+	case ActionFastPanLeft:
+		m.Camera.Pan(-panSpeed*fastPanFactor, 0)
+		m.clearStatus()
+	case ActionFastPanRight:
+		m.Camera.Pan(panSpeed*fastPanFactor, 0)
+		m.clearStatus()
+
+	// Page keys pan by a share of the screen, like vim's scrolling keys
+	case ActionHalfPageDown:
+		m.Camera.Pan(0, m.pageHeight()/2*float64(count))
+		m.clearStatus()
+	case ActionHalfPageUp:
+		m.Camera.Pan(0, -m.pageHeight()/2*float64(count))
+		m.clearStatus()
+	case ActionPageDown:
+		m.Camera.Pan(0, m.pageHeight()*float64(count))
+		m.clearStatus()
+	case ActionPageUp:
+		m.Camera.Pan(0, -m.pageHeight()*float64(count))
+		m.clearStatus()
+	case ActionLineDown:
+		m.Camera.Pan(0, float64(count)/m.Camera.Zoom)
+		m.clearStatus()
+	case ActionLineUp:
+		m.Camera.Pan(0, -float64(count)/m.Camera.Zoom)
+		m.clearStatus()
 
 	// Zoom
-	case "+", "=":
-		m.Camera.ZoomIn()
-		m.StatusMsg = ""
-	case "-", "_":
-		m.Camera.ZoomOut()
-		m.StatusMsg = ""
+	case ActionZoomIn:
+		m.zoomBy(math.Pow(1.2, float64(count)*zoomAccel(accel)))
+		m.clearStatus()
+	case ActionZoomOut:
+		m.zoomBy(math.Pow(0.8, float64(count)*zoomAccel(accel)))
+		m.clearStatus()
 
 	// Reset camera
-	case "0":
-		m.Camera = NewCamera()
-		m.StatusMsg = "Camera reset"
+	case ActionResetCamera:
+		m.ResetCamera()
+		m.setStatus(StatusInfo, "Camera reset")
 
 	// Node creation - Enter for sibling, Tab for child
-	case "enter":
-		m.Mode = ModeEdit
-		m.EditBuffer = ""
-		m.IsCreatingNode = true
-		m.IsCreatingChild = false
-		m.StatusMsg = "New sibling: type text and press Enter"
+	case ActionCreateSibling:
+		m.startCreate(CreateSibling)
+		m.setPrompt("New sibling: type text and press Enter")
 
-	case "tab":
-		m.Mode = ModeEdit
-		m.EditBuffer = ""
-		m.IsCreatingNode = true
-		m.IsCreatingChild = true
-		m.StatusMsg = "New child: type text and press Enter"
+	case ActionCreateChild:
+		m.startCreate(CreateChild)
+		m.setPrompt("New child: type text and press Enter")
+
+	// Insert a new level between the selected node and its parent
+	case ActionInsertParent:
+		if node := m.GetSelectedNode(); node == nil || node.ID == "0" {
+			m.setStatus(StatusWarn, "Cannot insert above the root node")
+		} else {
+			m.startCreate(CreateParent)
+			m.setPrompt("New parent: type text and press Enter")
+		}
 
 	// Edit selected node
-	case "e":
+	case ActionEdit:
+		if node := m.GetSelectedNode(); node != nil {
+			m.startEdit(node.Text)
+			m.setPrompt("Edit node text (ESC to cancel, Enter to save)")
+		}
+
+	// Edit selected node in $EDITOR
+	case ActionEditExternal:
+		if node := m.GetSelectedNode(); node != nil {
+			return m, m.openInEditor(node, false)
+		}
+	case ActionYank:
+		m.YankSelected(false)
+	case ActionYankBranch:
+		m.YankSelected(true)
+
+	// Notes: i shows the panel, Alt+N edits them there and Alt+I in $EDITOR, { and } scroll
+	case ActionToggleNotes:
+		m.ToggleNotes()
+	case ActionEditNotes:
 		if node := m.GetSelectedNode(); node != nil {
-			m.Mode = ModeEdit
-			m.EditBuffer = node.Text
-			m.IsCreatingNode = false
-			m.StatusMsg = "Edit node text (ESC to cancel, Enter to save)"
+			m.startEditNotes(node)
 		}
+	case ActionEditNotesExternal:
+		if node := m.GetSelectedNode(); node != nil {
+			m.ShowNotes = true
+			return m, m.openInEditor(node, true)
+		}
+	case ActionScrollNotesUp:
+		m.ScrollNotes(-1)
+	case ActionScrollNotesDown:
+		m.ScrollNotes(1)
 
 	// Delete selected node
-	case "x", "delete", "backspace":
+	case ActionDelete:
+		if node := m.GetSelectedNode(); node != nil {
+			m.requestDelete(node)
+		}
+
+	// Delete only the selected node, keeping its children
+	case ActionSplice:
+		if m.Selected != "" {
+			m.SpliceNode(m.Selected)
+		}
+
+	// Make the last change again on the selected node
+	case ActionRepeat:
+		m.RepeatLastAction()
+
+	// Tags: t adds/removes tags on the selected node, T filters by tag, Esc clears the filter
+	case ActionTag:
+		if m.Selected != "" {
+			m.startCommand("tag ")
+			m.setPrompt("Tags to add or remove (Tab completes)")
+		}
+	case ActionFilter:
+		m.startCommand("filter ")
+		m.setPrompt("Show only nodes with tag (Tab completes)")
+	case ActionClearFilter:
+		if m.TagFilter != "" {
+			m.ClearTagFilter()
+		}
+
+	// Tasks: space checks/unchecks the selected task (making it a task first)
+	case ActionToggleDone:
+		m.ToggleDone()
+
+	// Structural navigation: g p parent, g c first child, g s / g S next/previous sibling
+	case ActionStructural:
+		m.PendingKey = "g"
+		m.setPrompt("g: [p]arent [c]hild [s]ibling [S]previous sibling [i]d")
+
+	// Marks: the next key names the mark to set or jump to
+	case ActionMark:
+		m.PendingKey = "m"
+		m.setPrompt("Mark: press a letter to mark this node")
+	case ActionJumpMark:
+		m.PendingKey = "'"
+		m.setPrompt("Jump to mark: press its letter")
+
+	// Sort the selected node's children; the next key picks the order
+	case ActionSortChildren:
+		if m.Selected != "" {
+			m.PendingKey = "S"
+			m.setPrompt("Sort children: [a]lphabetical [r]everse [c]reation order")
+		}
+
+	// Template picker
+	case ActionTemplate:
+		m.OpenTemplates()
+
+	// Align the selected node's children; the next key picks how
+	case ActionAlign:
+		if m.Selected != "" {
+			m.PendingKey = "A"
+			m.setPrompt("Align children: [x] left edges [d] distribute [c] center on parent")
+		}
+
+	// Collapsing: the next key picks what to collapse or expand
+	case ActionCollapse:
+		m.PendingKey = "z"
+		m.setPrompt("z: [a] toggle branch [M] collapse all [R] expand all [1-3] show levels")
+
+	// Visual mode: select several nodes
+	case ActionVisual:
+		m.startVisual()
+
+	// Hint mode: label the visible nodes and jump to one by typing its label
+	case ActionHints:
+		m.startHintMode()
+
+	// Toggle minimap
+	case ActionMinimap:
+		m.ShowMinimap = !m.ShowMinimap
+	case ActionLegend:
+		m.ToggleLegend()
+
+	// Keep the camera on the selected node
+	case ActionFollow:
+		m.ToggleFollow()
+
+	// Count the selected branch's and the map's nodes, words and links
+	case ActionStats:
+		m.ToggleStats()
+
+	// Curved or right-angled edges
+	case ActionEdgeStyle:
+		m.ToggleEdgeStyle()
+
+	// Show each node's ID in its top border
+	case ActionToggleIDs:
+		m.ShowIDs = !m.ShowIDs
+		if m.ShowIDs {
+			m.setStatus(StatusInfo, "Showing node IDs")
+		} else {
+			m.setStatus(StatusInfo, "Hiding node IDs")
+		}
+
+	// Switch between dark and light themes
+	case ActionTheme:
+		m.ToggleTheme()
+
+	// Duplicate selected node (alt+d: with its subtree)
+	case ActionDuplicate, ActionDuplicateSubtree:
 		if m.Selected != "" {
-			m.DeleteNode(m.Selected)
+			m.DuplicateNode(m.Selected, keyActions[key] == ActionDuplicateSubtree)
 		}
 
 	// Create link
-	case "L":
+	case ActionLink:
 		if m.Selected != "" {
 			m.Mode = ModeLink
 			m.LinkSourceID = m.Selected
-			m.StatusMsg = "Select target node (ESC to cancel)"
+			m.setPrompt("Select target node (ESC to cancel)")
+		}
+
+	// Command line
+	case ActionCommand:
+		m.startCommand("")
+
+	// Help overlay
+	case ActionHelp:
+		m.ToggleHelp()
+
+	// Undo/redo
+	case ActionUndo:
+		m.Undo()
+	case ActionRedo:
+		m.Redo()
+
+	// Manage the selected node's edges
+	case ActionEdges:
+		if m.Selected != "" {
+			m.Mode = ModeEdge
+			m.EdgeIndex = 0
+			m.setPrompt(m.edgeStatus())
+		}
+
+	// Write a picture of the whole map to text files
+	case ActionSnapshot:
+		m.snapshot()
+
+	// Nudge the selected node into place
+	case ActionMoveMode:
+		m.startMoveMode()
+
+	// Move node under a different parent
+	case ActionReparent:
+		if m.Selected != "" {
+			m.Mode = ModeReparent
+			m.LinkSourceID = m.Selected
+			m.setPrompt("Select new parent (ESC to cancel)")
 		}
 
 	// Select nodes
-	case "]":
+	case ActionSelectNext:
 		m.selectNextNode()
-	case "[":
+	case ActionSelectPrev:
 		m.selectPrevNode()
 
 	// Center camera on selected node
-	case "c":
+	case ActionCenter:
 		if node := m.GetSelectedNode(); node != nil {
-			cx, cy := node.GetCenter()
-			m.Camera.TargetX = cx
-			m.Camera.TargetY = cy
-			m.StatusMsg = "Centered on node"
+			m.centerOn(node)
+			m.setStatus(StatusInfo, "Centered on node")
 		}
 
+	// Search
+	case ActionSearch:
+		m.Mode = ModeSearch
+		m.SearchQuery = ""
+		m.updateSearchMatches()
+		m.clearStatus()
+	case ActionSearchNext:
+		m.jumpToSearchMatch(1)
+	case ActionSearchPrev:
+		m.jumpToSearchMatch(-1)
+
+	// Re-layout the whole tree
+	case ActionRelayout:
+		m.AutoLayout()
+
+	// Fit the whole map on screen
+	case ActionFit:
+		m.FitToScreen()
+
+	// Zoom in on the selected node and its children
+	case ActionZoomSelection:
+		m.ZoomToSelection()
+
 	// Save/Load
-	case "ctrl+s":
-		if err := m.SaveToFile("mindmap.json"); err != nil {
-			m.StatusMsg = fmt.Sprintf("Error saving: %v", err)
+	case ActionSave:
+		if m.CurrentFile == "" {
+			m.startCommand("w ")
 		} else {
-			m.StatusMsg = "Saved to mindmap.json"
+			m.saveAs(m.CurrentFile, true)
 		}
-	case "ctrl+o":
-		if err := m.LoadFromFile("mindmap.json"); err != nil {
-			m.StatusMsg = fmt.Sprintf("Error loading: %v", err)
+	case ActionSaveAs:
+		m.startCommand("w ")
+	case ActionReload:
+		filename := m.FileName()
+		if err := m.OpenFile(filename); err != nil {
+			m.setStatus(StatusError, fmt.Sprintf("Error loading: %v", err))
 		} else {
-			m.StatusMsg = "Loaded from mindmap.json"
+			m.setStatus(m.loadStatusLevel(), fmt.Sprintf("Loaded from %s", filename)+m.loadWarningSuffix())
+			m.rememberFile()
+			m.offerRecovery()
 		}
 
 	}
@@ -189,79 +604,452 @@ func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // handleEditMode handles input when editing a node
 func (m Model) handleEditMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Paste {
+		// Text pasted through the terminal keeps its line breaks
+		m.pasteIntoEdit(string(msg.Runes))
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "esc":
-		m.Mode = ModeNormal
-		m.EditBuffer = ""
-		m.IsCreatingNode = false
-		m.StatusMsg = "Cancelled"
+		m.endEdit()
+		m.setStatus(StatusInfo, "Cancelled")
 		return m, nil
 
 	case "enter":
-		if m.EditBuffer != "" {
-			if m.IsCreatingNode {
-				// Creating new node - check if child or sibling
-				if m.IsCreatingChild {
-					m.AddChildNode(m.EditBuffer)
-				} else {
-					m.AddSiblingNode(m.EditBuffer)
-				}
-			} else {
-				// Editing existing node
-				if node := m.GetSelectedNode(); node != nil {
-					node.Text = m.EditBuffer
-					node.UpdateSize()
-					m.StatusMsg = "Node updated"
-				}
+		text, creating, notes := m.EditBuffer, m.Creating, m.EditingNotes
+		m.endEdit()
+		switch {
+		case notes:
+			if node := m.GetSelectedNode(); node != nil {
+				m.setNotes(node, text)
+			}
+		case strings.TrimSpace(text) == "" && creating.Kind != CreateNone:
+			m.setStatus(StatusWarn, "Empty text: no node created")
+		case strings.TrimSpace(text) == "":
+			m.setStatus(StatusWarn, "Empty text: node unchanged")
+		case creating.Kind != CreateNone:
+			m.applyCreate(creating, text)
+		default:
+			// Editing existing node
+			if node := m.GetSelectedNode(); node != nil {
+				m.setText(node, text)
 			}
 		}
-		m.Mode = ModeNormal
-		m.EditBuffer = ""
-		m.IsCreatingNode = false
-		m.IsCreatingChild = false
 		return m, nil
 
-	case "backspace":
-		if len(m.EditBuffer) > 0 {
-			m.EditBuffer = m.EditBuffer[:len(m.EditBuffer)-1]
-		}
+	case "ctrl+v":
+		m.PasteClipboard()
 
 	default:
-		// Add character to buffer
-		if len(msg.String()) == 1 {
-			m.EditBuffer += msg.String()
-		}
+		// Cursor movement, deletion, and typed characters (alt+enter inserts a newline)
+		m.EditBuffer, m.EditCursor, _ = applyTextKey(m.EditBuffer, m.EditCursor, msg, true)
 	}
 
 	return m, nil
 }
 
-// handleLinkMode handles input when creating a link
+// setText replaces a node's text as one undoable step
+func (m *Model) setText(node *Node, text string) {
+	m.pushUndo(fmt.Sprintf("edit node %s", node.ID))
+	node.Text = text
+	node.UpdateSize(m.WrapWidth)
+	m.remember(LastAction{Kind: RepeatEdit, Text: text})
+	m.setStatus(StatusInfo, "Node updated")
+}
+
+// startEdit enters edit mode with the given initial text and the cursor at its end
+func (m *Model) startEdit(text string) {
+	m.Mode = ModeEdit
+	m.EditBuffer = text
+	m.EditCursor = len([]rune(text))
+	m.Creating = CreateParams{}
+	m.EditingNotes = false
+}
+
+// startCreate enters edit mode to create a child or sibling of the selected node.
+// Where the node goes is decided now, so leaving edit mode either applies it all or nothing.
+func (m *Model) startCreate(kind CreateKind) {
+	m.startEdit("")
+	m.Creating = m.planCreate(kind)
+}
+
+// endEdit leaves edit mode, dropping the edit buffer and any pending creation
+func (m *Model) endEdit() {
+	m.Mode = ModeNormal
+	m.EditBuffer = ""
+	m.EditCursor = 0
+	m.Creating = CreateParams{}
+	m.EditingNotes = false
+}
+
+// handleLinkMode handles input when picking a target node for a link or a reparent
 func (m Model) handleLinkMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
+		if m.Mode == ModeReparent {
+			m.setStatus(StatusInfo, "Move cancelled")
+		} else {
+			m.setStatus(StatusInfo, "Link cancelled")
+		}
+		// Go back to the source rather than the candidate under the cursor
+		if _, ok := m.Nodes[m.LinkSourceID]; ok {
+			m.Selected = m.LinkSourceID
+		}
 		m.Mode = ModeNormal
 		m.LinkSourceID = ""
-		m.StatusMsg = "Link cancelled"
+		m.SelectedSet = nil
 		return m, nil
 
 	case "tab":
 		m.selectNextNode()
 	case "shift+tab":
 		m.selectPrevNode()
+	case "up":
+		m.selectNodeInDirection(0, -1)
+	case "down":
+		m.selectNodeInDirection(0, 1)
+	case "left":
+		m.selectNodeInDirection(-1, 0)
+	case "right":
+		m.selectNodeInDirection(1, 0)
 
 	case "enter":
-		if m.Selected != "" && m.LinkSourceID != "" && m.Selected != m.LinkSourceID {
-			m.AddEdge(m.LinkSourceID, m.Selected)
+		m.finishLink(m.Selected)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// requestDelete deletes a leaf node immediately, or asks first when the node has
+// descendants or cross-links pointing into what would be deleted
+func (m *Model) requestDelete(node *Node) {
+	descendants := m.GetDescendantsOf(node.ID)
+	crossLinks := m.incomingCrossLinks(node.ID, descendants)
+	if node.ID == "0" || len(descendants) == 0 && crossLinks == 0 {
+		m.DeleteNode(node.ID)
+		return
+	}
+
+	title := truncateWidth(singleLine(node.Text), 20)
+	var what string
+	switch {
+	case len(descendants) > 0 && crossLinks > 0:
+		what = fmt.Sprintf("'%s', %d descendants and %d incoming links", title, len(descendants), crossLinks)
+	case len(descendants) > 0:
+		what = fmt.Sprintf("'%s' and %d descendants", title, len(descendants))
+	default:
+		what = fmt.Sprintf("'%s' and %d incoming links", title, crossLinks)
+	}
+
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmDelete
+	m.ConfirmTarget = node.ID
+	m.ConfirmPrompt = fmt.Sprintf("Delete node %s? [y/N]", what)
+	if len(descendants) > 0 {
+		m.ConfirmPrompt += " ([r] keeps the children)"
+	}
+}
+
+// incomingCrossLinks counts edges from outside a node's subtree into it that aren't parent edges
+func (m *Model) incomingCrossLinks(id string, descendants []*Node) int {
+	inside := map[string]bool{id: true}
+	for _, descendant := range descendants {
+		inside[descendant.ID] = true
+	}
+
+	count := 0
+	for _, edge := range m.Edges {
+		if inside[edge.ToID] && !inside[edge.FromID] && !m.isChildOf(edge.ToID, edge.FromID) {
+			count++
+		}
+	}
+	return count
+}
+
+// handleConfirmMode handles the answer to a confirmation prompt
+func (m Model) handleConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if m.ConfirmAction == ConfirmQuit {
+		return m.answerQuit(key)
+	}
+	if m.ConfirmAction == ConfirmRecover {
+		return m.answerRecover(key)
+	}
+	if m.ConfirmAction == ConfirmResume {
+		return m.answerResume(key)
+	}
+	if key == "esc" || key == "n" {
+		m.endConfirm()
+		m.setStatus(StatusInfo, "Cancelled")
+		return m, nil
+	}
+
+	switch m.ConfirmAction {
+	case ConfirmReplace:
+		r := m.PendingReplace
+		m.endConfirm()
+		m.PendingReplace = Replacement{}
+		if key == "y" {
+			m.applyReplacement(r)
+		} else {
+			m.setStatus(StatusInfo, "Cancelled")
+		}
+	case ConfirmDeleteSet:
+		m.endConfirm()
+		if key == "y" {
+			m.DeleteSelected()
+		} else {
+			m.Mode = ModeVisual
+			m.setStatus(StatusInfo, "Cancelled")
+		}
+	case ConfirmDelete:
+		// Anything but an explicit yes (or reparent) keeps the node
+		id := m.ConfirmTarget
+		m.endConfirm()
+		switch {
+		case key == "y":
+			m.DeleteNode(id)
+		case key == "r" && len(m.GetChildrenOf(id)) > 0:
+			m.SpliceNode(id)
+		default:
+			m.setStatus(StatusInfo, "Cancelled")
 		}
+	case ConfirmOverwrite:
+		path := m.ConfirmTarget
+		m.endConfirm()
+		if key == "y" {
+			m.saveAs(path, true)
+		} else {
+			m.QuitAfterSave = false
+			m.setStatus(StatusInfo, "Cancelled")
+		}
+		return m.quitIfSaved()
+	}
+
+	return m, nil
+}
+
+// quit handles q: a clean map quits right away, changes are saved to the current file
+// (or asked about when save_on_quit is off), and an unnamed map asks where to save
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	switch {
+	case !m.Dirty:
+		return m, tea.Quit
+	case m.CurrentFile == "":
+		m.QuitAfterSave = true
+		m.startCommand("w ")
+		return m, nil
+	case m.Config.SaveOnQuit:
+		return m.saveAndQuit()
+	}
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmQuit
+	m.ConfirmPrompt = "Unsaved changes — save before quitting? [y/n/Esc]"
+	return m, nil
+}
+
+// saveAndQuit saves to the current file and quits, staying open if the save fails
+func (m Model) saveAndQuit() (tea.Model, tea.Cmd) {
+	if err := m.SaveToFile(m.CurrentFile); err != nil && !isBackupError(err) {
+		m.setStatus(StatusError, fmt.Sprintf("Error: %v", err))
+		return m, nil
+	}
+	m.rememberFile()
+	return m, tea.Quit
+}
+
+// quitIfSaved finishes a quit that was waiting for a file name: it quits once the
+// save went through and gives up if it failed or was cancelled
+func (m Model) quitIfSaved() (tea.Model, tea.Cmd) {
+	if !m.QuitAfterSave || m.Mode == ModeConfirm {
+		return m, nil // Still waiting, e.g. on the overwrite prompt
+	}
+	m.QuitAfterSave = false
+	if !m.Dirty {
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// answerQuit handles the answer to the unsaved-changes prompt shown when quitting
+func (m Model) answerQuit(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "y":
+		m.endConfirm()
+		return m.saveAndQuit()
+	case "n":
+		removeRecovery(m.FileName()) // Changes were discarded on purpose
+		return m, tea.Quit
+	case "esc":
+		m.endConfirm()
+		m.setStatus(StatusInfo, "Cancelled")
+	}
+	return m, nil
+}
+
+// endConfirm leaves confirmation mode and clears the pending prompt
+func (m *Model) endConfirm() {
+	m.Mode = ModeNormal
+	m.ConfirmAction = ConfirmNone
+	m.ConfirmTarget = ""
+	m.ConfirmPrompt = ""
+}
+
+// startCommand enters command mode with the given initial text
+func (m *Model) startCommand(initial string) {
+	m.Mode = ModeCommand
+	m.CommandBuffer = initial
+	m.CommandHistoryBack = 0
+	m.clearStatus()
+}
+
+// handleCommandMode handles input while typing a ':' command
+func (m Model) handleCommandMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
 		m.Mode = ModeNormal
-		m.LinkSourceID = ""
+		m.CommandBuffer = ""
+		m.SelectedSet = nil
+		if m.QuitAfterSave {
+			m.QuitAfterSave = false
+			m.setStatus(StatusWarn, "Not quitting (Q quits without saving)")
+		}
 		return m, nil
+
+	case tea.KeyEnter:
+		line := m.CommandBuffer
+		m.Mode = ModeNormal
+		m.CommandBuffer = ""
+		m.recordCommand(line)
+		cmd := m.executeCommand(line)
+		m.SelectedSet = nil
+		model, quitCmd := m.quitIfSaved()
+		return model, tea.Batch(cmd, quitCmd)
+
+	case tea.KeyBackspace:
+		if len(m.CommandBuffer) == 0 {
+			m.Mode = ModeNormal
+			m.QuitAfterSave = false
+			return m, nil
+		}
+		m.CommandBuffer = dropLastRune(m.CommandBuffer)
+
+	case tea.KeySpace:
+		m.CommandBuffer += " "
+
+	case tea.KeyTab:
+		m.CommandBuffer = m.completeCommand(m.CommandBuffer)
+
+	case tea.KeyUp:
+		m.browseCommandHistory(-1)
+	case tea.KeyDown:
+		m.browseCommandHistory(1)
+
+	case tea.KeyRunes:
+		m.CommandBuffer += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+// finishLink completes link or reparent mode with the chosen target node
+func (m *Model) finishLink(targetID string) {
+	if targetID != "" && m.LinkSourceID != "" && targetID != m.LinkSourceID {
+		if m.Mode == ModeReparent && len(m.SelectedSet) > 0 {
+			m.reparentSelected(targetID)
+			targetID = m.LinkSourceID
+		} else if m.Mode == ModeReparent {
+			m.ReparentNode(m.LinkSourceID, targetID)
+			targetID = m.LinkSourceID // Keep the moved node selected
+		} else {
+			m.AddEdge(m.LinkSourceID, targetID)
+		}
+	}
+	m.Selected = targetID
+	m.Mode = ModeNormal
+	m.LinkSourceID = ""
+	m.SelectedSet = nil
+}
+
+// handleEdgeMode handles input while choosing an edge to delete
+func (m Model) handleEdgeMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.Mode = ModeNormal
+		m.clearStatus()
+
+	case "tab", "right", "down", "j", "l":
+		m.cycleEdge(1)
+	case "shift+tab", "left", "up", "k", "h":
+		m.cycleEdge(-1)
+
+	case "x", "delete", "enter":
+		if idx := m.currentEdge(); idx >= 0 {
+			edge := m.Edges[idx]
+			m.DeleteEdge(edge.FromID, edge.ToID)
+			if len(m.edgesTouching(m.Selected)) == 0 {
+				m.Mode = ModeNormal
+			} else {
+				m.EdgeIndex = 0
+			}
+		}
 	}
 
 	return m, nil
 }
 
+// handleSearchMode handles input while typing a search query
+func (m Model) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.Mode = ModeNormal
+		m.SearchQuery = ""
+		m.updateSearchMatches()
+		m.setStatus(StatusInfo, "Search cancelled")
+		return m, nil
+
+	case tea.KeyEnter:
+		m.Mode = ModeNormal
+		if id := m.currentSearchMatch(); id != "" {
+			m.expandTo(id)
+			m.Selected = id
+			m.centerOn(m.Nodes[id])
+		}
+		m.setPrompt(m.searchStatus())
+		return m, nil
+
+	case tea.KeyTab, tea.KeyDown:
+		if len(m.SearchMatches) > 0 {
+			m.SearchIndex = (m.SearchIndex + 1) % len(m.SearchMatches)
+		}
+		return m, nil
+
+	case tea.KeyShiftTab, tea.KeyUp:
+		if len(m.SearchMatches) > 0 {
+			m.SearchIndex = (m.SearchIndex - 1 + len(m.SearchMatches)) % len(m.SearchMatches)
+		}
+		return m, nil
+
+	case tea.KeyBackspace:
+		m.SearchQuery = dropLastRune(m.SearchQuery)
+
+	case tea.KeySpace:
+		m.SearchQuery += " "
+
+	case tea.KeyRunes:
+		m.SearchQuery += string(msg.Runes)
+
+	default:
+		return m, nil
+	}
+
+	// Filter live as the query changes
+	m.updateSearchMatches()
+	return m, nil
+}
+
 // selectNextNode cycles to the next node
 func (m *Model) selectNextNode() {
 	if len(m.Nodes) == 0 {
@@ -270,7 +1058,9 @@ func (m *Model) selectNextNode() {
 
 	ids := make([]string, 0, len(m.Nodes))
 	for id := range m.Nodes {
-		ids = append(ids, id)
+		if !m.isHidden(id) {
+			ids = append(ids, id)
+		}
 	}
 
 	// Find current index
@@ -285,7 +1075,7 @@ func (m *Model) selectNextNode() {
 	// Select next
 	nextIdx := (currentIdx + 1) % len(ids)
 	m.Selected = ids[nextIdx]
-	m.StatusMsg = ""
+	m.clearStatus()
 }
 
 // selectPrevNode cycles to the previous node
@@ -296,7 +1086,9 @@ func (m *Model) selectPrevNode() {
 
 	ids := make([]string, 0, len(m.Nodes))
 	for id := range m.Nodes {
-		ids = append(ids, id)
+		if !m.isHidden(id) {
+			ids = append(ids, id)
+		}
 	}
 
 	// Find current index
@@ -314,91 +1106,118 @@ func (m *Model) selectPrevNode() {
 		prevIdx = len(ids) - 1
 	}
 	m.Selected = ids[prevIdx]
-	m.StatusMsg = ""
+	m.clearStatus()
 }
 
 // selectNodeInDirection selects the nearest node in the given direction using smart scoring
 func (m *Model) selectNodeInDirection(dx, dy float64) {
 	selectedNode := m.GetSelectedNode()
 	if selectedNode == nil {
+		// Nothing to move from: start at the node closest to the middle of the view
+		if nearest := m.nearestNode(m.Camera.X, m.Camera.Y); nearest != nil {
+			m.Selected = nearest.ID
+			m.setStatus(StatusInfo, fmt.Sprintf("Selected node %s, nearest the center", nearest.ID))
+		}
 		return
 	}
 
-	// Get center of current node
-	currentX, currentY := selectedNode.GetCenter()
-
 	var bestNode *Node
-	var bestScore float64 = -1
+	bestScore := -1.0
 
-	// Find the best node in the given direction
-	for _, node := range m.Nodes {
+	// Find the best node in the given direction; ties go to the lowest ID
+	consider := func(node *Node) {
 		if node.ID == m.Selected {
-			continue // Skip current node
+			return // Skip current node
 		}
-
-		// Get center of candidate node
-		nodeX, nodeY := node.GetCenter()
-
-		// Calculate relative position
-		relX := nodeX - currentX
-		relY := nodeY - currentY
-
-		// Check if node is in the right direction
-		inDirection := false
-		var primaryDist, secondaryDist float64
-
-		if dx != 0 { // Horizontal movement (left/right)
-			if (dx > 0 && relX > 0) || (dx < 0 && relX < 0) {
-				inDirection = true
-				primaryDist = absFloat(relX)     // Distance in direction of movement
-				secondaryDist = absFloat(relY)   // Distance perpendicular to movement
-			}
-		} else if dy != 0 { // Vertical movement (up/down)
-			if (dy > 0 && relY > 0) || (dy < 0 && relY < 0) {
-				inDirection = true
-				primaryDist = absFloat(relY)     // Distance in direction of movement
-				secondaryDist = absFloat(relX)   // Distance perpendicular to movement
-			}
-		}
-
-		if !inDirection {
-			continue
+		score, ok := directionScore(selectedNode, node, dx, dy)
+		if !ok {
+			return
 		}
-
-		// Score: prioritize nodes that are:
-		// 1. Aligned (low secondary distance)
-		// 2. Close (low primary distance)
-		// Lower score is better
-		score := secondaryDist*2.0 + primaryDist
-
-		// Update best node if this has a better score
-		if bestScore < 0 || score < bestScore {
+		if bestScore < 0 || score < bestScore || (score == bestScore && compareIDs(node.ID, bestNode.ID) < 0) {
 			bestScore = score
 			bestNode = node
 		}
 	}
 
+	// Search growing margins around the current node. A node whose box lies outside a
+	// margin of r is more than r away along one axis and so scores more than r; once the
+	// best score is within r no node further out can beat it.
+	left, top := selectedNode.X, selectedNode.Y
+	right, bottom := left+float64(selectedNode.Width), top+float64(selectedNode.Height)
+	minX, minY, maxX, maxY, _ := m.nodeBounds()
+	for r := spatialCellSize; ; r *= 2 {
+		for _, node := range m.nodesInRect(left-r, top-r, right+r, bottom+r) {
+			consider(node)
+		}
+		if bestNode != nil && bestScore <= r {
+			break
+		}
+		if left-r <= minX && top-r <= minY && right+r >= maxX && bottom+r >= maxY {
+			break // The margin covers the whole map
+		}
+	}
+
 	// Select the best node found
 	if bestNode != nil {
 		m.Selected = bestNode.ID
-		m.StatusMsg = ""
+		m.clearStatus()
+	}
+}
+
+// misalignedPenalty is added to the score of a node that doesn't overlap the current one
+// across the direction of travel, so a node straight ahead wins over one off to the side
+// unless it is much further away
+const misalignedPenalty = 30.0
+
+// directionScore rates a move from one node to another in direction (dx, dy), lower being
+// better. Only nodes whose center lies that way count. Distances are between the boxes'
+// edges: the gap ahead, plus twice the gap to the side, plus misalignedPenalty when the
+// boxes don't overlap sideways at all.
+func directionScore(from, to *Node, dx, dy float64) (float64, bool) {
+	fromX, fromY := from.GetCenter()
+	toX, toY := to.GetCenter()
+
+	// Edges as [start, end) along the direction of travel (a) and across it (b)
+	fromA, fromAEnd, toA, toAEnd := from.X, from.X+float64(from.Width), to.X, to.X+float64(to.Width)
+	fromB, fromBEnd, toB, toBEnd := from.Y, from.Y+float64(from.Height), to.Y, to.Y+float64(to.Height)
+	ahead := toX - fromX
+	dir := dx
+	if dx == 0 {
+		fromA, fromAEnd, fromB, fromBEnd = fromB, fromBEnd, fromA, fromAEnd
+		toA, toAEnd, toB, toBEnd = toB, toBEnd, toA, toAEnd
+		ahead, dir = toY-fromY, dy
+	}
+	if ahead*dir <= 0 {
+		return 0, false
 	}
+
+	gap := max(0, toA-fromAEnd)
+	if dir < 0 {
+		gap = max(0, fromA-toAEnd)
+	}
+	side := max(0, toB-fromBEnd, fromB-toBEnd)
+	score := gap + 2*side
+	if toB >= fromBEnd || fromB >= toBEnd {
+		score += misalignedPenalty
+	}
+	return score, true
 }
 
-// absFloat returns absolute value of float64
-func absFloat(x float64) float64 {
-	if x < 0 {
-		return -x
+// dropLastRune removes the final character from s
+func dropLastRune(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
 	}
-	return x
+	return string(runes[:len(runes)-1])
 }
 
-// trimString trims a string to max length with ellipsis
+// trimString trims a string to max display width with ellipsis
 func trimString(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if textWidth(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return truncateWidth(s, maxLen-3) + "..."
 }
 
 // ellipsis adds ellipsis if string is too long
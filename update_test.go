@@ -0,0 +1,264 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pressKey sends one key press and returns the updated model and its command
+func pressKey(m Model, key string) (Model, tea.Cmd) {
+	updated, cmd := m.Update(keyMsg(key))
+	return updated.(Model), cmd
+}
+
+// quits reports whether a command (or any in a batch) quits the program. Timers
+// and other commands that don't return right away don't quit.
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	if reflect.ValueOf(cmd).Pointer() == reflect.ValueOf(tea.Quit).Pointer() {
+		return true
+	}
+	msgs := make(chan tea.Msg, 1)
+	go func() { msgs <- cmd() }()
+	select {
+	case msg := <-msgs:
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			return slices.ContainsFunc(batch, quits)
+		}
+		_, ok := msg.(tea.QuitMsg)
+		return ok
+	case <-time.After(50 * time.Millisecond):
+		return false
+	}
+}
+
+// quitTestModel returns a map with an unsaved child, saved to path first unless path is empty
+func quitTestModel(t *testing.T, path string) Model {
+	t.Helper()
+	m := newTestModel(t)
+	if path != "" {
+		m.CurrentFile = path
+		if err := m.SaveToFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m = press(m, "tab", "N", "e", "w", "enter")
+	if !m.Dirty {
+		t.Fatal("creating a node didn't mark the map dirty")
+	}
+	return m
+}
+
+// savedNodeCount returns how many nodes the file at path holds, or -1 if it can't be read
+func savedNodeCount(t *testing.T, path string) int {
+	t.Helper()
+	if _, err := os.Stat(path); err != nil {
+		return -1
+	}
+	return len(loadTestMap(t, path).Nodes)
+}
+
+func TestQuit(t *testing.T) {
+	tests := []struct {
+		name       string
+		withFile   bool
+		saveOnQuit bool
+		keys       []string
+		wantQuit   bool
+		wantSaved  int // Nodes in the file afterwards, -1 for no file
+	}{
+		{"save on quit", true, true, []string{"q"}, true, 2},
+		{"ask, then save", true, false, []string{"q", "y"}, true, 2},
+		{"ask, then discard", true, false, []string{"q", "n"}, true, 1},
+		{"ask, then stay", true, false, []string{"q", "esc"}, false, 1},
+		{"discard with Q", true, true, []string{"Q"}, true, 1},
+		{"force quit", true, true, []string{"ctrl+c"}, true, 1},
+		{"no file, discard with Q", false, true, []string{"Q"}, true, -1},
+		{"no file, cancel the file name", false, true, []string{"q", "esc"}, false, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "map.json")
+			file := ""
+			if tt.withFile {
+				file = path
+			}
+			m := quitTestModel(t, file)
+			m.Config.SaveOnQuit = tt.saveOnQuit
+
+			var cmd tea.Cmd
+			for _, key := range tt.keys {
+				m, cmd = pressKey(m, key)
+			}
+			if got := quits(cmd); got != tt.wantQuit {
+				t.Errorf("quit = %v, want %v", got, tt.wantQuit)
+			}
+			if got := savedNodeCount(t, path); got != tt.wantSaved {
+				t.Errorf("file holds %d nodes, want %d", got, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestQuitCleanMap(t *testing.T) {
+	m := newTestModel(t)
+	if _, cmd := pressKey(m, "q"); !quits(cmd) {
+		t.Error("q on a map without changes didn't quit")
+	}
+}
+
+func TestQuitAsksForFileName(t *testing.T) {
+	m := quitTestModel(t, "")
+	m, cmd := pressKey(m, "q")
+	if quits(cmd) || m.Mode != ModeCommand || !m.QuitAfterSave {
+		t.Fatalf("q without a file gave mode %v, want a file name prompt", m.Mode)
+	}
+
+	path := filepath.Join(t.TempDir(), "named.json")
+	m, _ = pressKey(m, path)
+	m, cmd = pressKey(m, "enter")
+	if !quits(cmd) {
+		t.Errorf("didn't quit after saving; status %q", m.StatusMsg)
+	}
+	if got := savedNodeCount(t, path); got != 2 {
+		t.Errorf("file holds %d nodes, want 2", got)
+	}
+}
+
+func TestQuitStaysOpenWhenSaveFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	m := quitTestModel(t, path)
+	m.CurrentFile = filepath.Join(t.TempDir(), "missing", "map.json")
+
+	m, cmd := pressKey(m, "q")
+	if quits(cmd) {
+		t.Error("quit although the save failed")
+	}
+	if m.StatusLevel != StatusError || !m.Dirty {
+		t.Errorf("got status %q (level %v), dirty %v; want an error and the changes kept", m.StatusMsg, m.StatusLevel, m.Dirty)
+	}
+}
+
+// rectNode returns a node with the given box and no parent
+func rectNode(id string, x, y float64, width, height int) *Node {
+	return &Node{ID: id, Text: id, X: x, Y: y, Width: width, Height: height}
+}
+
+func TestDirectionScore(t *testing.T) {
+	from := rectNode("a", 0, 0, 10, 3)
+	tests := []struct {
+		name   string
+		to     *Node
+		dx, dy float64
+		want   float64
+		ok     bool
+	}{
+		{"straight ahead", rectNode("b", 15, 0, 10, 3), 1, 0, 5, true},
+		{"overlapping ahead", rectNode("b", 5, 1, 10, 3), 1, 0, 0, true},
+		{"containing the current node", rectNode("b", -5, -2, 30, 8), 1, 0, 0, true},
+		{"offset, still overlapping sideways", rectNode("b", 15, 2, 10, 3), 1, 0, 5, true},
+		{"offset, touching sideways", rectNode("b", 15, 3, 10, 3), 1, 0, 5 + misalignedPenalty, true},
+		{"offset past the side", rectNode("b", 15, 5, 10, 3), 1, 0, 5 + 2*2 + misalignedPenalty, true},
+		{"behind", rectNode("b", -15, 0, 10, 3), 1, 0, 0, false},
+		{"level with the center", rectNode("b", 0, 10, 10, 3), 1, 0, 0, false},
+		{"below", rectNode("b", 0, 5, 10, 3), 0, 1, 2, true},
+		{"above and off to the right", rectNode("b", 20, -10, 10, 3), 0, -1, 7 + 2*10 + misalignedPenalty, true},
+		{"left, overlapping sideways", rectNode("b", -20, -1, 12, 3), -1, 0, 8, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := directionScore(from, tt.to, tt.dx, tt.dy)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("directionScore = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSelectNodeInDirection(t *testing.T) {
+	tests := []struct {
+		name   string
+		others []*Node
+		dx, dy float64
+		want   string
+	}{
+		{
+			name:   "straight ahead beats nearer but off to the side",
+			others: []*Node{rectNode("1", 40, 0, 10, 3), rectNode("2", 12, 4, 10, 3)},
+			dx:     1, want: "1",
+		},
+		{
+			name:   "tall node overlapping sideways beats a nearer center",
+			others: []*Node{rectNode("1", 20, -10, 10, 30), rectNode("2", 14, 6, 10, 3)},
+			dx:     1, want: "1",
+		},
+		{
+			name:   "ties go to the lowest ID",
+			others: []*Node{rectNode("12", 15, 5, 10, 3), rectNode("3", 15, -5, 10, 3)},
+			dx:     1, want: "3",
+		},
+		{
+			name:   "far beyond the first search margin",
+			others: []*Node{rectNode("1", 0, 500, 10, 3), rectNode("2", -300, 0, 10, 3)},
+			dy:     1, want: "1",
+		},
+		{
+			name:   "nothing that way",
+			others: []*Node{rectNode("1", 20, 0, 10, 3)},
+			dx:     -1, want: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			m.Nodes = map[string]*Node{"0": rectNode("0", 0, 0, 10, 3)}
+			for _, node := range tt.others {
+				m.Nodes[node.ID] = node
+			}
+			m.invalidateSpatialIndex()
+			m.Selected = "0"
+
+			m.selectNodeInDirection(tt.dx, tt.dy)
+			if m.Selected != tt.want {
+				t.Errorf("selected %s, want %s", m.Selected, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineScrollKeys(t *testing.T) {
+	m := newTestModel(t)
+	y := m.Camera.TargetY
+	m = press(m, "ctrl+e")
+	if m.Camera.TargetY <= y {
+		t.Errorf("ctrl+e moved the camera from %v to %v, want it further down", y, m.Camera.TargetY)
+	}
+	m = press(m, "ctrl+y")
+	if m.Camera.TargetY != y {
+		t.Errorf("ctrl+y left the camera at %v, want %v", m.Camera.TargetY, y)
+	}
+}
+
+func TestZoomInKeys(t *testing.T) {
+	for _, key := range []string{"+", "="} {
+		m := newTestModel(t)
+		zoom := m.Camera.TargetZoom
+		if m = press(m, key); m.Camera.TargetZoom <= zoom {
+			t.Errorf("%s left the zoom at %v, want more than %v", key, m.Camera.TargetZoom, zoom)
+		}
+	}
+	m := newTestModel(t)
+	addTestChild(&m, m.Selected, "child")
+	zoom := m.Camera.TargetZoom
+	if m = press(m, "A"); m.Camera.TargetZoom != zoom || m.PendingKey != "A" {
+		t.Errorf("A zoomed to %v with pending key %q, want it to start an alignment", m.Camera.TargetZoom, m.PendingKey)
+	}
+}
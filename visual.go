@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startVisual enters visual mode with the selected node as the only member of the set
+func (m *Model) startVisual() {
+	if m.Selected == "" {
+		return
+	}
+	m.Mode = ModeVisual
+	m.SelectedSet = map[string]bool{m.Selected: true}
+	m.clearStatus()
+}
+
+// endVisual goes back to single selection
+func (m *Model) endVisual() {
+	m.Mode = ModeNormal
+	m.SelectedSet = nil
+}
+
+// isSelected reports whether a node is drawn as selected: the cursor, or any member of
+// the visual selection
+func (m Model) isSelected(id string) bool {
+	return id == m.Selected || m.SelectedSet[id]
+}
+
+// selectedIDs returns the members of the visual selection in ID order, or just the
+// selected node outside visual selection
+func (m *Model) selectedIDs() []string {
+	if len(m.SelectedSet) == 0 {
+		if m.Selected == "" {
+			return nil
+		}
+		return []string{m.Selected}
+	}
+	ids := make([]string, 0, len(m.SelectedSet))
+	for id := range m.SelectedSet {
+		if m.Nodes[id] != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// topmostSelected returns the selected nodes that aren't below another selected node;
+// operations on whole subtrees only need to touch these
+func (m *Model) topmostSelected() []string {
+	ids := m.selectedIDs()
+	inSet := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inSet[id] = true
+	}
+	var topmost []string
+	for _, id := range ids {
+		covered := false
+		visited := map[string]bool{id: true}
+		for parent := m.Nodes[id].ParentID; parent != "" && !visited[parent]; parent = m.Nodes[parent].ParentID {
+			if inSet[parent] {
+				covered = true
+				break
+			}
+			visited[parent] = true
+			if m.Nodes[parent] == nil {
+				break
+			}
+		}
+		if !covered {
+			topmost = append(topmost, id)
+		}
+	}
+	return topmost
+}
+
+// batch runs fn as a single undo step, however many changes it makes
+func (m *Model) batch(label string, fn func()) {
+	m.pushUndo(label)
+	m.inBatch = true
+	defer func() { m.inBatch = false }()
+	fn()
+}
+
+// handleVisualMode handles input while building a multi-node selection
+func (m Model) handleVisualMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	extend := func(dx, dy float64) {
+		m.selectNodeInDirection(dx, dy)
+		if m.Selected != "" {
+			m.SelectedSet[m.Selected] = true
+		}
+	}
+
+	switch msg.String() {
+	case "esc", "v":
+		m.endVisual()
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "up":
+		extend(0, -1)
+	case "down":
+		extend(0, 1)
+	case "left":
+		extend(-1, 0)
+	case "right":
+		extend(1, 0)
+	case " ":
+		// Toggle the node under the cursor
+		if m.SelectedSet[m.Selected] {
+			delete(m.SelectedSet, m.Selected)
+		} else if m.Selected != "" {
+			m.SelectedSet[m.Selected] = true
+		}
+
+	case "x", "delete", "backspace":
+		m.requestDeleteSelected()
+	case "C":
+		m.RecolorSelected()
+		m.endVisual()
+	case "t":
+		m.startCommand("tag ")
+		m.setPrompt(fmt.Sprintf("Tags to add or remove on %d nodes (Tab completes)", len(m.SelectedSet)))
+	case "m", "P":
+		m.Mode = ModeReparent
+		m.LinkSourceID = m.Selected
+		m.setPrompt(fmt.Sprintf("Select new parent for %d nodes (ESC to cancel)", len(m.SelectedSet)))
+	}
+	return m, nil
+}
+
+// requestDeleteSelected asks before deleting every selected node and its subtree
+func (m *Model) requestDeleteSelected() {
+	ids := m.topmostSelected()
+	ids = slices.DeleteFunc(ids, func(id string) bool { return id == "0" })
+	if len(ids) == 0 {
+		m.setStatus(StatusWarn, "Cannot delete root node")
+		return
+	}
+
+	descendants := 0
+	for _, id := range ids {
+		descendants += len(m.GetDescendantsOf(id))
+	}
+	what := fmt.Sprintf("%d nodes", len(ids))
+	if descendants > 0 {
+		what += fmt.Sprintf(" and %d descendants", descendants)
+	}
+	m.Mode = ModeConfirm
+	m.ConfirmAction = ConfirmDeleteSet
+	m.ConfirmPrompt = fmt.Sprintf("Delete %s? [y/N]", what)
+}
+
+// DeleteSelected deletes every selected node with its subtree as one undo step
+func (m *Model) DeleteSelected() {
+	ids := m.topmostSelected()
+	cursor := m.GetSelectedNode()
+
+	deleted := 0
+	m.batch(fmt.Sprintf("delete %d nodes", len(ids)), func() {
+		for _, id := range ids {
+			if id == "0" {
+				continue
+			}
+			deleted += 1 + len(m.GetDescendantsOf(id))
+			m.removeNodesUnder(id)
+		}
+	})
+	m.SelectedSet = nil
+	if cursor != nil {
+		m.selectAfterDelete(cursor)
+	}
+	m.remember(LastAction{Kind: RepeatDelete})
+	m.setStatus(StatusInfo, fmt.Sprintf("Deleted %d nodes", deleted))
+}
+
+// removeNodesUnder removes a node and all of its descendants
+func (m *Model) removeNodesUnder(id string) {
+	ids := []string{id}
+	for _, descendant := range m.GetDescendantsOf(id) {
+		ids = append(ids, descendant.ID)
+	}
+	m.trashNodes(ids)
+	m.removeNodes(ids)
+}
+
+// RecolorSelected gives the selected nodes the palette color after the cursor node's
+func (m *Model) RecolorSelected() {
+	ids := m.selectedIDs()
+	node := m.GetSelectedNode()
+	if node == nil || len(ids) == 0 {
+		return
+	}
+
+	next := 0
+	if i := slices.Index(m.ColorPalette, node.Color); i >= 0 {
+		next = (i + 1) % len(m.ColorPalette)
+	}
+	m.recolor(ids, m.ColorPalette[next])
+}
+
+// recolor gives nodes a color picked by hand, as one undo step
+func (m *Model) recolor(ids []string, color string) {
+	m.batch(fmt.Sprintf("recolor %d nodes", len(ids)), func() {
+		for _, id := range ids {
+			m.Nodes[id].Color = color
+			m.Nodes[id].OwnColor = true
+		}
+	})
+	m.remember(LastAction{Kind: RepeatRecolor, Color: color})
+	m.setStatus(StatusInfo, fmt.Sprintf("Recolored %d nodes", len(ids)))
+}
+
+// reparentSelected moves every selected subtree under a new parent as one undo step
+func (m *Model) reparentSelected(newParentID string) {
+	ids := m.topmostSelected()
+	moved := 0
+	m.batch(fmt.Sprintf("reparent %d nodes", len(ids)), func() {
+		for _, id := range ids {
+			before := m.Nodes[id].ParentID
+			m.ReparentNode(id, newParentID)
+			if m.Nodes[id].ParentID != before {
+				moved++
+			}
+		}
+	})
+	if moved < len(ids) {
+		m.setStatus(StatusInfo, fmt.Sprintf("Moved %d of %d nodes under %s", moved, len(ids), newParentID))
+	} else {
+		m.setStatus(StatusInfo, fmt.Sprintf("Moved %d nodes under %s", moved, newParentID))
+	}
+	m.SelectedSet = nil
+}